@@ -2,6 +2,8 @@ package app
 
 import (
 	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/diogopedro/shotgun/internal/core/builder"
 )
 
 // App represents the main application
@@ -9,13 +11,25 @@ type App struct {
 	state *AppState
 }
 
-// NewApplication creates a new application instance
+// NewApplication creates a new application instance scanning the current directory
 func NewApplication() *App {
 	return &App{
 		state: NewApp(),
 	}
 }
 
+// NewApplicationWithRoots creates an application scanning the given project roots,
+// as passed on the command line (shotgun [path...]); none means the current directory
+func NewApplicationWithRoots(paths []string) (*App, error) {
+	roots, err := builder.ResolveRoots(paths)
+	if err != nil {
+		return nil, err
+	}
+	state := NewApp()
+	state.Roots = roots
+	return &App{state: state}, nil
+}
+
 // Run starts the application
 func (app *App) Run() error {
 	p := tea.NewProgram(app.state, tea.WithAltScreen())
@@ -2,12 +2,14 @@ package app
 
 import (
 	"context"
+	"path/filepath"
 
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/diogopedro/shotgun/internal/components/help"
 	"github.com/diogopedro/shotgun/internal/components/progress"
+	"github.com/diogopedro/shotgun/internal/core/builder"
 	tmplcore "github.com/diogopedro/shotgun/internal/core/template"
 	"github.com/diogopedro/shotgun/internal/models"
 	"github.com/diogopedro/shotgun/internal/screens/confirm"
@@ -92,7 +94,10 @@ type AppState struct {
 	Help help.HelpModel
 
 	// Shared data across screens
+	Roots            []string // Absolute project directories scanned into the file tree; the first receives output
 	SelectedFiles    []string
+	RenderModes      map[string]models.RenderMode // Non-default per-file render modes
+	Slices           map[string][]builder.Slice   // Line ranges and symbols requested by task @mentions
 	SelectedTemplate *models.Template
 	TaskContent      string
 	RulesContent     string
@@ -133,6 +138,7 @@ func NewApp() *AppState {
 
 	app := &AppState{
 		CurrentScreen:    FileTreeScreen,
+		Roots:            []string{currentDir()},
 		SelectedFiles:    make([]string, 0),
 		SelectedTemplate: nil,
 		TaskContent:      "",
@@ -241,6 +247,7 @@ func (a *AppState) saveCurrentScreenState() {
 	switch a.CurrentScreen {
 	case FileTreeScreen:
 		a.SelectedFiles = a.FileTree.GetSelectedFiles()
+		a.RenderModes = a.FileTree.GetRenderModes()
 	case TemplateScreen:
 		a.SelectedTemplate = a.Template.GetSelected()
 	case TaskScreen:
@@ -273,7 +280,50 @@ func (a *AppState) loadScreenState(screen ScreenType) {
 // buildConfirmationSummary creates a summary for the confirmation screen
 func (a *AppState) buildConfirmationSummary() {
 	// Set the confirmation data using the proper method
-	a.Confirmation.SetData(a.SelectedTemplate, a.SelectedFiles, a.TaskContent, a.RulesContent)
+	a.Confirmation.SetData(a.SelectedTemplate, a.promptFiles(), a.TaskContent, a.RulesContent)
+	a.Confirmation.SetRenderModes(a.RenderModes)
+	a.Confirmation.SetSlices(a.Slices)
+	a.Confirmation.SetPathRoots(a.Roots)
+}
+
+// currentDir returns the absolute working directory, the default project root
+func currentDir() string {
+	root, err := filepath.Abs(".")
+	if err != nil {
+		return "."
+	}
+	return root
+}
+
+// primaryRoot returns the first project root, which @mentions are resolved against
+// and prompts are written to
+func (a *AppState) primaryRoot() string {
+	if len(a.Roots) == 0 {
+		return currentDir()
+	}
+	return a.Roots[0]
+}
+
+// promptFiles returns the selected files plus any files @mentioned in the task,
+// recording the slices the mentions ask for
+func (a *AppState) promptFiles() []string {
+	mentioned, slices := builder.ResolveMentions(a.TaskContent, a.primaryRoot())
+	a.Slices = slices
+	if len(mentioned) == 0 {
+		return a.SelectedFiles
+	}
+
+	files := append([]string(nil), a.SelectedFiles...)
+	seen := make(map[string]bool, len(files))
+	for _, f := range files {
+		seen[f] = true
+	}
+	for _, f := range mentioned {
+		if !seen[f] {
+			files = append(files, f)
+		}
+	}
+	return files
 }
 
 // initializeGenerationScreen prepares the generation screen with current app state
@@ -18,7 +18,7 @@ func (a *AppState) Init() tea.Cmd {
 		a.FileTree.Init(),
 		// Auto-start file scanning for the initial screen
 		a.FileTree.StartScanning(),
-		a.FileTree.LoadFromScanner(a.ctx, "."),
+		a.FileTree.LoadRoots(a.ctx, a.Roots),
 	)
 }
 
@@ -40,6 +40,11 @@ func (a *AppState) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return a.handleExitDialog(msg)
 		}
 
+		// The file tree filter takes Esc and, while typing, plain keys before global handling
+		if a.CurrentScreen == FileTreeScreen && a.FileTree.CapturesKey(normalizeKey(msg)) {
+			return a.handleScreenInput(msg)
+		}
+
 		// Check for global keys (robust to platform-specific key types)
 		if IsGlobalKey(normalizeKey(msg)) || isFunctionKeyMsg(msg) {
 			return a.GlobalKeyHandler(msg)
@@ -236,10 +241,13 @@ func (a *AppState) handleConfirmationInput(msg tea.KeyMsg) (tea.Model, tea.Cmd)
 	if !a.Confirmation.IsReady() {
 		a.Confirmation.SetData(
 			a.SelectedTemplate,
-			a.SelectedFiles,
+			a.promptFiles(),
 			a.TaskContent,
 			a.RulesContent,
 		)
+		a.Confirmation.SetRenderModes(a.RenderModes)
+		a.Confirmation.SetSlices(a.Slices)
+		a.Confirmation.SetPathRoots(a.Roots)
 
 		// Trigger size calculation and filename generation
 		return a, tea.Batch(
@@ -279,14 +287,30 @@ func (a *AppState) generatePrompt() tea.Cmd {
 	// Switch to generation screen
 	a.SetCurrentScreen(GenerateScreen)
 
+	// Resolve task @mentions before reading a.Slices
+	files := a.promptFiles()
+
 	// Create generation configuration from app state
 	config := builder.GenerationConfig{
 		Template:      a.SelectedTemplate,
 		Variables:     make(map[string]string),
-		SelectedFiles: a.SelectedFiles,
+		SelectedFiles: files,
 		TaskContent:   a.TaskContent,
 		RulesContent:  a.RulesContent,
-		OutputPath:    "", // Use current directory
+		OutputPath:    a.primaryRoot(),
+		EmitManifest:  a.Confirmation.ManifestEnabled(),
+		StreamToFile:  true,
+		Chunking:      a.Confirmation.ChunkConfig(),
+		Compaction:    a.Confirmation.Compaction(),
+		RenderModes:   a.RenderModes,
+		Slices:        a.Slices,
+		Paths:         a.Confirmation.PathOptions(),
+		Annotations:   a.Confirmation.Annotations(),
+		Transformers:  a.Confirmation.Transformers(),
+		Truncation:    a.Confirmation.Truncation(),
+
+		TreeFormat:        a.Confirmation.TreeFormat(),
+		NormalizeNewlines: a.Confirmation.NormalizeNewlines(),
 	}
 
 	// Start generation process
@@ -0,0 +1,675 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/diogopedro/shotgun/internal/core/builder"
+	"github.com/diogopedro/shotgun/internal/core/deps"
+	"github.com/diogopedro/shotgun/internal/core/pairing"
+	"github.com/diogopedro/shotgun/internal/core/scanner"
+	"github.com/diogopedro/shotgun/internal/core/search"
+	tmplcore "github.com/diogopedro/shotgun/internal/core/template"
+	"github.com/diogopedro/shotgun/internal/models"
+	"github.com/spf13/cobra"
+)
+
+// GenerateOptions configures a headless prompt generation run
+type GenerateOptions struct {
+	Paths                []string // Files, directories or slice selectors (path:120-240, path#FuncName) to include
+	Slices               []string // Additional slice selectors
+	Grep                 string   // Keep only files whose contents match this pattern
+	GrepLiteral          bool     // Match Grep as plain text instead of a regular expression
+	TemplateID           string
+	Task                 string
+	Rules                string
+	Output               string              // Output file; empty writes a timestamped file in the current directory, "-" writes to stdout
+	Manifest             bool                // Write a reproducibility manifest next to the output
+	SplitTokens          int64               // Split the prompt into numbered parts of at most N estimated tokens
+	SplitSize            string              // Split the prompt into numbered parts of at most this size, e.g. "200KB"
+	ExpandDeps           int                 // Include in-module Go packages imported by the selection, up to N hops
+	ReverseDeps          int                 // Include in-module Go files that import the selection, up to N hops
+	WithTests            bool                // Include the tests (and test data) of selected source files
+	WithSources          bool                // Include the sources of selected test files
+	PairRules            []string            // Extra SOURCE=TEST pairing rules added to the defaults
+	TreeFormat           *builder.TreeFormat // Structure rendering options; nil uses builder.DefaultTreeFormat
+	PathOptions          builder.PathOptions // Display paths and anonymization; an empty root uses the working directory
+	Compact              []string            // Compaction modes; nil uses the template's defaults, "none" disables them
+	Annotations          []string            // Line numbers and file headers; nil uses the template's defaults, "none" disables them
+	Transforms           []string            // Content transformers by name; nil enables all, "none" disables them
+	MaxFileSize          string              // Size above which files are truncated or replaced, e.g. "256KB"; empty uses the default
+	Truncate             string              // Truncation strategy spec for oversized files; empty embeds a placeholder
+	NormalizeNewlines    bool                // Convert CRLF line endings to LF in file contents
+	TextExtensions       []string            // Extensions always treated as text, added to the built-in list
+	BinaryExtensions     []string            // Extensions always treated as binary, added to the built-in list
+	IncludeGenerated     bool                // Scan in generated files, found by .gitattributes, file globs or headers
+	IncludeVendored      bool                // Scan in files marked linguist-vendored in .gitattributes
+	IncludeExportIgnored bool                // Scan in files marked export-ignore in .gitattributes
+	GeneratedGlobs       []string            // Extra file globs treated as generated code
+	GeneratedPathOnly    bool                // List generated files found by scanning without their content
+	Roots                []string            // Project roots; with several, prompt paths start with each root's label
+	Archive              string              // Read files from this .zip, .tar or .tar.gz instead of the disk
+	Rev                  string              // Read files from this git revision of the working tree's repository
+	Delta                string              // Manifest of a previous prompt; unchanged files are sent as markers
+	DeltaDiffs           bool                // With Delta, send changed files as diffs against the previous prompt
+}
+
+// CollectOptions controls which files found by scanning directories are kept
+type CollectOptions struct {
+	Detector             *scanner.BinaryDetector    // Binary detector; nil uses the shared one
+	IncludeGenerated     bool                       // Keep files marked linguist-generated
+	IncludeVendored      bool                       // Keep files marked linguist-vendored
+	IncludeExportIgnored bool                       // Keep files marked export-ignore
+	Generated            *scanner.GeneratedDetector // Generated-code rules; nil uses the defaults
+	Source               *scanner.Source            // Archive or git revision paths are resolved in; nil uses the disk
+}
+
+// NewGenerateCmd creates the headless generate command
+func NewGenerateCmd() *cobra.Command {
+	var opts GenerateOptions
+	var taskFile string
+	var hideBinary bool
+	tree := builder.DefaultTreeFormat
+
+	generateCmd := &cobra.Command{
+		Use:   "generate [path...]",
+		Short: "Generate a prompt without the interactive UI",
+		Long: `Generate a prompt from files and directories without starting the TUI.
+Directories are scanned with the same ignore rules as the interactive file tree.
+With --archive or --rev, paths are read from an archive or git revision instead
+of the working tree.
+
+Examples:
+  shotgun generate -t prompt-make-plan --task "Add caching" internal/core
+  shotgun generate -t prompt-analyze-bug --task-file bug.md -o prompt.md .
+  shotgun generate -t prompt-analyze-bug --task "Fix timeout" --grep "context deadline exceeded" --grep-literal .
+  shotgun generate -t prompt-analyze-bug --task "Fix parsing" internal/core/builder/compact.go#stripCLikeComments
+  shotgun generate -t prompt-make-plan --task "See @internal/app/update.go:270-300" --slice internal/app/model.go:1-60 .
+  shotgun generate -t prompt-make-plan --task "Refactor" --expand-deps 1 internal/screens/confirm/update.go
+  shotgun generate -t prompt-make-plan --task "Rename API" --reverse-deps 1 internal/models/files.go -o -
+  shotgun generate -t prompt-analyze-bug --task-file bug.md --with-tests internal/core/builder/chunk.go
+  shotgun generate -t prompt-analyze-bug --task "Flaky test" --with-tests --pair-rule "*.ts=*.e2e.ts" src
+  shotgun generate -t prompt-make-plan --task "Overview" --tree-summary --tree-depth 2 --collapse-dirs --path-prefix repo/ .
+  shotgun generate -t prompt-make-plan --task "Summarize" --compact all --annotate line-numbers internal/core
+  shotgun generate -t prompt-make-plan --task "Review" --split-tokens 100000 --manifest -o review.md .
+  shotgun generate -t prompt-make-plan --task "Trace the order flow" --root ../orders --root ../billing
+  shotgun generate -t prompt-analyze-bug --task "Review the release" --archive release-1.2.0.tar.gz
+  shotgun generate -t prompt-analyze-bug --task "Find the regression" --rev v1.2.0 internal/core
+  shotgun generate -t prompt-make-plan --task "Continue" --delta prompt.md.lock.json --delta-diffs .`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if taskFile != "" {
+				data, err := os.ReadFile(taskFile)
+				if err != nil {
+					return fmt.Errorf("failed to read task file: %w", err)
+				}
+				opts.Task = string(data)
+			}
+			opts.Paths = args
+			tree.ShowBinary = !hideBinary
+			opts.TreeFormat = &tree
+			return Generate(cmd.Context(), cmd.OutOrStdout(), cmd.ErrOrStderr(), opts)
+		},
+	}
+
+	flags := generateCmd.Flags()
+	flags.StringVarP(&opts.TemplateID, "template", "t", "", "Template ID to use (required)")
+	flags.StringVar(&opts.Task, "task", "", "Task description")
+	flags.StringVar(&taskFile, "task-file", "", "Read the task description from a file")
+	flags.StringVar(&opts.Rules, "rules", "", "Additional rules or constraints")
+	flags.StringVar(&opts.Grep, "grep", "", "Include only files whose contents match this regular expression")
+	flags.BoolVar(&opts.GrepLiteral, "grep-literal", false, "Treat the --grep pattern as plain text")
+	flags.StringArrayVarP(&opts.Slices, "slice", "s", nil, `Include only part of a file: "path:120-240" or "path#FuncName" (repeatable)`)
+	flags.StringVarP(&opts.Output, "output", "o", "", `Output file ("-" for stdout; default is a timestamped file in the current directory)`)
+	flags.BoolVar(&opts.Manifest, "manifest", false, "Write a reproducibility manifest next to the prompt")
+	flags.Int64Var(&opts.SplitTokens, "split-tokens", 0, "Split the prompt into numbered part files of at most N estimated tokens")
+	flags.StringVar(&opts.SplitSize, "split-size", "", `Split the prompt into numbered part files of at most this size, e.g. "200KB"`)
+	flags.StringVar(&opts.Delta, "delta", "", "Manifest of a previous prompt; files unchanged since then are sent as markers")
+	flags.BoolVar(&opts.DeltaDiffs, "delta-diffs", false, "With --delta, send changed files as diffs against the previous prompt")
+	flags.IntVar(&opts.ExpandDeps, "expand-deps", 0, "Include Go packages from this module imported by the selection, up to N hops")
+	flags.IntVar(&opts.ReverseDeps, "reverse-deps", 0, "Include Go files from this module that import the selection, up to N hops")
+	flags.BoolVar(&opts.WithTests, "with-tests", false, "Include tests paired with selected source files (Go testdata included)")
+	flags.BoolVar(&opts.WithSources, "with-sources", false, "Include sources paired with selected test files")
+	flags.StringArrayVar(&opts.PairRules, "pair-rule", nil, `Extra test pairing rule as SOURCE=TEST, e.g. "*.ts=*.e2e.ts" (repeatable)`)
+	flags.BoolVar(&tree.UseUnicode, "unicode", tree.UseUnicode, "Draw the file tree with box-drawing instead of ASCII characters")
+	flags.BoolVar(&tree.ShowSizes, "tree-sizes", false, "Show file sizes in the file tree")
+	flags.BoolVar(&hideBinary, "hide-binary", false, "List binary files without a placeholder content block")
+	flags.IntVar(&tree.IndentSize, "tree-indent", tree.IndentSize, "Columns per file tree level")
+	flags.BoolVar(&tree.CollapseDirs, "collapse-dirs", false, "Join single-child directory chains into one tree line")
+	flags.BoolVar(&tree.Summary, "tree-summary", false, "Write a tree-only overview before the file contents")
+	flags.IntVar(&tree.MaxDepth, "tree-depth", 0, "Limit the --tree-summary overview to N levels")
+	flags.StringVar(&opts.PathOptions.Prefix, "path-prefix", "", `Virtual prefix for file paths in the prompt, e.g. "repo/"`)
+	flags.BoolVar(&opts.PathOptions.Absolute, "absolute-paths", false, "Write absolute file paths instead of paths relative to the working directory")
+	flags.BoolVar(&opts.PathOptions.Anonymize, "anonymize", false, "Scrub the local username, hostname and home directory from file contents")
+	flags.StringArrayVar(&opts.Compact, "compact", nil, `Compact file contents: "strip-comments", "collapse-blank", "drop-license", "trim-trailing", "all" or "none" (repeatable; default from template)`)
+	flags.StringArrayVar(&opts.Annotations, "annotate", nil, `Annotate file contents: "line-numbers[=pipe|colon|plain]", "header" or "none" (repeatable; default from template)`)
+	flags.StringArrayVar(&opts.Transforms, "transform", nil, `Content transformers: "notebook", "sample", "lockfile", "minified", "all" or "none" (repeatable; default all)`)
+	flags.StringVar(&opts.MaxFileSize, "max-file-size", "", `Size above which files are truncated or replaced, e.g. "256KB" (default 10MB)`)
+	flags.StringVar(&opts.Truncate, "truncate", "", `Cut down oversized files: "head-tail[=200,100]", "head[=64KB]" or "keywords[=a,b]" (default: placeholder)`)
+	flags.BoolVar(&opts.NormalizeNewlines, "normalize-newlines", false, "Convert CRLF line endings in file contents to LF")
+	flags.StringArrayVar(&opts.TextExtensions, "text-ext", nil, `Treat files with this extension as text, e.g. ".svg" (repeatable)`)
+	flags.StringArrayVar(&opts.BinaryExtensions, "binary-ext", nil, `Treat files with this extension as binary, e.g. ".dat" (repeatable)`)
+	flags.BoolVar(&opts.IncludeGenerated, "include-generated", false, "Include generated files (by .gitattributes, file name globs or generated-code headers) when scanning directories")
+	flags.StringArrayVar(&opts.Roots, "root", nil, "Project root for relative prompt paths; repeat for several roots, labeled by directory name (default: all paths under the roots)")
+	flags.StringArrayVar(&opts.GeneratedGlobs, "generated-glob", nil, `Treat files matching this glob as generated code, e.g. "*.gen.ts" (repeatable)`)
+	flags.BoolVar(&opts.GeneratedPathOnly, "generated-path-only", false, "List generated files found when scanning directories by path only, without content")
+	flags.BoolVar(&opts.IncludeVendored, "include-vendored", false, "Include files marked linguist-vendored in .gitattributes when scanning directories")
+	flags.BoolVar(&opts.IncludeExportIgnored, "include-export-ignored", false, "Include files marked export-ignore in .gitattributes when scanning directories")
+	flags.StringVar(&opts.Archive, "archive", "", "Read files from a .zip, .tar or .tar.gz archive; paths are relative to the archive root")
+	flags.StringVar(&opts.Rev, "rev", "", `Read files from a git revision of the current repository, e.g. "v1.2.0", without checking it out`)
+	generateCmd.MarkFlagRequired("template")
+
+	return generateCmd
+}
+
+// Generate runs a headless generation. The prompt goes to stdout when opts.Output is "-";
+// progress and summaries go to stderr.
+func Generate(ctx context.Context, stdout, stderr io.Writer, opts GenerateOptions) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if opts.DeltaDiffs && opts.Delta == "" {
+		return fmt.Errorf("--delta-diffs requires --delta")
+	}
+
+	source, err := openSource(opts)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	var roots []string
+	if len(opts.Roots) > 0 {
+		var err error
+		if roots, err = builder.ResolveRoots(opts.Roots); err != nil {
+			return err
+		}
+		if len(opts.Paths) == 0 {
+			opts.Paths = roots
+		}
+	}
+
+	paths, slices, err := parseSelectors(append(append([]string(nil), opts.Paths...), opts.Slices...), source)
+	if err != nil {
+		return err
+	}
+	var binaryDetection *scanner.BinaryDetectorConfig
+	detector := scanner.SharedBinaryDetector()
+	if len(opts.TextExtensions) > 0 || len(opts.BinaryExtensions) > 0 {
+		binaryDetection = &scanner.BinaryDetectorConfig{
+			TextExtensions:   opts.TextExtensions,
+			BinaryExtensions: opts.BinaryExtensions,
+		}
+		detector = scanner.NewBinaryDetectorWithConfig(*binaryDetection)
+	}
+
+	generatedDetector, err := scanner.NewGeneratedDetector(scanner.GeneratedConfig{Globs: opts.GeneratedGlobs})
+	if err != nil {
+		return err
+	}
+
+	files, generated, err := CollectFiles(ctx, paths, CollectOptions{
+		Detector:             detector,
+		IncludeGenerated:     opts.IncludeGenerated || opts.GeneratedPathOnly,
+		IncludeVendored:      opts.IncludeVendored,
+		IncludeExportIgnored: opts.IncludeExportIgnored,
+		Generated:            generatedDetector,
+		Source:               source,
+	})
+	if err != nil {
+		return err
+	}
+	var renderModes map[string]models.RenderMode
+	if opts.GeneratedPathOnly && len(generated) > 0 {
+		renderModes = make(map[string]models.RenderMode, len(generated))
+		for _, path := range generated {
+			renderModes[path] = models.RenderPathOnly
+		}
+	}
+
+	if opts.Grep != "" {
+		files, err = grepFiles(ctx, stderr, files, search.Query{Pattern: opts.Grep, Literal: opts.GrepLiteral}, detector)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Files @mentioned in the task are included with their slices; they name working tree files
+	if source == nil {
+		mentioned, mentionSlices := builder.ResolveMentions(opts.Task, ".")
+		files = mergeFiles(files, mentioned)
+		for path, s := range mentionSlices {
+			slices[path] = append(slices[path], s...)
+		}
+	}
+
+	files, err = expandDependencies(stderr, files, opts.ExpandDeps, opts.ReverseDeps)
+	if err != nil {
+		return err
+	}
+
+	files, err = pairTests(stderr, files, opts)
+	if err != nil {
+		return err
+	}
+
+	if len(files) == 0 {
+		return fmt.Errorf("no files selected")
+	}
+
+	service := tmplcore.NewTemplateService(nil)
+	if _, err := service.LoadAllTemplates(ctx); err != nil {
+		return fmt.Errorf("failed to load templates: %w", err)
+	}
+
+	template, err := service.GetTemplate(opts.TemplateID)
+	if err != nil {
+		return err
+	}
+
+	compactionModes := template.Compaction
+	if opts.Compact != nil {
+		compactionModes = opts.Compact
+	}
+	compaction, err := builder.ParseCompaction(compactionModes)
+	if err != nil {
+		return err
+	}
+
+	annotationSpecs := template.Annotations
+	if opts.Annotations != nil {
+		annotationSpecs = opts.Annotations
+	}
+	annotations, err := builder.ParseAnnotations(annotationSpecs)
+	if err != nil {
+		return err
+	}
+
+	transformers := builder.DefaultTransformers()
+	if opts.Transforms != nil {
+		if transformers, err = builder.ParseTransformers(opts.Transforms); err != nil {
+			return err
+		}
+	}
+
+	truncation, err := builder.ParseTruncation(opts.Truncate)
+	if err != nil {
+		return err
+	}
+	chunking, err := parseSplit(opts)
+	if err != nil {
+		return err
+	}
+
+	var maxFileSize int64
+	if opts.MaxFileSize != "" {
+		if maxFileSize, err = builder.ParseByteSize(opts.MaxFileSize); err != nil || maxFileSize <= 0 {
+			return fmt.Errorf("invalid --max-file-size %q", opts.MaxFileSize)
+		}
+	}
+
+	config := builder.GenerationConfig{
+		Template:          template,
+		Variables:         make(map[string]string),
+		SelectedFiles:     files,
+		TaskContent:       opts.Task,
+		RulesContent:      opts.Rules,
+		EmitManifest:      opts.Manifest,
+		Chunking:          chunking,
+		Compaction:        compaction,
+		RenderModes:       renderModes,
+		Slices:            slices,
+		TreeFormat:        opts.TreeFormat,
+		Paths:             opts.PathOptions,
+		Annotations:       annotations,
+		Transformers:      transformers,
+		MaxFileSize:       maxFileSize,
+		Truncation:        truncation,
+		NormalizeNewlines: opts.NormalizeNewlines,
+		BinaryDetection:   binaryDetection,
+		Source:            source,
+	}
+	switch {
+	case len(roots) > 1:
+		config.Paths.Roots = roots
+	case len(roots) == 1:
+		config.Paths.Root = roots[0]
+	}
+	if config.Paths.Root == "" && len(config.Paths.Roots) == 0 && !config.Paths.Absolute {
+		if source != nil {
+			config.Paths.Root = source.Root
+		} else if root, err := os.Getwd(); err == nil {
+			config.Paths.Root = root
+		}
+	}
+
+	if opts.Delta != "" {
+		if config.Delta, err = builder.LoadDeltaContext(opts.Delta, opts.DeltaDiffs); err != nil {
+			return fmt.Errorf("failed to load --delta manifest: %w", err)
+		}
+	}
+
+	generator := builder.NewPromptGenerator()
+	writer := builder.NewFileWriter()
+
+	var result *builder.GeneratedPrompt
+	switch {
+	case opts.Output == "-":
+		result, err = generator.GeneratePromptTo(ctx, stdout, config)
+	case opts.Output == "":
+		result, err = generator.GenerateToFile(ctx, config, writer)
+	case chunking != nil:
+		result, err = writeParts(ctx, generator, writer, config, opts.Output)
+	default:
+		var outputFile string
+		outputFile, err = writer.WritePromptStreamAt(opts.Output, func(w io.Writer) error {
+			var genErr error
+			result, genErr = generator.GeneratePromptTo(ctx, w, config)
+			return genErr
+		})
+		if err == nil {
+			result.OutputFile = outputFile
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to generate prompt: %w", err)
+	}
+
+	if result.OutputFile == "" {
+		fmt.Fprintf(stderr, "✓ Generated prompt from %d files (%d bytes)\n", result.FileCount, result.TotalSize)
+		return nil
+	}
+
+	written := result.OutputFile
+	if len(result.PartFiles) > 0 {
+		written = fmt.Sprintf("%s (%d parts)", result.PartFiles[0], len(result.PartFiles))
+	}
+	fmt.Fprintf(stderr, "✓ Wrote %s from %d files (%d bytes)\n", written, result.FileCount, result.TotalSize)
+	if result.Manifest != nil {
+		manifestFile, err := builder.WriteManifest(result.Manifest, result.ManifestTarget())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stderr, "✓ Wrote manifest %s\n", manifestFile)
+	}
+
+	return nil
+}
+
+// parseSplit returns the chunking configuration for --split-tokens and --split-size, or nil
+// when the prompt is not split. Parts are written as files, so splitting needs a file output.
+func parseSplit(opts GenerateOptions) (*builder.ChunkConfig, error) {
+	if opts.SplitTokens == 0 && opts.SplitSize == "" {
+		return nil, nil
+	}
+	if opts.SplitTokens < 0 {
+		return nil, fmt.Errorf("invalid --split-tokens %d", opts.SplitTokens)
+	}
+
+	chunking := &builder.ChunkConfig{MaxTokens: opts.SplitTokens}
+	if opts.SplitSize != "" {
+		size, err := builder.ParseByteSize(opts.SplitSize)
+		if err != nil || size <= 0 {
+			return nil, fmt.Errorf("invalid --split-size %q", opts.SplitSize)
+		}
+		chunking.MaxBytes = size
+	}
+	if opts.Output == "-" {
+		return nil, fmt.Errorf("--split-tokens and --split-size write part files and cannot be used with -o -")
+	}
+	return chunking, nil
+}
+
+// writeParts generates a split prompt and writes it as numbered parts next to output,
+// or to output itself when everything fits in one part
+func writeParts(ctx context.Context, generator *builder.PromptGenerator, writer *builder.FileWriter, config builder.GenerationConfig, output string) (*builder.GeneratedPrompt, error) {
+	result, err := generator.GeneratePrompt(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result.Parts) > 0 {
+		if result.PartFiles, err = writer.WritePromptPartsAt(result.Parts, output); err != nil {
+			return nil, err
+		}
+		result.OutputFile = result.PartFiles[0]
+	} else if result.OutputFile, err = writer.WritePromptFileAt(result.Content, output); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// openSource opens the archive or git revision the options read from, or returns nil for
+// the disk. Options that read the working tree itself cannot be combined with a source.
+func openSource(opts GenerateOptions) (*scanner.Source, error) {
+	if opts.Archive == "" && opts.Rev == "" {
+		return nil, nil
+	}
+	if opts.Archive != "" && opts.Rev != "" {
+		return nil, fmt.Errorf("--archive and --rev cannot be combined")
+	}
+
+	var conflict string
+	switch {
+	case opts.Grep != "":
+		conflict = "--grep"
+	case opts.ExpandDeps > 0:
+		conflict = "--expand-deps"
+	case opts.ReverseDeps > 0:
+		conflict = "--reverse-deps"
+	case opts.WithTests:
+		conflict = "--with-tests"
+	case opts.WithSources:
+		conflict = "--with-sources"
+	case len(opts.Roots) > 0:
+		conflict = "--root"
+	}
+	if conflict != "" {
+		return nil, fmt.Errorf("%s reads the working tree and cannot be combined with --archive or --rev", conflict)
+	}
+
+	if opts.Rev != "" {
+		return scanner.OpenGitRevision(".", opts.Rev)
+	}
+	return scanner.OpenArchive(opts.Archive)
+}
+
+// CollectFiles resolves paths into a sorted list of absolute file paths. Directories are
+// scanned with the project's ignore rules; binary files found while scanning are skipped,
+// as are generated and vendored files unless options include them. Files named explicitly
+// are always kept. generated lists the kept files found to be generated code.
+func CollectFiles(ctx context.Context, paths []string, options CollectOptions) (files, generated []string, err error) {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	seen := make(map[string]bool)
+	add := func(path string) bool {
+		if seen[path] {
+			return false
+		}
+		seen[path] = true
+		files = append(files, path)
+		return true
+	}
+
+	for _, path := range paths {
+		abs, err := options.Source.Resolve(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to resolve %s: %w", path, err)
+		}
+
+		info, err := options.Source.Stat(abs)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+
+		if !info.IsDir() {
+			add(abs)
+			continue
+		}
+
+		scannerInstance, err := scanner.New(
+			scanner.WithBinaryDetector(options.Detector),
+			scanner.WithGeneratedDetector(options.Generated),
+			scanner.WithSource(options.Source),
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+		nodes, err := scannerInstance.ScanDirectorySync(ctx, abs)
+		if err != nil && len(nodes) == 0 {
+			return nil, nil, fmt.Errorf("failed to scan %s: %w", path, err)
+		}
+		for _, node := range nodes {
+			if node.IsDirectory || node.IsIgnored || node.IsBinary {
+				continue
+			}
+			switch {
+			case node.IsGenerated && !options.IncludeGenerated,
+				node.IsVendored && !options.IncludeVendored,
+				node.IsExportIgnored && !options.IncludeExportIgnored:
+				continue
+			}
+			if add(node.Path) && node.IsGenerated {
+				generated = append(generated, node.Path)
+			}
+		}
+	}
+
+	sort.Strings(files)
+	sort.Strings(generated)
+	return files, generated, nil
+}
+
+// grepFiles keeps the files whose contents match the query and reports the hits
+func grepFiles(ctx context.Context, stderr io.Writer, files []string, query search.Query, detector *scanner.BinaryDetector) ([]string, error) {
+	results, err := search.New(search.WithBinaryDetector(detector)).Search(ctx, files, query)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([]string, 0, len(results))
+	for _, r := range results {
+		fmt.Fprintf(stderr, "  %s: %d hits\n", r.Path, r.Hits)
+		matched = append(matched, r.Path)
+	}
+	fmt.Fprintf(stderr, "+ %d files match %q\n", len(matched), query.Pattern)
+
+	return matched, nil
+}
+
+// parseSelectors splits selectors into plain paths and per-file slices keyed by absolute path,
+// or by path in the source when reading an archive or git revision
+func parseSelectors(selectors []string, source *scanner.Source) ([]string, map[string][]builder.Slice, error) {
+	var paths []string
+	slices := make(map[string][]builder.Slice)
+
+	for _, selector := range selectors {
+		path, slice, err := builder.ParseSelector(selector)
+		if err != nil {
+			return nil, nil, err
+		}
+		paths = append(paths, path)
+		if slice == nil {
+			continue
+		}
+
+		abs, err := source.Resolve(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to resolve %s: %w", path, err)
+		}
+		slices[abs] = append(slices[abs], *slice)
+	}
+
+	return paths, slices, nil
+}
+
+// mergeFiles appends extra files not already present and sorts the result
+func mergeFiles(files, extra []string) []string {
+	seen := make(map[string]bool, len(files))
+	for _, f := range files {
+		seen[f] = true
+	}
+	for _, f := range extra {
+		if !seen[f] {
+			seen[f] = true
+			files = append(files, f)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// expandDependencies adds in-module Go imports and importers of the selected files
+func expandDependencies(stderr io.Writer, files []string, forward, reverse int) ([]string, error) {
+	if forward <= 0 && reverse <= 0 {
+		return files, nil
+	}
+
+	start := "."
+	if len(files) > 0 {
+		start = filepath.Dir(files[0])
+	}
+	resolver, err := deps.NewResolver(start)
+	if err != nil {
+		return nil, fmt.Errorf("dependency expansion: %w", err)
+	}
+
+	expanded := append([]string(nil), files...)
+	if forward > 0 {
+		added, err := resolver.Expand(files, forward)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(stderr, "+ %d files from imports (%d hops)\n", len(added), forward)
+		expanded = append(expanded, added...)
+	}
+	if reverse > 0 {
+		added, err := resolver.Dependents(expanded, reverse)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(stderr, "+ %d files from dependents (%d hops)\n", len(added), reverse)
+		expanded = append(expanded, added...)
+	}
+
+	sort.Strings(expanded)
+	return expanded, nil
+}
+
+// pairTests adds the tests of selected sources and the sources of selected tests
+func pairTests(stderr io.Writer, files []string, opts GenerateOptions) ([]string, error) {
+	if !opts.WithTests && !opts.WithSources {
+		return files, nil
+	}
+
+	rules := pairing.DefaultRules()
+	for _, spec := range opts.PairRules {
+		rule, err := pairing.ParseRule(spec)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	pairer := pairing.New(rules...)
+
+	paired := append([]string(nil), files...)
+	if opts.WithTests {
+		added := pairer.Tests(files)
+		fmt.Fprintf(stderr, "+ %d paired test files\n", len(added))
+		paired = append(paired, added...)
+	}
+	if opts.WithSources {
+		added := pairer.Sources(files)
+		fmt.Fprintf(stderr, "+ %d paired source files\n", len(added))
+		paired = append(paired, added...)
+	}
+
+	sort.Strings(paired)
+	return paired, nil
+}
@@ -0,0 +1,377 @@
+package cli
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/diogopedro/shotgun/internal/core/builder"
+)
+
+func TestNewGenerateCmd(t *testing.T) {
+	cmd := NewGenerateCmd()
+	if !strings.HasPrefix(cmd.Use, "generate") {
+		t.Errorf("expected Use to start with 'generate', got %s", cmd.Use)
+	}
+	if cmd.Flags().Lookup("expand-deps") == nil {
+		t.Error("expected --expand-deps flag")
+	}
+}
+
+func TestGenerateExpandDeps(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":         "module example.com/app\n",
+		"cmd/main.go":    "package main\n\nimport \"example.com/app/store\"\n\nfunc main() { store.Open() }\n",
+		"store/store.go": "package store\n\nfunc Open() {}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	opts := GenerateOptions{
+		Paths:      []string{filepath.Join(root, "cmd", "main.go")},
+		TemplateID: "prompt-make-plan",
+		Task:       "Explain the store",
+		Output:     "-",
+		ExpandDeps: 1,
+	}
+	if err := Generate(context.Background(), &stdout, &stderr, opts); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+
+	if !strings.Contains(stdout.String(), "func Open() {}") {
+		t.Errorf("expected imported package in prompt, stderr: %s", stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "+ 1 files from imports") {
+		t.Errorf("unexpected summary: %s", stderr.String())
+	}
+}
+
+func TestGenerateSlices(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "big.txt")
+	if err := os.WriteFile(file, []byte("alpha\nbeta\ngamma\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	opts := GenerateOptions{
+		Paths:      []string{file + ":2-2"},
+		TemplateID: "prompt-make-plan",
+		Task:       "Explain beta",
+		Output:     "-",
+	}
+	if err := Generate(context.Background(), &stdout, &stderr, opts); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+
+	out := stdout.String()
+	if !strings.Contains(out, "--- lines 2-2 of 3 ---\nbeta\n") || strings.Contains(out, "alpha") {
+		t.Errorf("expected only the selected lines in the prompt:\n%s", out)
+	}
+}
+
+func TestGenerateCompact(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "notes.txt")
+	if err := os.WriteFile(file, []byte("alpha   \n\n\n\nbeta\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	opts := GenerateOptions{
+		Paths:      []string{file},
+		TemplateID: "prompt-make-plan",
+		Task:       "Compact notes",
+		Output:     "-",
+		Compact:    []string{"trim-trailing", "collapse-blank"},
+	}
+	if err := Generate(context.Background(), &stdout, &stderr, opts); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "alpha\n\nbeta\n") {
+		t.Errorf("expected compacted contents in the prompt:\n%s", stdout.String())
+	}
+
+	opts.Compact = []string{"minify"}
+	if err := Generate(context.Background(), &stdout, &stderr, opts); err == nil {
+		t.Error("expected an error for an unknown compaction mode")
+	}
+}
+
+func TestGenerateSplit(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		content := strings.Repeat(name+" line\n", 200)
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	output := filepath.Join(t.TempDir(), "prompt.md")
+	opts := GenerateOptions{
+		Paths:      []string{root},
+		TemplateID: "prompt-make-plan",
+		Task:       "Read in parts",
+		Output:     output,
+		SplitSize:  "4KB",
+	}
+	if err := Generate(context.Background(), &stdout, &stderr, opts); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+
+	if _, err := os.Stat(builder.PartFilename(output, 2)); err != nil {
+		t.Errorf("expected a second part file: %v\nstderr: %s", err, stderr.String())
+	}
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Errorf("expected only part files to be written, stat error: %v", err)
+	}
+	if !strings.Contains(stderr.String(), "parts)") {
+		t.Errorf("unexpected summary: %s", stderr.String())
+	}
+
+	opts.Output = "-"
+	if err := Generate(context.Background(), &stdout, &stderr, opts); err == nil {
+		t.Error("expected splitting to stdout to fail")
+	}
+}
+
+func TestGenerateGrep(t *testing.T) {
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		"retry.go": "package app\n\n// connection reset by peer\n",
+		"other.go": "package app\n",
+	} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	opts := GenerateOptions{
+		Paths:       []string{root},
+		TemplateID:  "prompt-make-plan",
+		Task:        "Handle resets",
+		Output:      "-",
+		Grep:        "reset by",
+		GrepLiteral: true,
+	}
+	if err := Generate(context.Background(), &stdout, &stderr, opts); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+
+	if !strings.Contains(stdout.String(), "retry.go") || strings.Contains(stdout.String(), "other.go") {
+		t.Errorf("expected only the matching file:\n%s", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "+ 1 files match") {
+		t.Errorf("unexpected summary: %s", stderr.String())
+	}
+}
+
+func TestCollectFilesGitAttributes(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".gitattributes":        "*.pb.go linguist-generated\nthird_party/** linguist-vendored\ndocs/** export-ignore\n",
+		"api/api.go":            "package api\n",
+		"docs/guide.md":         "# Guide\n",
+		"api/api.pb.go":         "package api\n",
+		"third_party/lib/lib.c": "int lib(void);\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names := func(options CollectOptions) []string {
+		collected, _, err := CollectFiles(context.Background(), []string{root}, options)
+		if err != nil {
+			t.Fatalf("CollectFiles failed: %v", err)
+		}
+		var rel []string
+		for _, path := range collected {
+			r, _ := filepath.Rel(root, path)
+			rel = append(rel, filepath.ToSlash(r))
+		}
+		return rel
+	}
+
+	if got := strings.Join(names(CollectOptions{}), ","); got != ".gitattributes,api/api.go" {
+		t.Errorf("default collection = %s", got)
+	}
+	if got := strings.Join(names(CollectOptions{IncludeVendored: true}), ","); got != ".gitattributes,api/api.go,third_party/lib/lib.c" {
+		t.Errorf("vendored collection = %s", got)
+	}
+	if got := strings.Join(names(CollectOptions{IncludeExportIgnored: true}), ","); got != ".gitattributes,api/api.go,docs/guide.md" {
+		t.Errorf("export-ignored collection = %s", got)
+	}
+	if got := strings.Join(names(CollectOptions{IncludeGenerated: true, IncludeVendored: true, IncludeExportIgnored: true}), ","); got != ".gitattributes,api/api.go,api/api.pb.go,docs/guide.md,third_party/lib/lib.c" {
+		t.Errorf("inclusive collection = %s", got)
+	}
+}
+
+func TestGenerateGeneratedFiles(t *testing.T) {
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		"app.go":         "package app\n\nfunc Serve() {}\n",
+		"kind_string.go": "// Code generated by \"stringer -type=Kind\"; DO NOT EDIT.\n\npackage app\n",
+		"schema.gen.sql": "CREATE TABLE t (id int);\n",
+	} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run := func(opts GenerateOptions) string {
+		var stdout, stderr bytes.Buffer
+		opts.Paths = []string{root}
+		opts.TemplateID = "prompt-make-plan"
+		opts.Task = "Review"
+		opts.Output = "-"
+		if err := Generate(context.Background(), &stdout, &stderr, opts); err != nil {
+			t.Fatalf("generate failed: %v", err)
+		}
+		return stdout.String()
+	}
+
+	out := run(GenerateOptions{GeneratedGlobs: []string{"*.gen.sql"}})
+	if !strings.Contains(out, "func Serve()") || strings.Contains(out, "kind_string.go") || strings.Contains(out, "schema.gen.sql") {
+		t.Errorf("generated files should be excluded by default:\n%s", out)
+	}
+
+	out = run(GenerateOptions{GeneratedGlobs: []string{"*.gen.sql"}, GeneratedPathOnly: true})
+	if !strings.Contains(out, "kind_string.go") || strings.Contains(out, "stringer -type=Kind") || strings.Contains(out, "CREATE TABLE") {
+		t.Errorf("generated files should be listed without content:\n%s", out)
+	}
+}
+
+func TestGenerateArchive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "release.zip")
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive := zip.NewWriter(out)
+	for name, content := range map[string]string{
+		".gitignore":      "*.log\n",
+		"src/main.go":     "package main\n\nfunc main() {}\n",
+		"src/debug.log":   "noise\n",
+		"src/logo.png":    "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR",
+		"docs/README.md":  "# Release\n",
+		"docs/changes.md": "- fixed\n",
+	} {
+		w, _ := archive.Create(name)
+		w.Write([]byte(content))
+	}
+	archive.Close()
+	out.Close()
+
+	run := func(opts GenerateOptions) (string, error) {
+		var stdout, stderr bytes.Buffer
+		opts.Archive = path
+		opts.TemplateID = "prompt-make-plan"
+		opts.Task = "Review"
+		opts.Output = "-"
+		err := Generate(context.Background(), &stdout, &stderr, opts)
+		return stdout.String(), err
+	}
+
+	prompt, err := run(GenerateOptions{})
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	if !strings.Contains(prompt, `<file path="src/main.go">package main`) || !strings.Contains(prompt, "# Release") {
+		t.Errorf("archive files should be embedded relative to the archive root:\n%s", prompt)
+	}
+	for _, unwanted := range []string{"debug.log", "logo.png"} {
+		if strings.Contains(prompt, unwanted) {
+			t.Errorf("%s should be skipped like in a directory:\n%s", unwanted, prompt)
+		}
+	}
+
+	prompt, err = run(GenerateOptions{Paths: []string{"docs/changes.md"}})
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	if !strings.Contains(prompt, "- fixed") || strings.Contains(prompt, "main.go") {
+		t.Errorf("only the named archive file should be included:\n%s", prompt)
+	}
+
+	if _, err := run(GenerateOptions{Grep: "main"}); err == nil || !strings.Contains(err.Error(), "--grep") {
+		t.Errorf("expected --grep to be rejected with --archive, got %v", err)
+	}
+	if _, err := run(GenerateOptions{Paths: []string{"../outside"}}); err == nil {
+		t.Error("expected paths outside the archive to be rejected")
+	}
+}
+
+func TestGenerateDelta(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("stable.go", "package app\n\nconst Stable = 1\n")
+	write("edited.go", "package app\n\nconst Edited = 1\n")
+
+	promptPath := filepath.Join(t.TempDir(), "prompt.md")
+	var stdout, stderr bytes.Buffer
+	base := GenerateOptions{Paths: []string{root}, TemplateID: "prompt-make-plan", Task: "Review", PathOptions: builder.PathOptions{Root: root}}
+	first := base
+	first.Output = promptPath
+	first.Manifest = true
+	if err := Generate(context.Background(), &stdout, &stderr, first); err != nil {
+		t.Fatalf("first generate failed: %v", err)
+	}
+
+	write("edited.go", "package app\n\nconst Edited = 2\n")
+
+	run := func(diffs bool) string {
+		t.Helper()
+		var stdout, stderr bytes.Buffer
+		opts := base
+		opts.Output = "-"
+		opts.Delta = builder.ManifestPath(promptPath)
+		opts.DeltaDiffs = diffs
+		if err := Generate(context.Background(), &stdout, &stderr, opts); err != nil {
+			t.Fatalf("delta generate failed: %v", err)
+		}
+		return stdout.String()
+	}
+
+	out := run(false)
+	if !strings.Contains(out, `<file path="stable.go" status="unchanged">`) ||
+		!strings.Contains(out, `<file path="edited.go" status="changed">`) || !strings.Contains(out, "const Edited = 2") {
+		t.Errorf("expected unchanged and changed files:\n%s", out)
+	}
+
+	out = run(true)
+	if !strings.Contains(out, `status="changed" format="diff"`) || !strings.Contains(out, "+const Edited = 2") {
+		t.Errorf("expected a diff for the changed file:\n%s", out)
+	}
+
+	opts := base
+	opts.DeltaDiffs = true
+	if err := Generate(context.Background(), &stdout, &stderr, opts); err == nil {
+		t.Error("expected --delta-diffs without --delta to fail")
+	}
+}
@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/diogopedro/shotgun/internal/core/builder"
+	tmplcore "github.com/diogopedro/shotgun/internal/core/template"
+	"github.com/spf13/cobra"
+)
+
+// NewRegenCmd creates the regen command
+func NewRegenCmd() *cobra.Command {
+	var output string
+	var force bool
+
+	regenCmd := &cobra.Command{
+		Use:   "regen <manifest>",
+		Short: "Rebuild a prompt from its manifest",
+		Long: `Rebuild a prompt from a manifest (*.lock.json) using the recorded template,
+variables and files. When nothing drifted the output is byte-identical to the
+original prompt.
+
+Examples:
+  shotgun regen prompt.md.lock.json              # Rewrite prompt.md
+  shotgun regen prompt.md.lock.json -o copy.md   # Write to another file
+  shotgun regen prompt.md.lock.json --force      # Regenerate despite drift`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RegenerateFromManifest(cmd.Context(), cmd.OutOrStdout(), args[0], output, force)
+		},
+	}
+
+	regenCmd.Flags().StringVarP(&output, "output", "o", "", "Output file (defaults to the prompt the manifest belongs to)")
+	regenCmd.Flags().BoolVarP(&force, "force", "f", false, "Regenerate even if files changed since the manifest was recorded")
+
+	return regenCmd
+}
+
+// RegenerateFromManifest rebuilds the prompt described by a manifest
+func RegenerateFromManifest(ctx context.Context, out io.Writer, manifestPath, output string, force bool) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	manifest, err := builder.LoadManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	if builder.HasDrift(manifest.Verify()) && !force {
+		return fmt.Errorf("files changed since the manifest was recorded (run 'shotgun verify %s' for details, or use --force)", manifestPath)
+	}
+
+	service := tmplcore.NewTemplateService(nil)
+	if _, err := service.LoadAllTemplates(ctx); err != nil {
+		return fmt.Errorf("failed to load templates: %w", err)
+	}
+
+	template, err := service.GetTemplate(manifest.Template.ID)
+	if err != nil {
+		return err
+	}
+
+	config, err := manifest.RegenerationConfig(template)
+	if err != nil {
+		return err
+	}
+	defer config.Source.Close()
+
+	result, err := builder.NewPromptGenerator().GeneratePrompt(ctx, config)
+	if err != nil {
+		return fmt.Errorf("failed to regenerate prompt: %w", err)
+	}
+
+	if output == "" {
+		output = builder.PromptPathFromManifest(manifestPath)
+	}
+
+	// Split prompts are rewritten as the same numbered parts
+	writer := builder.NewFileWriter()
+	var written string
+	if len(result.Parts) > 0 {
+		files, err := writer.WritePromptPartsAt(result.Parts, output)
+		if err != nil {
+			return err
+		}
+		written = fmt.Sprintf("%s (%d parts)", files[0], len(files))
+	} else {
+		written, err = writer.WritePromptFileAt(result.Content, output)
+		if err != nil {
+			return err
+		}
+	}
+
+	if result.Manifest.OutputSHA256 == manifest.OutputSHA256 {
+		fmt.Fprintf(out, "✓ Regenerated %s (byte-identical)\n", written)
+	} else {
+		fmt.Fprintf(out, "✓ Regenerated %s (output differs from original)\n", written)
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/diogopedro/shotgun/internal/core/builder"
+	"github.com/spf13/cobra"
+)
+
+// NewVerifyCmd creates the verify command
+func NewVerifyCmd() *cobra.Command {
+	verifyCmd := &cobra.Command{
+		Use:   "verify <manifest>",
+		Short: "Report files that changed since a prompt was generated",
+		Long: `Compare the files recorded in a prompt manifest (*.lock.json) against
+the current working tree and report which ones were modified or removed.
+
+The command exits with an error when any drift is detected.
+
+Examples:
+  shotgun verify shotgun_prompt_20250101_1200.md.lock.json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return VerifyManifest(cmd.OutOrStdout(), args[0])
+		},
+	}
+
+	return verifyCmd
+}
+
+// VerifyManifest checks a manifest for drift and writes a report to out
+func VerifyManifest(out io.Writer, manifestPath string) error {
+	manifest, err := builder.LoadManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	drifts := manifest.Verify()
+	changed := 0
+	for _, d := range drifts {
+		if d.Status == builder.DriftUnchanged {
+			continue
+		}
+		changed++
+		fmt.Fprintf(out, "%-9s %s\n", d.Status, d.Path)
+	}
+
+	if changed > 0 {
+		return fmt.Errorf("%d of %d files changed since %s", changed, len(drifts), manifest.GeneratedAt.Format("2006-01-02 15:04:05"))
+	}
+
+	fmt.Fprintf(out, "✓ All %d files match the manifest\n", len(drifts))
+	return nil
+}
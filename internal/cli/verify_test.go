@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/diogopedro/shotgun/internal/core/builder"
+	"github.com/diogopedro/shotgun/internal/models"
+)
+
+func TestNewVerifyCmd(t *testing.T) {
+	cmd := NewVerifyCmd()
+	if !strings.HasPrefix(cmd.Use, "verify") {
+		t.Errorf("expected Use to start with 'verify', got %s", cmd.Use)
+	}
+}
+
+func TestVerifyManifest(t *testing.T) {
+	tempDir := t.TempDir()
+	file := filepath.Join(tempDir, "main.go")
+	if err := os.WriteFile(file, []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &models.Template{ID: "t", Version: "1.0.0", Content: "{{FILE_STRUCTURE}}"}
+	manifest, err := builder.BuildManifest(tmpl, nil, []string{file}, "", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifestPath, err := builder.WriteManifest(manifest, filepath.Join(tempDir, "prompt.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := VerifyManifest(&out, manifestPath); err != nil {
+		t.Fatalf("expected no drift, got %v", err)
+	}
+	if !strings.Contains(out.String(), "All 1 files match") {
+		t.Errorf("unexpected output: %s", out.String())
+	}
+
+	if err := os.WriteFile(file, []byte("package changed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	if err := VerifyManifest(&out, manifestPath); err == nil {
+		t.Error("expected drift error")
+	}
+	if !strings.Contains(out.String(), "modified") || !strings.Contains(out.String(), file) {
+		t.Errorf("expected modified report, got: %s", out.String())
+	}
+}
+
+func TestRegenerateFromManifest_RejectsDrift(t *testing.T) {
+	tempDir := t.TempDir()
+	file := filepath.Join(tempDir, "main.go")
+	if err := os.WriteFile(file, []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &models.Template{ID: "prompt-analyze-bug", Version: "1.0.0", Content: "x"}
+	manifest, _ := builder.BuildManifest(tmpl, nil, []string{file}, "", time.Now())
+	manifestPath, _ := builder.WriteManifest(manifest, filepath.Join(tempDir, "prompt.md"))
+
+	os.Remove(file)
+
+	var out bytes.Buffer
+	err := RegenerateFromManifest(context.Background(), &out, manifestPath, "", false)
+	if err == nil || !strings.Contains(err.Error(), "changed") {
+		t.Errorf("expected drift error, got %v", err)
+	}
+}
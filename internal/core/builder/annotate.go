@@ -0,0 +1,206 @@
+package builder
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/diogopedro/shotgun/internal/core/scanner"
+	"github.com/diogopedro/shotgun/internal/models"
+)
+
+// Annotation names accepted in templates and on the command line
+const (
+	AnnotateLineNumbers = models.AnnotateLineNumbers
+	AnnotateHeader      = models.AnnotateHeader
+)
+
+// Gutter styles for line numbers
+const (
+	GutterPipe  = models.GutterPipe  // "  12 | code"
+	GutterColon = models.GutterColon // "  12: code"
+	GutterPlain = models.GutterPlain // "  12  code"
+)
+
+// Annotations adds line numbers and per-file header attributes to rendered content
+type Annotations struct {
+	LineNumbers bool   // Prefix each line of full file content with its number
+	Gutter      string // Gutter style for line numbers; empty means pipe
+	Header      bool   // Add language, lines and size attributes to each <file> tag
+}
+
+// ParseAnnotations builds Annotations from names; "line-numbers=colon" selects a gutter style
+func ParseAnnotations(specs []string) (Annotations, error) {
+	parsed, err := models.NormalizeAnnotations(specs)
+	if err != nil {
+		return Annotations{}, err
+	}
+
+	var a Annotations
+	for _, spec := range parsed {
+		switch spec.Name {
+		case AnnotateLineNumbers:
+			a.LineNumbers = true
+			a.Gutter = spec.Gutter
+		case AnnotateHeader:
+			a.Header = true
+		}
+	}
+	return a, nil
+}
+
+// Enabled reports whether any annotation is selected
+func (a Annotations) Enabled() bool {
+	return a.LineNumbers || a.Header
+}
+
+// Specs returns the selected annotations in the syntax accepted by ParseAnnotations
+func (a Annotations) Specs() []string {
+	var specs []string
+	if a.LineNumbers {
+		spec := AnnotateLineNumbers
+		if a.Gutter != "" && a.Gutter != GutterPipe {
+			spec += "=" + a.Gutter
+		}
+		specs = append(specs, spec)
+	}
+	if a.Header {
+		specs = append(specs, AnnotateHeader)
+	}
+	return specs
+}
+
+// gutterSeparator returns the text between a line number and its line
+func (a Annotations) gutterSeparator() string {
+	switch a.Gutter {
+	case GutterColon:
+		return ": "
+	case GutterPlain:
+		return "  "
+	default:
+		return " | "
+	}
+}
+
+// NumberLines prefixes every line of text with its 1-based number, right-aligned to the
+// widest number. Numbers count the rendered text, so with compaction they follow the
+// compacted content rather than the file on disk.
+func (a Annotations) NumberLines(text string) string {
+	if text == "" {
+		return text
+	}
+
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	width := len(strconv.Itoa(len(lines)))
+	separator := a.gutterSeparator()
+	var b strings.Builder
+	b.Grow(len(text) + len(lines)*(width+len(separator)))
+	for i, line := range lines {
+		fmt.Fprintf(&b, "%*d%s%s", width, i+1, separator, line)
+	}
+	return b.String()
+}
+
+// numberingOverhead returns the bytes NumberLines adds to text
+func (a Annotations) numberingOverhead(text string) int64 {
+	if text == "" {
+		return 0
+	}
+	lines := strings.Count(text, "\n")
+	if !strings.HasSuffix(text, "\n") {
+		lines++
+	}
+	width := len(strconv.Itoa(lines))
+	return int64(lines * (width + len(a.gutterSeparator())))
+}
+
+// FileHeader returns the header attributes for a file, starting with a space:
+// language="go" lines="120" size="3412". Modification times are left out so that
+// regenerating an unchanged file after a checkout or touch yields the same prompt.
+func FileHeader(path string) (string, error) {
+	return fileHeader(nil, path)
+}
+
+// fileHeader is FileHeader for a file of an archive or git revision
+func fileHeader(source *scanner.Source, path string) (string, error) {
+	info, err := source.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	lines, err := countLines(source, path)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(` language="%s" lines="%d" size="%d"`, languageName(path), lines, info.Size()), nil
+}
+
+// countLines counts the lines of a file, including a final line without a newline
+func countLines(source *scanner.Source, path string) (int, error) {
+	file, err := source.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	buf := make([]byte, 32*1024)
+	lines, last := 0, byte('\n')
+	for {
+		n, err := reader.Read(buf)
+		if n > 0 {
+			lines += bytes.Count(buf[:n], []byte{'\n'})
+			last = buf[n-1]
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	if last != '\n' {
+		lines++
+	}
+	return lines, nil
+}
+
+// languageNames maps file extensions to the language named in headers
+var languageNames = map[string]string{
+	".go": "go", ".js": "javascript", ".jsx": "javascript", ".mjs": "javascript", ".cjs": "javascript",
+	".ts": "typescript", ".tsx": "typescript", ".py": "python", ".rb": "ruby", ".rs": "rust",
+	".java": "java", ".kt": "kotlin", ".swift": "swift", ".c": "c", ".h": "c", ".cc": "cpp",
+	".cpp": "cpp", ".hpp": "cpp", ".cs": "csharp", ".scala": "scala", ".php": "php",
+	".sh": "shell", ".bash": "shell", ".zsh": "shell", ".sql": "sql", ".html": "html",
+	".css": "css", ".scss": "scss", ".json": "json", ".yaml": "yaml", ".yml": "yaml",
+	".toml": "toml", ".xml": "xml", ".md": "markdown", ".proto": "protobuf",
+}
+
+// languageName returns the language of a file from its extension, or "text"
+func languageName(path string) string {
+	if name, ok := languageNames[strings.ToLower(filepath.Ext(path))]; ok {
+		return name
+	}
+	switch filepath.Base(path) {
+	case "Makefile":
+		return "make"
+	case "Dockerfile":
+		return "dockerfile"
+	}
+	return "text"
+}
+
+// WithAnnotations adds line numbers and file header attributes to rendered content
+func WithAnnotations(annotations Annotations) Option {
+	return func(b *FileStructureBuilder) {
+		b.annotations = annotations
+	}
+}
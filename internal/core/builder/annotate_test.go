@@ -0,0 +1,129 @@
+package builder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/diogopedro/shotgun/internal/models"
+)
+
+func TestAnnotations_NumberLines(t *testing.T) {
+	text := strings.Repeat("x\n", 9) + "last"
+
+	tests := []struct {
+		gutter string
+		want   string
+	}{
+		{"", " 1 | x\n"},
+		{GutterColon, " 1: x\n"},
+		{GutterPlain, " 1  x\n"},
+	}
+
+	for _, tt := range tests {
+		a := Annotations{LineNumbers: true, Gutter: tt.gutter}
+		got := a.NumberLines(text)
+		if !strings.HasPrefix(got, tt.want) {
+			t.Errorf("gutter %q: got %q, want prefix %q", tt.gutter, got, tt.want)
+		}
+		if !strings.HasSuffix(got, "10"+a.gutterSeparator()+"last") {
+			t.Errorf("gutter %q: last line not numbered: %q", tt.gutter, got)
+		}
+		if overhead := a.numberingOverhead(text); overhead != int64(len(got)-len(text)) {
+			t.Errorf("gutter %q: overhead %d, actual %d", tt.gutter, overhead, len(got)-len(text))
+		}
+	}
+}
+
+func TestParseAnnotations(t *testing.T) {
+	a, err := ParseAnnotations([]string{"line-numbers=colon", "header"})
+	if err != nil {
+		t.Fatalf("ParseAnnotations failed: %v", err)
+	}
+	if !a.LineNumbers || !a.Header || a.Gutter != GutterColon {
+		t.Errorf("unexpected annotations: %+v", a)
+	}
+	if specs := strings.Join(a.Specs(), ","); specs != "line-numbers=colon,header" {
+		t.Errorf("Specs() = %q", specs)
+	}
+
+	if a, err := ParseAnnotations([]string{"none"}); err != nil || a.Enabled() {
+		t.Errorf("none should disable annotations, got %+v, %v", a, err)
+	}
+	for _, bad := range []string{"colour", "line-numbers=dots"} {
+		if _, err := ParseAnnotations([]string{bad}); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestGeneratePrompt_Annotations(t *testing.T) {
+	root := t.TempDir()
+	main := filepath.Join(root, "main.go")
+	notes := filepath.Join(root, "notes.txt")
+	os.WriteFile(main, []byte("package main\n\nfunc main() {}\n"), 0644)
+	os.WriteFile(notes, []byte(strings.Repeat("note\n", 12)), 0644)
+	files := []string{main, notes}
+
+	template := &models.Template{ID: "annotate", Content: "{{FILE_STRUCTURE}}"}
+	annotations := Annotations{LineNumbers: true, Header: true}
+	generate := func(annotations Annotations) *GeneratedPrompt {
+		t.Helper()
+		result, err := NewPromptGenerator().GeneratePrompt(context.Background(), GenerationConfig{
+			Template:      template,
+			SelectedFiles: files,
+			Annotations:   annotations,
+			EmitManifest:  true,
+		})
+		if err != nil {
+			t.Fatalf("GeneratePrompt failed: %v", err)
+		}
+		return result
+	}
+
+	plain := generate(Annotations{})
+	annotated := generate(annotations)
+
+	if !strings.Contains(annotated.Content, `<file path="main.go" language="go" lines="3" size="29">`) {
+		t.Errorf("expected header attributes:\n%s", annotated.Content)
+	}
+	os.Chtimes(main, time.Now().Add(time.Hour), time.Now().Add(time.Hour))
+	if touched := generate(annotations); touched.Content != annotated.Content {
+		t.Error("touching a file should not change its header")
+	}
+	if !strings.Contains(annotated.Content, "1 | package main\n2 | \n3 | func main() {}\n") {
+		t.Errorf("expected numbered lines:\n%s", annotated.Content)
+	}
+	if !strings.Contains(annotated.Content, "12 | note\n") || !strings.Contains(annotated.Content, " 1 | note\n") {
+		t.Errorf("expected right-aligned numbers:\n%s", annotated.Content)
+	}
+
+	config, err := annotated.Manifest.RegenerationConfig(template)
+	if err != nil {
+		t.Fatalf("RegenerationConfig failed: %v", err)
+	}
+	if config.Annotations != annotations {
+		t.Errorf("manifest restored %+v, want %+v", config.Annotations, annotations)
+	}
+
+	// The estimate grows by exactly the bytes annotations add
+	estimator := NewSizeEstimator(&mockTemplateProcessor{})
+	estimate := func(annotations Annotations) int64 {
+		t.Helper()
+		result, err := estimator.EstimatePromptSize(context.Background(), EstimationConfig{
+			Template:      template,
+			SelectedFiles: files,
+			Annotations:   annotations,
+		})
+		if err != nil {
+			t.Fatalf("EstimatePromptSize failed: %v", err)
+		}
+		return result.TotalSize
+	}
+	if got, want := estimate(annotations)-estimate(Annotations{}), int64(len(annotated.Content)-len(plain.Content)); got != want {
+		t.Errorf("estimated overhead %d, actual %d", got, want)
+	}
+}
@@ -0,0 +1,179 @@
+package builder
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ChunkConfig splits a prompt into numbered parts for chat UIs that cap message size
+type ChunkConfig struct {
+	MaxBytes  int64 `json:"max_bytes,omitempty"`  // Maximum bytes per part (0 = no byte limit)
+	MaxTokens int64 `json:"max_tokens,omitempty"` // Maximum estimated tokens per part (0 = no token limit)
+}
+
+// Limit returns the effective per-part size limit in bytes
+func (c ChunkConfig) Limit() int64 {
+	limit := c.MaxBytes
+	if c.MaxTokens > 0 {
+		tokenBytes := c.MaxTokens * BytesPerToken
+		if limit <= 0 || tokenBytes < limit {
+			limit = tokenBytes
+		}
+	}
+	return limit
+}
+
+// FileBoundaryWriter is notified after each complete file block is written,
+// letting consumers split output on file boundaries
+type FileBoundaryWriter interface {
+	io.Writer
+	FileBoundary() error
+}
+
+// bufferedBoundary flushes buffered output before forwarding a file boundary
+type bufferedBoundary struct {
+	*bufio.Writer
+	target FileBoundaryWriter
+}
+
+// FileBoundary implements FileBoundaryWriter
+func (b *bufferedBoundary) FileBoundary() error {
+	if err := b.Flush(); err != nil {
+		return err
+	}
+	return b.target.FileBoundary()
+}
+
+// chunkCollector buffers output as units that end on file boundaries
+type chunkCollector struct {
+	units   []string
+	current bytes.Buffer
+}
+
+// Write implements io.Writer
+func (c *chunkCollector) Write(p []byte) (int, error) {
+	return c.current.Write(p)
+}
+
+// FileBoundary closes the current unit
+func (c *chunkCollector) FileBoundary() error {
+	c.units = append(c.units, c.current.String())
+	c.current.Reset()
+	return nil
+}
+
+// finish returns all units including any trailing content
+func (c *chunkCollector) finish() []string {
+	if c.current.Len() > 0 || len(c.units) == 0 {
+		c.units = append(c.units, c.current.String())
+		c.current.Reset()
+	}
+	return c.units
+}
+
+// partHeader and partFooter frame each part so the model waits for the full prompt
+const (
+	partHeader      = "[Part %d of %d] This prompt is split into %d parts. Do not respond until you have received all of them; reply only \"Received part %d of %d\" and wait for the next part.\n\n"
+	finalPartHeader = "[Part %d of %d] This is the final part. You now have the complete prompt, proceed with the task.\n\n"
+	partFooter      = "\n\n[End of part %d of %d - wait for part %d]\n"
+)
+
+// framingOverhead reserves room for the header and footer of a part
+func framingOverhead(total int) int64 {
+	digits := len(fmt.Sprint(total))
+	return int64(len(partHeader) + len(partFooter) + 8*digits)
+}
+
+// splitIntoParts packs units greedily into parts under the limit and adds framing.
+// A unit larger than the limit becomes a part of its own.
+func splitIntoParts(units []string, limit int64) []string {
+	if limit <= 0 {
+		return []string{joinUnits(units)}
+	}
+
+	// The framing size depends on the part count; repeat until it is stable
+	count := 1
+	var groups [][]string
+	for attempt := 0; attempt < 3; attempt++ {
+		budget := limit - framingOverhead(count)
+		if budget < 1 {
+			budget = 1
+		}
+		groups = packUnits(units, budget)
+		if len(groups) == count {
+			break
+		}
+		count = len(groups)
+	}
+
+	if len(groups) == 1 {
+		return []string{joinUnits(groups[0])}
+	}
+
+	total := len(groups)
+	parts := make([]string, total)
+	for i, group := range groups {
+		n := i + 1
+		var part bytes.Buffer
+		if n == total {
+			fmt.Fprintf(&part, finalPartHeader, n, total)
+		} else {
+			fmt.Fprintf(&part, partHeader, n, total, total, n, total)
+		}
+		part.WriteString(joinUnits(group))
+		if n < total {
+			fmt.Fprintf(&part, partFooter, n, total, n+1)
+		}
+		parts[i] = part.String()
+	}
+	return parts
+}
+
+// packUnits groups consecutive units so each group stays within budget where possible
+func packUnits(units []string, budget int64) [][]string {
+	var groups [][]string
+	var current []string
+	var size int64
+
+	for _, unit := range units {
+		unitSize := int64(len(unit))
+		if len(current) > 0 && size+unitSize > budget {
+			groups = append(groups, current)
+			current, size = nil, 0
+		}
+		current = append(current, unit)
+		size += unitSize
+	}
+	if len(current) > 0 {
+		groups = append(groups, current)
+	}
+	return groups
+}
+
+// joinUnits concatenates units
+func joinUnits(units []string) string {
+	var b bytes.Buffer
+	for _, u := range units {
+		b.WriteString(u)
+	}
+	return b.String()
+}
+
+// partSuffixPattern matches the numbered part suffix of a prompt file
+var partSuffixPattern = regexp.MustCompile(`_part\d+(\.[^./]*)$`)
+
+// PartFilename returns the file name for part n of a prompt path
+func PartFilename(promptPath string, n int) string {
+	ext := filepath.Ext(promptPath)
+	return fmt.Sprintf("%s_part%02d%s", strings.TrimSuffix(promptPath, ext), n, ext)
+}
+
+// partBasePath returns the logical prompt path a part file belongs to
+func partBasePath(partPath string) string {
+	return partSuffixPattern.ReplaceAllString(partPath, "$1")
+}
@@ -0,0 +1,163 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/diogopedro/shotgun/internal/models"
+)
+
+func TestChunkConfig_Limit(t *testing.T) {
+	tests := []struct {
+		config ChunkConfig
+		want   int64
+	}{
+		{ChunkConfig{}, 0},
+		{ChunkConfig{MaxBytes: 1000}, 1000},
+		{ChunkConfig{MaxTokens: 100}, 100 * BytesPerToken},
+		{ChunkConfig{MaxBytes: 1000, MaxTokens: 100}, 100 * BytesPerToken},
+		{ChunkConfig{MaxBytes: 200, MaxTokens: 100}, 200},
+	}
+
+	for _, tt := range tests {
+		if got := tt.config.Limit(); got != tt.want {
+			t.Errorf("Limit(%+v) = %d, want %d", tt.config, got, tt.want)
+		}
+	}
+}
+
+func TestPartFilename(t *testing.T) {
+	got := PartFilename("/tmp/shotgun_prompt_20240101_1200.md", 3)
+	if got != "/tmp/shotgun_prompt_20240101_1200_part03.md" {
+		t.Errorf("Unexpected part filename: %s", got)
+	}
+	if base := partBasePath(got); base != "/tmp/shotgun_prompt_20240101_1200.md" {
+		t.Errorf("Unexpected base path: %s", base)
+	}
+}
+
+func chunkTestFiles(t *testing.T, count, size int) []string {
+	t.Helper()
+	tempDir := t.TempDir()
+	files := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		path := filepath.Join(tempDir, fmt.Sprintf("file%02d.txt", i))
+		if err := os.WriteFile(path, []byte(strings.Repeat("x", size)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, path)
+	}
+	return files
+}
+
+func TestGeneratePrompt_SplitsOnFileBoundaries(t *testing.T) {
+	files := chunkTestFiles(t, 6, 400)
+	config := GenerationConfig{
+		Template: &models.Template{
+			ID:      "chunk",
+			Content: "PREAMBLE {{TASK}}\n{{FILE_STRUCTURE}}\nPOSTSCRIPT",
+		},
+		TaskContent:   "split me",
+		SelectedFiles: files,
+		Chunking:      &ChunkConfig{MaxBytes: 1500},
+		EmitManifest:  true,
+	}
+
+	result, err := NewPromptGenerator().GeneratePrompt(context.Background(), config)
+	if err != nil {
+		t.Fatalf("GeneratePrompt failed: %v", err)
+	}
+
+	if len(result.Parts) < 2 {
+		t.Fatalf("Expected multiple parts, got %d", len(result.Parts))
+	}
+
+	total := len(result.Parts)
+	for i, part := range result.Parts {
+		n := i + 1
+		if !strings.HasPrefix(part, fmt.Sprintf("[Part %d of %d]", n, total)) {
+			t.Errorf("Part %d missing framing header: %q", n, part[:40])
+		}
+		if int64(len(part)) > config.Chunking.Limit() {
+			t.Errorf("Part %d exceeds limit: %d bytes", n, len(part))
+		}
+		if strings.Count(part, "<file path=") != strings.Count(part, "</file>") {
+			t.Errorf("Part %d splits a file block", n)
+		}
+		if n < total && !strings.Contains(part, "wait for part") {
+			t.Errorf("Part %d missing continuation footer", n)
+		}
+	}
+
+	if !strings.Contains(result.Parts[0], "PREAMBLE split me") {
+		t.Error("First part should carry the template preamble")
+	}
+	if !strings.Contains(result.Parts[total-1], "POSTSCRIPT") {
+		t.Error("Last part should carry the template tail")
+	}
+	if strings.Contains(result.Parts[1], "PREAMBLE") {
+		t.Error("Preamble should only appear in the first part")
+	}
+
+	if result.Manifest.Chunking == nil || len(result.Manifest.Parts) != total {
+		t.Errorf("Manifest should record chunking and %d parts, got %+v", total, result.Manifest.Parts)
+	}
+}
+
+func TestGeneratePrompt_SmallPromptIsNotSplit(t *testing.T) {
+	files := chunkTestFiles(t, 2, 10)
+	config := GenerationConfig{
+		Template:      &models.Template{ID: "chunk", Content: "{{FILE_STRUCTURE}}"},
+		SelectedFiles: files,
+		Chunking:      &ChunkConfig{MaxTokens: 10_000},
+	}
+
+	result, err := NewPromptGenerator().GeneratePrompt(context.Background(), config)
+	if err != nil {
+		t.Fatalf("GeneratePrompt failed: %v", err)
+	}
+	if len(result.Parts) != 0 {
+		t.Errorf("Expected no parts for a small prompt, got %d", len(result.Parts))
+	}
+	if strings.Contains(result.Content, "[Part") {
+		t.Error("Unsplit prompt should not carry part framing")
+	}
+}
+
+func TestGenerateToFile_WritesParts(t *testing.T) {
+	files := chunkTestFiles(t, 4, 600)
+	outDir := t.TempDir()
+	config := GenerationConfig{
+		Template:      &models.Template{ID: "chunk", Content: "{{FILE_STRUCTURE}}"},
+		SelectedFiles: files,
+		OutputPath:    outDir,
+		Chunking:      &ChunkConfig{MaxBytes: 1200},
+	}
+
+	result, err := NewPromptGenerator().GenerateToFile(context.Background(), config, NewFileWriter())
+	if err != nil {
+		t.Fatalf("GenerateToFile failed: %v", err)
+	}
+
+	if len(result.PartFiles) < 2 {
+		t.Fatalf("Expected part files, got %v", result.PartFiles)
+	}
+	for i, path := range result.PartFiles {
+		if !strings.HasSuffix(path, fmt.Sprintf("_part%02d.md", i+1)) {
+			t.Errorf("Unexpected part file name: %s", path)
+		}
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Part file not written: %v", err)
+		}
+	}
+	if result.OutputFile != result.PartFiles[0] {
+		t.Errorf("OutputFile should be the first part, got %s", result.OutputFile)
+	}
+	if target := result.ManifestTarget(); strings.Contains(target, "_part") {
+		t.Errorf("Manifest target should be the unsplit prompt path, got %s", target)
+	}
+}
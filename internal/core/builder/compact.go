@@ -0,0 +1,505 @@
+package builder
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/diogopedro/shotgun/internal/models"
+)
+
+// Compaction mode names accepted in templates and on the command line
+const (
+	CompactStripComments = models.CompactStripComments
+	CompactCollapseBlank = models.CompactCollapseBlank
+	CompactDropLicense   = models.CompactDropLicense
+	CompactTrimTrailing  = models.CompactTrimTrailing
+)
+
+// Compaction selects content transforms applied to each file before it is embedded
+type Compaction struct {
+	StripComments bool // Remove comments using a language-aware lexer
+	CollapseBlank bool // Collapse runs of blank lines into one
+	DropLicense   bool // Drop a leading license/copyright comment block
+	TrimTrailing  bool // Trim trailing whitespace from every line
+}
+
+// FullCompaction enables every compaction mode
+func FullCompaction() Compaction {
+	return Compaction{StripComments: true, CollapseBlank: true, DropLicense: true, TrimTrailing: true}
+}
+
+// ParseCompaction builds a Compaction from mode names ("all" enables every mode)
+func ParseCompaction(modes []string) (Compaction, error) {
+	names, err := models.NormalizeCompaction(modes)
+	if err != nil {
+		return Compaction{}, err
+	}
+
+	var c Compaction
+	for _, name := range names {
+		switch name {
+		case CompactStripComments:
+			c.StripComments = true
+		case CompactCollapseBlank:
+			c.CollapseBlank = true
+		case CompactDropLicense:
+			c.DropLicense = true
+		case CompactTrimTrailing:
+			c.TrimTrailing = true
+		}
+	}
+	return c, nil
+}
+
+// Enabled reports whether any compaction mode is selected
+func (c Compaction) Enabled() bool {
+	return c.StripComments || c.CollapseBlank || c.DropLicense || c.TrimTrailing
+}
+
+// Modes returns the selected mode names in canonical order
+func (c Compaction) Modes() []string {
+	var modes []string
+	if c.DropLicense {
+		modes = append(modes, CompactDropLicense)
+	}
+	if c.StripComments {
+		modes = append(modes, CompactStripComments)
+	}
+	if c.TrimTrailing {
+		modes = append(modes, CompactTrimTrailing)
+	}
+	if c.CollapseBlank {
+		modes = append(modes, CompactCollapseBlank)
+	}
+	return modes
+}
+
+// Apply runs the selected transforms over a file's content
+func (c Compaction) Apply(path, content string) string {
+	lang := languageForPath(path)
+
+	if c.DropLicense {
+		content = dropLicenseHeader(lang, content)
+	}
+	if c.StripComments {
+		switch lang {
+		case langCLike, langGo, langJS:
+			content = stripCLikeComments(lang, content)
+		case langPython:
+			content = stripHashComments(content, false)
+		case langShell:
+			content = stripHashComments(content, true)
+		}
+	}
+	if c.TrimTrailing {
+		content = trimTrailingWhitespace(content)
+	}
+	if c.CollapseBlank {
+		content = collapseBlankLines(content)
+	}
+	return content
+}
+
+// commentLanguage identifies the comment syntax of a file
+type commentLanguage int
+
+const (
+	langUnknown commentLanguage = iota
+	langGo
+	langJS
+	langCLike
+	langPython
+	langShell
+)
+
+// languageForPath picks the comment syntax from the file extension
+func languageForPath(path string) commentLanguage {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".go":
+		return langGo
+	case ".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx", ".mts", ".cts":
+		return langJS
+	case ".c", ".h", ".cc", ".cpp", ".hpp", ".java", ".kt", ".rs", ".swift", ".cs", ".scala":
+		return langCLike
+	case ".py", ".pyi":
+		return langPython
+	case ".sh", ".bash", ".zsh", ".ksh":
+		return langShell
+	}
+	return langUnknown
+}
+
+// commentStripper accumulates output and drops lines left empty by comment removal
+type commentStripper struct {
+	out        []byte
+	lineStart  int  // Offset in out where the current line begins
+	hadComment bool // A comment was removed from the current line
+}
+
+// writeByte appends a code byte, finishing the line on newline
+func (s *commentStripper) writeByte(c byte) {
+	if c == '\n' {
+		s.endLine()
+		return
+	}
+	s.out = append(s.out, c)
+}
+
+// writeString appends code text
+func (s *commentStripper) writeString(text string) {
+	for i := 0; i < len(text); i++ {
+		s.writeByte(text[i])
+	}
+}
+
+// trimCommentLine trims whitespace left behind on a line a comment was removed from.
+// It reports whether the line is now empty.
+func (s *commentStripper) trimCommentLine() bool {
+	line := strings.TrimRight(string(s.out[s.lineStart:]), " \t\r")
+	s.out = s.out[:s.lineStart+len(line)]
+	return strings.TrimSpace(line) == ""
+}
+
+// endLine terminates the current line, removing it if only a comment was on it
+func (s *commentStripper) endLine() {
+	if s.hadComment {
+		s.hadComment = false
+		if s.trimCommentLine() {
+			s.out = s.out[:s.lineStart]
+			return
+		}
+	}
+	s.out = append(s.out, '\n')
+	s.lineStart = len(s.out)
+}
+
+// finish returns the output, flushing a final unterminated line
+func (s *commentStripper) finish() string {
+	if s.hadComment && s.trimCommentLine() {
+		s.out = s.out[:s.lineStart]
+	}
+	return string(s.out)
+}
+
+// stripCLikeComments removes // and /* */ comments while respecting string, rune,
+// raw string and (for JS/TS) template and regular expression literals
+func stripCLikeComments(lang commentLanguage, src string) string {
+	var s commentStripper
+	var prev byte // Last significant code byte, used to spot JS regex literals
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '/' && i+1 < len(src) && src[i+1] == '/':
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				end = len(src) - i
+			}
+			comment := src[i : i+end]
+			// Go compiler directives are semantically meaningful
+			if lang == langGo && (strings.HasPrefix(comment, "//go:") || strings.HasPrefix(comment, "// +build")) {
+				s.writeString(comment)
+			} else {
+				s.hadComment = true
+			}
+			i += end
+
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				end = len(src) - i - 2
+			} else {
+				end += 2
+			}
+			comment := src[i : i+2+end]
+			s.hadComment = true
+			if strings.Contains(comment, "\n") {
+				s.endLine()
+				s.hadComment = true
+			} else {
+				s.writeByte(' ')
+			}
+			i += 2 + end
+
+		case c == '"' || c == '\'' || (c == '`' && (lang == langGo || lang == langJS)):
+			end := scanQuoted(src, i, c, !(c == '`' && lang == langGo))
+			s.writeString(src[i:end])
+			prev = c
+			i = end
+
+		case c == '/' && lang == langJS && startsRegex(prev):
+			end := scanRegex(src, i)
+			s.writeString(src[i:end])
+			prev = '/'
+			i = end
+
+		default:
+			s.writeByte(c)
+			if c != ' ' && c != '\t' && c != '\n' && c != '\r' {
+				prev = c
+			}
+			i++
+		}
+	}
+
+	return s.finish()
+}
+
+// scanQuoted returns the offset just past a quoted literal starting at i.
+// Single and double quoted literals end at an unescaped newline as well.
+func scanQuoted(src string, i int, quote byte, escapes bool) int {
+	multiline := quote == '`'
+	for j := i + 1; j < len(src); j++ {
+		switch src[j] {
+		case '\\':
+			if escapes {
+				j++
+			}
+		case quote:
+			return j + 1
+		case '\n':
+			if !multiline {
+				return j
+			}
+		}
+	}
+	return len(src)
+}
+
+// startsRegex reports whether a '/' after prev begins a JS regular expression literal
+func startsRegex(prev byte) bool {
+	return prev == 0 || strings.IndexByte("(,=:[!&|?{};+-*%<>~^", prev) >= 0
+}
+
+// scanRegex returns the offset just past a JS regular expression literal starting at i
+func scanRegex(src string, i int) int {
+	inClass := false
+	for j := i + 1; j < len(src); j++ {
+		switch src[j] {
+		case '\\':
+			j++
+		case '[':
+			inClass = true
+		case ']':
+			inClass = false
+		case '/':
+			if !inClass {
+				return j + 1
+			}
+		case '\n':
+			return j
+		}
+	}
+	return len(src)
+}
+
+// stripHashComments removes # comments for Python and shell. Python docstrings are kept
+// because removing them can leave empty bodies. Shell comments only start at a word boundary.
+func stripHashComments(src string, shell bool) string {
+	var s commentStripper
+	atWordStart := true
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '#' && (!shell || atWordStart):
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				end = len(src) - i
+			}
+			// Keep the shebang line
+			if i == 0 && strings.HasPrefix(src, "#!") {
+				s.writeString(src[:end])
+			} else {
+				s.hadComment = true
+			}
+			i += end
+
+		case !shell && (strings.HasPrefix(src[i:], `"""`) || strings.HasPrefix(src[i:], `'''`)):
+			end := strings.Index(src[i+3:], src[i:i+3])
+			if end < 0 {
+				end = len(src)
+			} else {
+				end = i + 3 + end + 3
+			}
+			s.writeString(src[i:end])
+			i = end
+
+		case c == '"' || c == '\'':
+			var end int
+			if shell && c == '\'' {
+				// Single quotes in shell have no escapes and may span lines
+				end = strings.IndexByte(src[i+1:], '\'')
+				if end < 0 {
+					end = len(src)
+				} else {
+					end = i + 1 + end + 1
+				}
+			} else if shell {
+				end = scanShellDouble(src, i)
+			} else {
+				end = scanQuoted(src, i, c, true)
+			}
+			s.writeString(src[i:end])
+			atWordStart = false
+			i = end
+
+		default:
+			s.writeByte(c)
+			atWordStart = c == ' ' || c == '\t' || c == '\n' || c == ';' || c == '(' || c == '|' || c == '&'
+			if c == '\\' && i+1 < len(src) {
+				s.writeByte(src[i+1])
+				i++
+			}
+			i++
+		}
+	}
+
+	return s.finish()
+}
+
+// scanShellDouble returns the offset just past a double-quoted shell string, which may span lines
+func scanShellDouble(src string, i int) int {
+	for j := i + 1; j < len(src); j++ {
+		switch src[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1
+		}
+	}
+	return len(src)
+}
+
+// copyrightLine matches a comment line starting with a copyright notice, e.g. "// Copyright 2024 The Authors"
+var copyrightLine = regexp.MustCompile(`(?im)^[\s/*#-]*(copyright\b|\(c\)|©)`)
+
+// licensePreambles open the headers of common licenses
+var licensePreambles = []string{
+	"spdx-license-identifier",
+	"licensed under the apache license",
+	"permission is hereby granted, free of charge",
+	"redistribution and use in source and binary forms",
+	"this program is free software",
+	"use of this source code is governed by",
+	"this source code form is subject to the terms of the mozilla public license",
+	"all rights reserved",
+}
+
+// dropLicenseHeader removes a leading comment block that mentions licensing.
+// A shebang line and Go build directives ahead of the header are preserved.
+func dropLicenseHeader(lang commentLanguage, src string) string {
+	var kept strings.Builder
+	rest := src
+
+	// Preserve a shebang
+	if strings.HasPrefix(rest, "#!") {
+		end := strings.IndexByte(rest, '\n')
+		if end < 0 {
+			return src
+		}
+		kept.WriteString(rest[:end+1])
+		rest = rest[end+1:]
+	}
+
+	header, after := leadingComment(lang, strings.TrimLeft(rest, " \t\r\n"))
+	if header == "" || !mentionsLicense(header) {
+		return src
+	}
+
+	// Skip blank lines that separated the header from the code
+	kept.WriteString(strings.TrimLeft(after, " \t\r\n"))
+	return kept.String()
+}
+
+// leadingComment splits off the comment block at the start of src
+func leadingComment(lang commentLanguage, src string) (string, string) {
+	hashStyle := lang == langPython || lang == langShell || lang == langUnknown
+	slashStyle := lang != langPython && lang != langShell
+
+	if slashStyle && strings.HasPrefix(src, "/*") {
+		end := strings.Index(src, "*/")
+		if end < 0 {
+			return "", src
+		}
+		return src[:end+2], src[end+2:]
+	}
+
+	var prefix string
+	switch {
+	case slashStyle && strings.HasPrefix(src, "//"):
+		prefix = "//"
+	case hashStyle && strings.HasPrefix(src, "#") && !strings.HasPrefix(src, "#!"):
+		prefix = "#"
+	default:
+		return "", src
+	}
+
+	// Consume consecutive line comments
+	offset := 0
+	for offset < len(src) {
+		line := src[offset:]
+		end := strings.IndexByte(line, '\n')
+		if end < 0 {
+			end = len(line)
+		} else {
+			end++
+		}
+		text := strings.TrimLeft(line[:end], " \t")
+		if !strings.HasPrefix(text, prefix) || (prefix == "//" && strings.HasPrefix(text, "//go:")) {
+			break
+		}
+		offset += end
+	}
+	return src[:offset], src[offset:]
+}
+
+// mentionsLicense reports whether a comment block is license boilerplate: it has a
+// copyright line, an SPDX identifier or a known license preamble. Documentation that
+// merely mentions licensing is kept.
+func mentionsLicense(comment string) bool {
+	if copyrightLine.MatchString(comment) {
+		return true
+	}
+	lower := strings.Join(strings.Fields(strings.ToLower(comment)), " ")
+	for _, preamble := range licensePreambles {
+		if strings.Contains(lower, preamble) {
+			return true
+		}
+	}
+	return false
+}
+
+// trimTrailingWhitespace removes spaces and tabs at the end of every line, keeping CRLF endings
+func trimTrailingWhitespace(src string) string {
+	lines := strings.Split(src, "\n")
+	for i, line := range lines {
+		cr := strings.HasSuffix(line, "\r")
+		line = strings.TrimRight(strings.TrimSuffix(line, "\r"), " \t")
+		if cr {
+			line += "\r"
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// collapseBlankLines reduces runs of blank lines to a single blank line
+func collapseBlankLines(src string) string {
+	lines := strings.Split(src, "\n")
+	out := lines[:0]
+	blankRun := 0
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			blankRun++
+			if blankRun > 1 {
+				continue
+			}
+		} else {
+			blankRun = 0
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,191 @@
+package builder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/diogopedro/shotgun/internal/models"
+)
+
+func TestParseCompaction(t *testing.T) {
+	c, err := ParseCompaction([]string{"strip-comments", " Trim-Trailing "})
+	if err != nil {
+		t.Fatalf("ParseCompaction failed: %v", err)
+	}
+	if !c.StripComments || !c.TrimTrailing || c.CollapseBlank || c.DropLicense {
+		t.Errorf("Unexpected compaction: %+v", c)
+	}
+
+	if all, _ := ParseCompaction([]string{"all"}); all != FullCompaction() {
+		t.Errorf("Expected all modes, got %+v", all)
+	}
+
+	if _, err := ParseCompaction([]string{"minify"}); err == nil {
+		t.Error("Expected error for unknown mode")
+	}
+}
+
+func TestStripComments_Go(t *testing.T) {
+	src := "//go:build linux\n\npackage main\n\n// Doc comment\nfunc main() {\n\ts := \"// not a comment\" // trailing\n\tr := `/* raw */`\n\t/* block\n\tcomment */\n\tx := 1 /* inline */ + 2\n\t_ = '/'\n}\n"
+	want := "//go:build linux\n\npackage main\n\nfunc main() {\n\ts := \"// not a comment\"\n\tr := `/* raw */`\n\tx := 1   + 2\n\t_ = '/'\n}\n"
+
+	got := Compaction{StripComments: true}.Apply("main.go", src)
+	if got != want {
+		t.Errorf("Unexpected Go output:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestStripComments_JavaScript(t *testing.T) {
+	src := "const url = \"http://x\"; // remove\nconst re = /https?:\\/\\//g;\nconst t = `a // b`;\nconst d = a / b / c; /* gone */\n"
+	want := "const url = \"http://x\";\nconst re = /https?:\\/\\//g;\nconst t = `a // b`;\nconst d = a / b / c;\n"
+
+	got := Compaction{StripComments: true}.Apply("app.ts", src)
+	if got != want {
+		t.Errorf("Unexpected JS output:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestStripComments_Python(t *testing.T) {
+	src := "#!/usr/bin/env python\n# comment\ndef f():\n    \"\"\"Docstring # kept\"\"\"\n    s = '# not comment'  # trailing\n    return s\n"
+	want := "#!/usr/bin/env python\ndef f():\n    \"\"\"Docstring # kept\"\"\"\n    s = '# not comment'\n    return s\n"
+
+	got := Compaction{StripComments: true}.Apply("mod.py", src)
+	if got != want {
+		t.Errorf("Unexpected Python output:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestStripComments_Shell(t *testing.T) {
+	src := "#!/bin/sh\n# setup\necho \"$# args # kept\" # note\necho ${#var} 'a # b'\n"
+	want := "#!/bin/sh\necho \"$# args # kept\"\necho ${#var} 'a # b'\n"
+
+	got := Compaction{StripComments: true}.Apply("run.sh", src)
+	if got != want {
+		t.Errorf("Unexpected shell output:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestDropLicenseHeader(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		src  string
+		want string
+	}{
+		{
+			name: "go line comments",
+			path: "a.go",
+			src:  "// Copyright 2024 Example\n// Licensed under MIT\n\npackage a\n",
+			want: "package a\n",
+		},
+		{
+			name: "block comment",
+			path: "a.js",
+			src:  "/*\n * SPDX-License-Identifier: Apache-2.0\n */\nexport {}\n",
+			want: "export {}\n",
+		},
+		{
+			name: "shebang preserved",
+			path: "a.sh",
+			src:  "#!/bin/bash\n# Copyright (c) Example\n\necho hi\n",
+			want: "#!/bin/bash\necho hi\n",
+		},
+		{
+			name: "ordinary doc comment kept",
+			path: "a.go",
+			src:  "// Package a does things\npackage a\n",
+			want: "// Package a does things\npackage a\n",
+		},
+		{
+			name: "doc comment mentioning licenses kept",
+			path: "license.go",
+			src:  "// Package license validates licensed features against the customer's license key.\npackage license\n",
+			want: "// Package license validates licensed features against the customer's license key.\npackage license\n",
+		},
+		{
+			name: "license preamble without copyright",
+			path: "a.py",
+			src:  "# Licensed under the Apache License, Version 2.0 (the \"License\");\n\nimport os\n",
+			want: "import os\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Compaction{DropLicense: true}.Apply(tt.path, tt.src)
+			if got != tt.want {
+				t.Errorf("Got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrimAndCollapse(t *testing.T) {
+	src := "a  \t\r\n\n\n\nb \n\n"
+	got := Compaction{TrimTrailing: true, CollapseBlank: true}.Apply("notes.txt", src)
+	if got != "a\r\n\nb\n" {
+		t.Errorf("Unexpected output: %q", got)
+	}
+}
+
+func TestGeneratePrompt_AppliesCompaction(t *testing.T) {
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "main.go")
+	os.WriteFile(path, []byte("// Copyright Example\n\npackage main\n\n// comment\nfunc main() {}\n"), 0644)
+
+	config := GenerationConfig{
+		Template:      &models.Template{ID: "compact", Content: "{{FILE_STRUCTURE}}"},
+		SelectedFiles: []string{path},
+		Compaction:    FullCompaction(),
+		EmitManifest:  true,
+	}
+
+	result, err := NewPromptGenerator().GeneratePrompt(context.Background(), config)
+	if err != nil {
+		t.Fatalf("GeneratePrompt failed: %v", err)
+	}
+	if strings.Contains(result.Content, "Copyright") || strings.Contains(result.Content, "// comment") {
+		t.Errorf("Compaction not applied:\n%s", result.Content)
+	}
+	if len(result.Manifest.Compaction) != 4 {
+		t.Errorf("Manifest should record compaction modes, got %v", result.Manifest.Compaction)
+	}
+}
+
+func TestEstimatePromptSize_CompactionSavings(t *testing.T) {
+	tempDir := t.TempDir()
+	commented := filepath.Join(tempDir, "a.go")
+	plain := filepath.Join(tempDir, "b.go")
+	os.WriteFile(commented, []byte("package a\n\n// "+strings.Repeat("x", 200)+"\nvar A = 1\n"), 0644)
+	os.WriteFile(plain, []byte("package b\n"), 0644)
+
+	estimator := NewSizeEstimator(nil)
+	config := EstimationConfig{
+		Template:      &models.Template{Content: "{{FILE_STRUCTURE}}"},
+		SelectedFiles: []string{commented, plain},
+	}
+
+	base, err := estimator.EstimatePromptSize(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config.Compaction = Compaction{StripComments: true}
+	compacted, err := estimator.EstimatePromptSize(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(compacted.FileSavings) != 1 || compacted.FileSavings[0].Path != commented {
+		t.Fatalf("Expected savings for the commented file only, got %+v", compacted.FileSavings)
+	}
+	if compacted.SavedSize < 200 || compacted.FileSavings[0].SavedTokens() < 50 {
+		t.Errorf("Unexpected savings: %d bytes", compacted.SavedSize)
+	}
+	if base.FileContentSize-compacted.FileContentSize != compacted.SavedSize {
+		t.Errorf("File content size should shrink by the savings")
+	}
+}
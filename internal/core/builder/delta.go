@@ -0,0 +1,132 @@
+package builder
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// DeltaStatus classifies a file relative to a previous generation
+type DeltaStatus string
+
+const (
+	DeltaNew       DeltaStatus = "new"
+	DeltaChanged   DeltaStatus = "changed"
+	DeltaUnchanged DeltaStatus = "unchanged"
+)
+
+// unchangedMarker is rendered instead of file content for unchanged files
+const unchangedMarker = "unchanged since previous context"
+
+// DeltaContext describes the previous generation a delta prompt is relative to
+type DeltaContext struct {
+	// Hashes maps file path to the SHA-256 recorded in the previous manifest
+	Hashes map[string]string `json:"hashes"`
+
+	// PreviousContents maps file path to the content sent in the previous prompt (optional)
+	PreviousContents map[string]string `json:"previous_contents,omitempty"`
+
+	// ShowDiffs renders unified diffs for changed files when previous content is known
+	ShowDiffs bool `json:"show_diffs,omitempty"`
+}
+
+// NewDeltaContext creates a delta context from a previous manifest
+func NewDeltaContext(previous *Manifest) *DeltaContext {
+	delta := &DeltaContext{
+		Hashes:           make(map[string]string),
+		PreviousContents: make(map[string]string),
+	}
+	if previous != nil {
+		for _, f := range previous.Files {
+			delta.Hashes[f.Path] = f.SHA256
+		}
+	}
+	return delta
+}
+
+// LoadDeltaContext loads a manifest and, when still present, the prompt it describes
+// so that changed files can be rendered as diffs against what was previously sent
+func LoadDeltaContext(manifestPath string, showDiffs bool) (*DeltaContext, error) {
+	manifest, err := LoadManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	delta := NewDeltaContext(manifest)
+	delta.ShowDiffs = showDiffs
+
+	if data, err := os.ReadFile(PromptPathFromManifest(manifestPath)); err == nil {
+		delta.PreviousContents = ParsePromptFiles(string(data))
+	}
+
+	return delta, nil
+}
+
+// Status returns the delta status of a file given its current hash
+func (d *DeltaContext) Status(path, currentHash string) DeltaStatus {
+	previous, ok := d.Hashes[path]
+	switch {
+	case !ok:
+		return DeltaNew
+	case previous != currentHash:
+		return DeltaChanged
+	default:
+		return DeltaUnchanged
+	}
+}
+
+// filePattern matches rendered <file path="...">content</file> blocks
+var filePattern = regexp.MustCompile(`(?s)<file path="([^"]*)"([^>]*)>(.*?)</file>`)
+
+// ParsePromptFiles extracts the unescaped file contents from a generated prompt.
+// Blocks that were themselves delta markers or diffs are skipped since they do not hold full content.
+func ParsePromptFiles(prompt string) map[string]string {
+	contents := make(map[string]string)
+	for _, match := range filePattern.FindAllStringSubmatch(prompt, -1) {
+		attrs := match[2]
+		if strings.Contains(attrs, `status="unchanged"`) || strings.Contains(attrs, `format="diff"`) {
+			continue
+		}
+		contents[html.UnescapeString(match[1])] = html.UnescapeString(match[3])
+	}
+	return contents
+}
+
+// applyDelta labels a rendered file with its status relative to the previous generation,
+// given the attributes of its render mode. Unchanged files keep only their header
+// attributes and a marker; changed files become a diff when diffs were requested and
+// the previous content is known.
+func (b *FileStructureBuilder) applyDelta(path, display string, current fileContent, attrs string) (string, string) {
+	if b.delta == nil {
+		return attrs, current.content
+	}
+
+	_, sum, err := hashFile(b.source, path)
+	if err != nil {
+		return attrs, current.content
+	}
+
+	status := b.delta.Status(path, sum)
+	switch status {
+	case DeltaUnchanged:
+		return fmt.Sprintf(` status="%s"%s`, status, current.header), unchangedMarker
+
+	case DeltaChanged:
+		if previous, known := b.delta.previousContent(path, display); known && b.delta.ShowDiffs {
+			diff := UnifiedDiff(previous, html.UnescapeString(current.content), display)
+			return fmt.Sprintf(` status="%s"%s format="diff"`, status, current.header), html.EscapeString(diff)
+		}
+	}
+	return fmt.Sprintf(` status="%s"%s`, status, attrs), current.content
+}
+
+// previousContent returns the content a file had in the previous prompt, by path or display path
+func (d *DeltaContext) previousContent(path, display string) (string, bool) {
+	if previous, ok := d.PreviousContents[path]; ok {
+		return previous, true
+	}
+	previous, ok := d.PreviousContents[display]
+	return previous, ok
+}
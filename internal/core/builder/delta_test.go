@@ -0,0 +1,169 @@
+package builder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/diogopedro/shotgun/internal/models"
+)
+
+func TestParsePromptFiles(t *testing.T) {
+	prompt := `├── a.go
+<file path="a.go">package a &amp;&amp; b</file>
+└── b.go
+<file path="b.go" status="unchanged">unchanged since previous context</file>
+<file path="c.go" status="changed" format="diff">--- a/c.go</file>
+`
+	contents := ParsePromptFiles(prompt)
+
+	if contents["a.go"] != "package a && b" {
+		t.Errorf("Expected unescaped content for a.go, got %q", contents["a.go"])
+	}
+	if _, ok := contents["b.go"]; ok {
+		t.Error("Unchanged markers should not be treated as content")
+	}
+	if _, ok := contents["c.go"]; ok {
+		t.Error("Diff blocks should not be treated as content")
+	}
+}
+
+func TestDeltaContext_Status(t *testing.T) {
+	delta := NewDeltaContext(&Manifest{Files: []ManifestFile{{Path: "a.go", SHA256: "abc"}}})
+
+	if got := delta.Status("a.go", "abc"); got != DeltaUnchanged {
+		t.Errorf("Expected unchanged, got %s", got)
+	}
+	if got := delta.Status("a.go", "def"); got != DeltaChanged {
+		t.Errorf("Expected changed, got %s", got)
+	}
+	if got := delta.Status("b.go", "abc"); got != DeltaNew {
+		t.Errorf("Expected new, got %s", got)
+	}
+}
+
+func TestGeneratePrompt_Delta(t *testing.T) {
+	tempDir := t.TempDir()
+	unchanged := filepath.Join(tempDir, "unchanged.go")
+	changed := filepath.Join(tempDir, "changed.go")
+	os.WriteFile(unchanged, []byte("package same\n"), 0644)
+	os.WriteFile(changed, []byte("package before\n\nfunc A() {}\n"), 0644)
+
+	tmpl := &models.Template{ID: "delta", Version: "1.0.0", Content: "{{FILE_STRUCTURE}}"}
+	generator := NewPromptGenerator()
+
+	first, err := generator.GeneratePrompt(context.Background(), GenerationConfig{
+		Template:      tmpl,
+		SelectedFiles: []string{unchanged, changed},
+		EmitManifest:  true,
+		Timestamp:     time.Unix(0, 0),
+	})
+	if err != nil {
+		t.Fatalf("GeneratePrompt failed: %v", err)
+	}
+
+	promptPath := filepath.Join(tempDir, "prompt.md")
+	os.WriteFile(promptPath, []byte(first.Content), 0644)
+	manifestPath, err := WriteManifest(first.Manifest, promptPath)
+	if err != nil {
+		t.Fatalf("WriteManifest failed: %v", err)
+	}
+
+	os.WriteFile(changed, []byte("package before\n\nfunc B() {}\n"), 0644)
+	added := filepath.Join(tempDir, "added.go")
+	os.WriteFile(added, []byte("package added\n"), 0644)
+
+	t.Run("full bodies", func(t *testing.T) {
+		delta, err := LoadDeltaContext(manifestPath, false)
+		if err != nil {
+			t.Fatalf("LoadDeltaContext failed: %v", err)
+		}
+
+		result, err := generator.GeneratePrompt(context.Background(), GenerationConfig{
+			Template:      tmpl,
+			SelectedFiles: []string{unchanged, changed, added},
+			Delta:         delta,
+		})
+		if err != nil {
+			t.Fatalf("GeneratePrompt failed: %v", err)
+		}
+
+		if strings.Contains(result.Content, "package same") {
+			t.Error("Unchanged file content should be omitted")
+		}
+		if !strings.Contains(result.Content, `status="unchanged">unchanged since previous context`) {
+			t.Errorf("Expected unchanged marker, got:\n%s", result.Content)
+		}
+		if !strings.Contains(result.Content, `status="changed">package before`) {
+			t.Errorf("Expected full changed content, got:\n%s", result.Content)
+		}
+		if !strings.Contains(result.Content, `status="new">package added`) {
+			t.Errorf("Expected new file content, got:\n%s", result.Content)
+		}
+	})
+
+	t.Run("diffs", func(t *testing.T) {
+		delta, err := LoadDeltaContext(manifestPath, true)
+		if err != nil {
+			t.Fatalf("LoadDeltaContext failed: %v", err)
+		}
+
+		result, err := generator.GeneratePrompt(context.Background(), GenerationConfig{
+			Template:      tmpl,
+			SelectedFiles: []string{unchanged, changed},
+			Delta:         delta,
+		})
+		if err != nil {
+			t.Fatalf("GeneratePrompt failed: %v", err)
+		}
+
+		if !strings.Contains(result.Content, `format="diff"`) {
+			t.Errorf("Expected diff for changed file, got:\n%s", result.Content)
+		}
+		if !strings.Contains(result.Content, "-func A() {}\n+func B() {}") {
+			t.Errorf("Expected diff body, got:\n%s", result.Content)
+		}
+	})
+	t.Run("render modes and regeneration", func(t *testing.T) {
+		delta, err := LoadDeltaContext(manifestPath, true)
+		if err != nil {
+			t.Fatalf("LoadDeltaContext failed: %v", err)
+		}
+
+		config := GenerationConfig{
+			Template:      tmpl,
+			SelectedFiles: []string{unchanged, changed, added},
+			RenderModes:   map[string]models.RenderMode{added: models.RenderOutline},
+			Delta:         delta,
+			EmitManifest:  true,
+			Timestamp:     time.Unix(0, 0),
+		}
+		result, err := generator.GeneratePrompt(context.Background(), config)
+		if err != nil {
+			t.Fatalf("GeneratePrompt failed: %v", err)
+		}
+		if !strings.Contains(result.Content, `status="new" mode="outline">package added`) {
+			t.Errorf("Expected new files to keep their render mode, got:\n%s", result.Content)
+		}
+
+		// The manifest records the delta base, so regeneration rebuilds the same delta prompt
+		recorded := result.Manifest.Delta
+		if recorded == nil || len(recorded.Hashes) != 2 || len(recorded.PreviousContents) != 1 || !recorded.ShowDiffs {
+			t.Fatalf("Expected the delta base of the two previous files, got %+v", recorded)
+		}
+		regenConfig, err := result.Manifest.RegenerationConfig(tmpl)
+		if err != nil {
+			t.Fatalf("RegenerationConfig failed: %v", err)
+		}
+		regenerated, err := generator.GeneratePrompt(context.Background(), regenConfig)
+		if err != nil {
+			t.Fatalf("GeneratePrompt failed: %v", err)
+		}
+		if regenerated.Content != result.Content {
+			t.Errorf("Expected byte-identical regeneration, got:\n%s\nwant:\n%s", regenerated.Content, result.Content)
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package builder
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff_Equal(t *testing.T) {
+	if diff := UnifiedDiff("a\nb\n", "a\nb\n", "f.go"); diff != "" {
+		t.Errorf("Expected empty diff for equal input, got %q", diff)
+	}
+}
+
+func TestUnifiedDiff_SingleChange(t *testing.T) {
+	before := "one\ntwo\nthree\nfour\nfive\n"
+	after := "one\ntwo\nTHREE\nfour\nfive\n"
+
+	diff := UnifiedDiff(before, after, "f.txt")
+
+	expected := "--- a/f.txt\n+++ b/f.txt\n@@ -1,5 +1,5 @@\n one\n two\n-three\n+THREE\n four\n five\n"
+	if diff != expected {
+		t.Errorf("Unexpected diff:\n%s\nwant:\n%s", diff, expected)
+	}
+}
+
+func TestUnifiedDiff_SeparateHunks(t *testing.T) {
+	var beforeLines, afterLines []string
+	for i := 0; i < 30; i++ {
+		line := strings.Repeat("x", i%5+1)
+		beforeLines = append(beforeLines, line)
+		afterLines = append(afterLines, line)
+	}
+	afterLines[2] = "changed-early"
+	afterLines[25] = "changed-late"
+
+	diff := UnifiedDiff(strings.Join(beforeLines, "\n"), strings.Join(afterLines, "\n"), "f")
+
+	if got := strings.Count(diff, "@@ -"); got != 2 {
+		t.Errorf("Expected 2 hunks, got %d:\n%s", got, diff)
+	}
+	if !strings.Contains(diff, "@@ -1,6 +1,6 @@") {
+		t.Errorf("Expected first hunk header at line 1, got:\n%s", diff)
+	}
+	if !strings.Contains(diff, "@@ -23,7 +23,7 @@") {
+		t.Errorf("Expected second hunk header at line 23, got:\n%s", diff)
+	}
+}
+
+func TestUnifiedDiff_InsertAndDelete(t *testing.T) {
+	diff := UnifiedDiff("a\nb\nc\n", "a\nc\nd\n", "f")
+
+	if !strings.Contains(diff, "-b\n") || !strings.Contains(diff, "+d\n") {
+		t.Errorf("Expected deletion of b and insertion of d, got:\n%s", diff)
+	}
+
+	fromEmpty := UnifiedDiff("", "new\n", "f")
+	if !strings.Contains(fromEmpty, "@@ -1,0 +1,1 @@\n+new\n") {
+		t.Errorf("Unexpected diff from empty file:\n%s", fromEmpty)
+	}
+}
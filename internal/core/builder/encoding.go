@@ -0,0 +1,25 @@
+package builder
+
+import "github.com/diogopedro/shotgun/internal/core/scanner"
+
+// WithNormalizeNewlines converts CRLF and lone CR line endings to LF in file contents
+func WithNormalizeNewlines(enabled bool) Option {
+	return func(b *FileStructureBuilder) {
+		b.normalizeNewlines = enabled
+	}
+}
+
+// decode converts raw file content to UTF-8 text as configured for this builder
+func (b *FileStructureBuilder) decode(content []byte) string {
+	return decodeText(content, b.normalizeNewlines)
+}
+
+// decodeText transcodes UTF-16, BOM-prefixed and Latin-1 content to UTF-8 and
+// optionally normalizes line endings
+func decodeText(content []byte, normalize bool) string {
+	text, _ := scanner.DecodeText(content)
+	if normalize {
+		text = scanner.NormalizeNewlines(text)
+	}
+	return text
+}
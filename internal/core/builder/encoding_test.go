@@ -0,0 +1,67 @@
+package builder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/diogopedro/shotgun/internal/models"
+)
+
+func TestGeneratePrompt_DecodesAndNormalizesContent(t *testing.T) {
+	root := t.TempDir()
+	script := filepath.Join(root, "setup.ps1")
+	legacy := filepath.Join(root, "legacy.c")
+
+	// UTF-16LE with a byte order mark and CRLF line endings, as Windows tools write it
+	var utf16 []byte
+	for _, c := range []byte("Write-Host done\r\nexit 0\r\n") {
+		utf16 = append(utf16, c, 0)
+	}
+	os.WriteFile(script, append([]byte{0xFF, 0xFE}, utf16...), 0644)
+	os.WriteFile(legacy, []byte("/* d\xe9j\xe0 vu */\r\n"), 0644)
+	files := []string{legacy, script}
+
+	template := &models.Template{ID: "encoding", Content: "{{FILE_STRUCTURE}}"}
+	config := GenerationConfig{
+		Template:          template,
+		SelectedFiles:     files,
+		NormalizeNewlines: true,
+		EmitManifest:      true,
+	}
+	result, err := NewPromptGenerator().GeneratePrompt(context.Background(), config)
+	if err != nil {
+		t.Fatalf("GeneratePrompt failed: %v", err)
+	}
+
+	for _, want := range []string{
+		"<file path=\"setup.ps1\">Write-Host done\nexit 0\n</file>",
+		"<file path=\"legacy.c\">/* déjà vu */\n</file>",
+	} {
+		if !strings.Contains(result.Content, want) {
+			t.Errorf("expected %q in:\n%s", want, result.Content)
+		}
+	}
+
+	regenerated, err := result.Manifest.RegenerationConfig(template)
+	if err != nil {
+		t.Fatalf("RegenerationConfig failed: %v", err)
+	}
+	if !regenerated.NormalizeNewlines {
+		t.Error("manifest should restore newline normalization")
+	}
+
+	estimate, err := NewSizeEstimator(&mockTemplateProcessor{}).EstimatePromptSize(context.Background(), EstimationConfig{
+		Template:          template,
+		SelectedFiles:     files,
+		NormalizeNewlines: true,
+	})
+	if err != nil {
+		t.Fatalf("EstimatePromptSize failed: %v", err)
+	}
+	if want := int64(len("Write-Host done\nexit 0\n") + len("/* déjà vu */\n")); estimate.FileContentSize != want {
+		t.Errorf("FileContentSize = %d, want %d", estimate.FileContentSize, want)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/diogopedro/shotgun/internal/models"
@@ -17,10 +18,19 @@ type SizeEstimator struct {
 
 // EstimationConfig holds configuration for size estimation
 type EstimationConfig struct {
-	Template      *models.Template
-	Variables     map[string]string
-	SelectedFiles []string
-	IncludeTree   bool
+	Template          *models.Template
+	Variables         map[string]string
+	SelectedFiles     []string
+	IncludeTree       bool
+	Compaction        Compaction
+	RenderModes       map[string]models.RenderMode
+	Slices            map[string][]Slice
+	Paths             PathOptions  // Display paths and anonymization, as used for generation
+	Annotations       Annotations  // Line numbers and file header attributes
+	Transformers      Transformers // Per-file-type content transformers, as used for generation
+	MaxFileSize       int64        // Size above which files are truncated or replaced; zero uses DefaultMaxFileSize
+	Truncation        Truncation   // How oversized files are cut down, with keywords already resolved
+	NormalizeNewlines bool         // Convert CRLF and lone CR line endings to LF
 }
 
 // SizeEstimate contains detailed size breakdown
@@ -31,6 +41,27 @@ type SizeEstimate struct {
 	TreeStructSize  int64
 	OverheadSize    int64
 	WarningLevel    int
+	SavedSize       int64         // Bytes removed by compaction across all files
+	FileSavings     []FileSavings // Per-file compaction savings, largest first
+	TruncatedFiles  int           // Oversized files cut down by truncation
+	TruncatedSize   int64         // Bytes omitted from truncated files
+}
+
+// FileSavings records how much compaction shrank a single file
+type FileSavings struct {
+	Path         string
+	OriginalSize int64
+	CompactSize  int64
+}
+
+// SavedBytes returns the bytes removed from the file
+func (f FileSavings) SavedBytes() int64 {
+	return f.OriginalSize - f.CompactSize
+}
+
+// SavedTokens returns the estimated tokens removed from the file
+func (f FileSavings) SavedTokens() int64 {
+	return EstimateTokens(f.OriginalSize) - EstimateTokens(f.CompactSize)
 }
 
 // ProgressCallback is called during progressive calculation
@@ -48,7 +79,8 @@ func NewSizeEstimator(templateEngine TemplateProcessor) *SizeEstimator {
 	}
 }
 
-// EstimatePromptSize calculates the estimated total size of the prompt output
+// EstimatePromptSize calculates the estimated total size of the prompt output. Each file is
+// read once, through the same pipeline as generation, so file contents are measured as rendered.
 func (e *SizeEstimator) EstimatePromptSize(ctx context.Context, config EstimationConfig) (*SizeEstimate, error) {
 	estimate := &SizeEstimate{}
 
@@ -59,16 +91,15 @@ func (e *SizeEstimator) EstimatePromptSize(ctx context.Context, config Estimatio
 	}
 	estimate.TemplateSize = templateSize
 
-	// Calculate file content size
-	fileContentSize, treeStructSize, err := e.calculateFileStructureSize(ctx, config.SelectedFiles)
+	// Render each file's content as generation would and measure it
+	display := config.Paths.DisplayPaths(config.SelectedFiles)
+	contentFiles, attrOverhead, err := e.calculateFileContentSize(ctx, config, display, estimate)
 	if err != nil {
-		return nil, fmt.Errorf("failed to calculate file structure size: %w", err)
+		return nil, fmt.Errorf("failed to calculate file content size: %w", err)
 	}
-	estimate.FileContentSize = fileContentSize
-	estimate.TreeStructSize = treeStructSize
 
 	// Calculate XML and formatting overhead
-	estimate.OverheadSize = e.calculateFormattingOverhead(config.SelectedFiles, fileContentSize)
+	estimate.OverheadSize = e.calculateFormattingOverhead(contentFiles, display) + attrOverhead
 
 	// Calculate total size
 	estimate.TotalSize = estimate.TemplateSize + estimate.FileContentSize +
@@ -80,6 +111,88 @@ func (e *SizeEstimator) EstimatePromptSize(ctx context.Context, config Estimatio
 	return estimate, nil
 }
 
+// structureOptions configures a structure builder to render files as generation does
+func (config EstimationConfig) structureOptions() []Option {
+	var options []Option
+	if config.Compaction.Enabled() {
+		options = append(options, WithCompaction(config.Compaction))
+	}
+	if len(config.RenderModes) > 0 {
+		options = append(options, WithRenderModes(config.RenderModes))
+	}
+	if len(config.Slices) > 0 {
+		options = append(options, WithSlices(config.Slices))
+	}
+	if !config.Paths.IsZero() {
+		options = append(options, WithPaths(config.Paths))
+	}
+	if config.Annotations.Enabled() {
+		options = append(options, WithAnnotations(config.Annotations))
+	}
+	if len(config.Transformers) > 0 {
+		options = append(options, WithTransformers(config.Transformers))
+	}
+	if config.MaxFileSize > 0 {
+		options = append(options, WithMaxFileSize(config.MaxFileSize))
+	}
+	if config.Truncation.Enabled() {
+		options = append(options, WithTruncation(config.Truncation))
+	}
+	if config.NormalizeNewlines {
+		options = append(options, WithNormalizeNewlines(true))
+	}
+	return options
+}
+
+// calculateFileContentSize renders every selected file and adds its content, tree line,
+// compaction savings and truncation to the estimate. It returns the files that get a
+// content block and the bytes taken by their attributes.
+func (e *SizeEstimator) calculateFileContentSize(ctx context.Context, config EstimationConfig, display map[string]string, estimate *SizeEstimate) ([]string, int64, error) {
+	var contentFiles []string
+	var attrOverhead int64
+
+	structureBuilder := NewFileStructureBuilder(config.structureOptions()...)
+	contents := structureBuilder.streamFileContents(ctx, config.SelectedFiles)
+	for _, filePath := range config.SelectedFiles {
+		file, err := contents.next(ctx)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		estimate.TreeStructSize += e.calculateTreeStructureOverhead(displayPath(display, filePath))
+		if file.mode == models.RenderPathOnly {
+			continue
+		}
+		contentFiles = append(contentFiles, filePath)
+		if file.err != nil {
+			continue // Rendered as a short error
+		}
+
+		rendered := int64(len(file.content))
+		estimate.FileContentSize += rendered
+		attrOverhead += int64(len(file.attributes()))
+
+		if file.compacted > 0 {
+			estimate.FileSavings = append(estimate.FileSavings, FileSavings{
+				Path:         filePath,
+				OriginalSize: rendered + file.compacted,
+				CompactSize:  rendered,
+			})
+			estimate.SavedSize += file.compacted
+		}
+		if file.truncated {
+			estimate.TruncatedFiles++
+			estimate.TruncatedSize += file.size - rendered
+		}
+	}
+
+	sort.SliceStable(estimate.FileSavings, func(i, j int) bool {
+		return estimate.FileSavings[i].SavedBytes() > estimate.FileSavings[j].SavedBytes()
+	})
+
+	return contentFiles, attrOverhead, nil
+}
+
 // CalculateProgressively calculates size with progress callbacks
 func (e *SizeEstimator) CalculateProgressively(ctx context.Context, files []string, callback ProgressCallback) (int64, error) {
 	var totalSize int64
@@ -142,33 +255,12 @@ func (e *SizeEstimator) calculateTemplateSize(template *models.Template, variabl
 	return int64(len(processed)), nil
 }
 
-// calculateFileStructureSize calculates total size of files and tree structure
-func (e *SizeEstimator) calculateFileStructureSize(ctx context.Context, selectedFiles []string) (int64, int64, error) {
-	var fileContentSize int64
-	var treeStructSize int64
-
-	for _, filePath := range selectedFiles {
-		select {
-		case <-ctx.Done():
-			return 0, 0, ctx.Err()
-		default:
-		}
-
-		// Get file size
-		fileInfo, err := os.Stat(filePath)
-		if err != nil {
-			continue // Skip inaccessible files
-		}
-
-		if !fileInfo.IsDir() {
-			fileContentSize += fileInfo.Size()
-
-			// Calculate tree structure overhead for this file
-			treeStructSize += e.calculateTreeStructureOverhead(filePath)
-		}
+// displayPath returns the prompt path of a file, or the file itself when it has none
+func displayPath(display map[string]string, filePath string) string {
+	if path, ok := display[filePath]; ok {
+		return path
 	}
-
-	return fileContentSize, treeStructSize, nil
+	return filePath
 }
 
 // calculateTreeStructureOverhead estimates ASCII tree character overhead
@@ -186,14 +278,15 @@ func (e *SizeEstimator) calculateTreeStructureOverhead(filePath string) int64 {
 	return treeChars + pathDisplay
 }
 
-// calculateFormattingOverhead estimates XML tags and escaping overhead
-func (e *SizeEstimator) calculateFormattingOverhead(selectedFiles []string, contentSize int64) int64 {
+// calculateFormattingOverhead estimates XML tag and formatting overhead. Contents are
+// measured after XML escaping, so escaping adds nothing here.
+func (e *SizeEstimator) calculateFormattingOverhead(selectedFiles []string, display map[string]string) int64 {
 	var overhead int64
 
 	// XML tag overhead per file: <file path="...">content</file>
 	for _, filePath := range selectedFiles {
 		// Opening tag: <file path="filepath">
-		openTag := int64(len(`<file path="`) + len(filePath) + len(`">`))
+		openTag := int64(len(`<file path="`) + len(displayPath(display, filePath)) + len(`">`))
 
 		// Closing tag: </file>
 		closeTag := int64(len(`</file>`))
@@ -201,13 +294,10 @@ func (e *SizeEstimator) calculateFormattingOverhead(selectedFiles []string, cont
 		overhead += openTag + closeTag
 	}
 
-	// XML escaping expansion factor (estimated 5% increase)
-	escapingOverhead := contentSize / 20
-
 	// Additional markdown formatting (headers, code blocks)
 	markdownOverhead := int64(len(selectedFiles)) * 50 // Estimated per file
 
-	return overhead + escapingOverhead + markdownOverhead
+	return overhead + markdownOverhead
 }
 
 // determineWarningLevel returns warning level based on size
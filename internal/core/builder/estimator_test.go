@@ -5,6 +5,7 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
 
@@ -126,7 +127,7 @@ func TestCalculateTemplateSizeFallback(t *testing.T) {
 	}
 }
 
-func TestCalculateFileStructureSize(t *testing.T) {
+func TestCalculateFileContentSize(t *testing.T) {
 	// Create temporary test files
 	tempDir := t.TempDir()
 
@@ -148,12 +149,18 @@ func TestCalculateFileStructureSize(t *testing.T) {
 	selectedFiles := []string{file1, file2}
 
 	ctx := context.Background()
-	fileContentSize, treeStructSize, err := estimator.calculateFileStructureSize(ctx, selectedFiles)
+	estimate := &SizeEstimate{}
+	contentFiles, _, err := estimator.calculateFileContentSize(ctx, EstimationConfig{SelectedFiles: selectedFiles}, nil, estimate)
+	fileContentSize, treeStructSize := estimate.FileContentSize, estimate.TreeStructSize
 
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
 
+	if len(contentFiles) != len(selectedFiles) {
+		t.Errorf("Expected %d files with content, got %d", len(selectedFiles), len(contentFiles))
+	}
+
 	expectedContentSize := int64(len(content1) + len(content2))
 	if fileContentSize != expectedContentSize {
 		t.Errorf("Expected file content size %d, got %d", expectedContentSize, fileContentSize)
@@ -307,6 +314,65 @@ func TestEstimatePromptSize(t *testing.T) {
 	}
 }
 
+func TestEstimatePromptSize_MatchesGeneration(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"main.go":    "// Package main runs.\npackage main\n\n\n\nfunc main() { println(\"a < b\") }\n",
+		"server.log": strings.Repeat("connection reset by peer\n", 100),
+		"notes.txt":  "first\r\nsecond\r\n",
+	}
+	var selected []string
+	for name, content := range files {
+		path := filepath.Join(tempDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		selected = append(selected, path)
+	}
+
+	compaction, _ := ParseCompaction([]string{"all"})
+	annotations, _ := ParseAnnotations([]string{"line-numbers", "header"})
+	truncation, _ := ParseTruncation("head-tail=5,5")
+	template := &models.Template{ID: "sizes", Content: "{{FILE_STRUCTURE}}"}
+	result, err := NewPromptGenerator().GeneratePrompt(context.Background(), GenerationConfig{
+		Template:          template,
+		SelectedFiles:     selected,
+		Compaction:        compaction,
+		Annotations:       annotations,
+		MaxFileSize:       1024,
+		Truncation:        truncation,
+		NormalizeNewlines: true,
+	})
+	if err != nil {
+		t.Fatalf("GeneratePrompt failed: %v", err)
+	}
+
+	estimate, err := NewSizeEstimator(nil).EstimatePromptSize(context.Background(), EstimationConfig{
+		Template:          template,
+		SelectedFiles:     selected,
+		Compaction:        compaction,
+		Annotations:       annotations,
+		MaxFileSize:       1024,
+		Truncation:        truncation,
+		NormalizeNewlines: true,
+	})
+	if err != nil {
+		t.Fatalf("EstimatePromptSize failed: %v", err)
+	}
+
+	// File contents are measured exactly as rendered
+	var rendered int
+	for _, block := range regexp.MustCompile(`(?s)<file path="[^"]*"[^>]*>(.*?)</file>`).FindAllStringSubmatch(result.Content, -1) {
+		rendered += len(block[1])
+	}
+	if estimate.FileContentSize != int64(rendered) {
+		t.Errorf("FileContentSize = %d, want %d", estimate.FileContentSize, rendered)
+	}
+	if estimate.TruncatedFiles != 1 || len(estimate.FileSavings) == 0 {
+		t.Errorf("expected a truncated file and compaction savings, got %d and %v", estimate.TruncatedFiles, estimate.FileSavings)
+	}
+}
+
 func TestDetermineWarningLevel(t *testing.T) {
 	estimator := NewSizeEstimator(nil)
 
@@ -338,15 +404,14 @@ func TestCalculateFormattingOverhead(t *testing.T) {
 		"path/to/file1.go",
 		"path/to/file2.go",
 	}
-	contentSize := int64(1000)
 
-	overhead := estimator.calculateFormattingOverhead(selectedFiles, contentSize)
+	overhead := estimator.calculateFormattingOverhead(selectedFiles, nil)
 
 	if overhead <= 0 {
 		t.Error("Expected positive formatting overhead")
 	}
 
-	// Should include XML tags, escaping, and markdown formatting
+	// Should include XML tags and markdown formatting
 	expectedMinimum := int64(len(selectedFiles)) * 20 // Conservative estimate
 	if overhead < expectedMinimum {
 		t.Errorf("Expected overhead at least %d, got %d", expectedMinimum, overhead)
@@ -2,22 +2,44 @@ package builder
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"sort"
 	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/diogopedro/shotgun/internal/core/scanner"
 	"github.com/diogopedro/shotgun/internal/models"
 )
 
 // GenerationConfig contains all the configuration needed for prompt generation
 type GenerationConfig struct {
-	Template      *models.Template
-	Variables     map[string]string
-	SelectedFiles []string
-	TaskContent   string
-	RulesContent  string
-	OutputPath    string
+	Template          *models.Template
+	Variables         map[string]string
+	SelectedFiles     []string
+	TaskContent       string
+	RulesContent      string
+	OutputPath        string
+	EmitManifest      bool                          // Record a reproducibility manifest alongside the prompt
+	Timestamp         time.Time                     // Fixed generation time; zero uses SOURCE_DATE_EPOCH or now
+	Delta             *DeltaContext                 // Only send full content for files new or changed since a previous prompt
+	StreamToFile      bool                          // Write directly to a file under OutputPath instead of returning Content
+	Chunking          *ChunkConfig                  // Split the prompt into numbered parts on file boundaries
+	Compaction        Compaction                    // Content transforms applied to every file
+	RenderModes       map[string]models.RenderMode  // Per-file full/outline/path-only rendering; missing means full
+	Slices            map[string][]Slice            // Per-file line ranges or symbols; only these parts are rendered
+	TreeFormat        *TreeFormat                   // Structure rendering options; nil keeps the builder's format
+	Paths             PathOptions                   // Display paths (relative to the project root by default) and anonymization
+	Annotations       Annotations                   // Line numbers and per-file header attributes
+	Transformers      Transformers                  // Replace notebooks, data files, lockfiles and minified files; nil keeps full content
+	MaxFileSize       int64                         // Size above which files are truncated or replaced; zero uses DefaultMaxFileSize
+	Truncation        Truncation                    // How oversized files are cut down; zero embeds a placeholder
+	NormalizeNewlines bool                          // Convert CRLF and lone CR line endings to LF
+	BinaryDetection   *scanner.BinaryDetectorConfig // Extension lists and thresholds for binary detection; nil uses the shared detector
+	Source            *scanner.Source               // Archive or git revision the selected files are read from; nil reads the disk
 }
 
 // GeneratedPrompt contains the result of prompt generation with metadata
@@ -27,6 +49,19 @@ type GeneratedPrompt struct {
 	FileCount    int
 	TotalSize    int64
 	GeneratedAt  time.Time
+	Manifest     *Manifest
+	OutputFile   string   // Set when the prompt was streamed straight to disk (first part when split)
+	Parts        []string // Framed part contents when the prompt was split
+	PartFiles    []string // Part file paths when split parts were written to disk
+}
+
+// ManifestTarget returns the prompt path the manifest is named after.
+// Split prompts share one manifest named after the unsplit prompt path.
+func (r *GeneratedPrompt) ManifestTarget() string {
+	if len(r.PartFiles) > 0 {
+		return partBasePath(r.PartFiles[0])
+	}
+	return r.OutputFile
 }
 
 // GenerationProgressCallback is called during async generation to report progress
@@ -79,11 +114,97 @@ func NewPromptGenerator() *PromptGenerator {
 
 // GeneratePrompt combines template, variables, and file structure into final prompt
 func (pg *PromptGenerator) GeneratePrompt(ctx context.Context, config GenerationConfig) (*GeneratedPrompt, error) {
+	if config.Chunking != nil && config.Chunking.Limit() > 0 {
+		return pg.generateParts(ctx, config)
+	}
+
+	var content strings.Builder
+	result, err := pg.GeneratePromptTo(ctx, &content, config)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Content = content.String()
+	return result, nil
+}
+
+// generateParts generates the prompt and splits it on file boundaries under the chunk limit.
+// The first part carries the template preamble; every part is framed with its position.
+func (pg *PromptGenerator) generateParts(ctx context.Context, config GenerationConfig) (*GeneratedPrompt, error) {
+	collector := &chunkCollector{}
+	result, err := pg.GeneratePromptTo(ctx, collector, config)
+	if err != nil {
+		return nil, err
+	}
+
+	units := collector.finish()
+	result.Content = joinUnits(units)
+
+	parts := splitIntoParts(units, config.Chunking.Limit())
+	if len(parts) > 1 {
+		result.Parts = parts
+	}
+
+	if result.Manifest != nil {
+		result.Manifest.recordParts(*config.Chunking, result.Parts)
+	}
+
+	return result, nil
+}
+
+// GenerateToFile streams the prompt straight into a new file under config.OutputPath
+// (or the current directory), so the full prompt is never held in memory.
+// Chunked prompts are buffered to count their parts, then written as numbered part files.
+func (pg *PromptGenerator) GenerateToFile(ctx context.Context, config GenerationConfig, writer *FileWriter) (*GeneratedPrompt, error) {
+	if config.Chunking != nil && config.Chunking.Limit() > 0 {
+		result, err := pg.generateParts(ctx, config)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(result.Parts) > 0 {
+			result.PartFiles, err = writer.WritePromptParts(result.Parts, config.OutputPath)
+			if err != nil {
+				return nil, err
+			}
+			result.OutputFile = result.PartFiles[0]
+		} else {
+			result.OutputFile, err = writer.WritePromptFile(result.Content, config.OutputPath)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		result.Content = ""
+		result.Parts = nil
+		return result, nil
+	}
+
+	var result *GeneratedPrompt
+	outputFile, err := writer.WritePromptStream(config.OutputPath, func(w io.Writer) error {
+		var err error
+		result, err = pg.GeneratePromptTo(ctx, w, config)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.OutputFile = outputFile
+	return result, nil
+}
+
+// GeneratePromptTo streams the final prompt to w. The template is split around
+// {{FILE_STRUCTURE}} so the file structure is written in place rather than substituted as a string.
+func (pg *PromptGenerator) GeneratePromptTo(ctx context.Context, w io.Writer, config GenerationConfig) (*GeneratedPrompt, error) {
 	if config.Template == nil {
 		return nil, fmt.Errorf("template is required for generation")
 	}
 
-	startTime := time.Now()
+	startTime := config.Timestamp
+	if startTime.IsZero() {
+		startTime = GenerationTime()
+	}
 
 	// Check context cancellation
 	select {
@@ -105,67 +226,178 @@ func (pg *PromptGenerator) GeneratePrompt(ctx context.Context, config Generation
 	variables["RULES"] = config.RulesContent
 
 	// Add automatic variables
-	variables["CURRENT_DATE"] = time.Now().Format("2006-01-02")
+	variables["CURRENT_DATE"] = startTime.Format("2006-01-02")
 	variables["SELECTED_FILES_COUNT"] = fmt.Sprintf("%d", len(config.SelectedFiles))
-
-	// Check context cancellation
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
+	if _, ok := variables["PROJECT_NAME"]; !ok {
+		variables["PROJECT_NAME"] = config.Paths.ProjectName()
 	}
 
-	// Step 2: Generate file structure if files are selected
-	var fileStructure string
-	if len(config.SelectedFiles) > 0 {
-		structure, err := pg.fileStructureBuilder.GenerateStructure(ctx, config.SelectedFiles)
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate file structure: %w", err)
+	// FILE_STRUCTURE is streamed separately
+	delete(variables, "FILE_STRUCTURE")
+
+	// Step 2: Configure the structure builder for this run
+	var runOptions []Option
+	if config.Delta != nil {
+		runOptions = append(runOptions, WithDelta(config.Delta))
+	}
+	if config.Compaction.Enabled() {
+		runOptions = append(runOptions, WithCompaction(config.Compaction))
+	}
+	if len(config.RenderModes) > 0 {
+		runOptions = append(runOptions, WithRenderModes(config.RenderModes))
+	}
+	if len(config.Slices) > 0 {
+		// Fail before streaming rather than embedding a broken slice
+		if err := validateSlices(config.Source, config.Slices); err != nil {
+			return nil, err
 		}
-		fileStructure = structure
+		runOptions = append(runOptions, WithSlices(config.Slices))
 	}
-
-	variables["FILE_STRUCTURE"] = fileStructure
-
-	// Check context cancellation
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
+	if config.TreeFormat != nil {
+		runOptions = append(runOptions, WithTreeFormat(*config.TreeFormat))
 	}
-
-	// Step 3: Process template with simple variable substitution
-	processedTemplate := config.Template.Content
-	for key, value := range variables {
-		placeholder := "{{" + key + "}}"
-		processedTemplate = strings.ReplaceAll(processedTemplate, placeholder, value)
+	if !config.Paths.IsZero() {
+		runOptions = append(runOptions, WithPaths(config.Paths))
+	}
+	if config.Annotations.Enabled() {
+		runOptions = append(runOptions, WithAnnotations(config.Annotations))
+	}
+	if len(config.Transformers) > 0 {
+		runOptions = append(runOptions, WithTransformers(config.Transformers))
+	}
+	if config.MaxFileSize > 0 {
+		runOptions = append(runOptions, WithMaxFileSize(config.MaxFileSize))
+	}
+	truncation := config.Truncation.ForTask(config.TaskContent)
+	if truncation.Enabled() {
+		runOptions = append(runOptions, WithTruncation(truncation))
+	}
+	if config.NormalizeNewlines {
+		runOptions = append(runOptions, WithNormalizeNewlines(true))
+	}
+	if config.BinaryDetection != nil {
+		runOptions = append(runOptions, WithBinaryDetector(scanner.NewBinaryDetectorWithConfig(*config.BinaryDetection)))
+	}
+	if config.Source != nil {
+		runOptions = append(runOptions, WithSource(config.Source))
+	}
+	structureBuilder := pg.fileStructureBuilder
+	if len(runOptions) > 0 {
+		structureBuilder = structureBuilder.With(runOptions...)
 	}
 
-	// Check context cancellation
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
+	// Step 3: Stream template segments with simple variable substitution.
+	// A single-pass replacer keeps output deterministic even when values contain placeholders.
+	replacer := newVariableReplacer(variables)
+	hasher := sha256.New()
+	counter := &countingWriter{w: io.MultiWriter(w, hasher)}
+	var out io.Writer = counter
+	if fb, ok := w.(FileBoundaryWriter); ok {
+		out = &boundaryCountingWriter{countingWriter: counter, target: fb}
 	}
 
-	// Step 4: Use processed template as final content
-	finalContent := processedTemplate
+	segments := strings.Split(config.Template.Content, fileStructurePlaceholder)
+	for i, segment := range segments {
+		if i > 0 && len(config.SelectedFiles) > 0 {
+			if err := structureBuilder.WriteStructure(ctx, out, config.SelectedFiles); err != nil {
+				return nil, fmt.Errorf("failed to generate file structure: %w", err)
+			}
+		}
+
+		if _, err := io.WriteString(out, replacer.Replace(segment)); err != nil {
+			return nil, fmt.Errorf("failed to write prompt: %w", err)
+		}
 
-	// Step 5: Calculate metadata
-	templateSize := int64(len(config.Template.Content))
-	totalSize := int64(len(finalContent))
+		// Check context cancellation
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+	}
 
+	// Step 4: Calculate metadata
 	result := &GeneratedPrompt{
-		Content:      finalContent,
-		TemplateSize: templateSize,
+		TemplateSize: int64(len(config.Template.Content)),
 		FileCount:    len(config.SelectedFiles),
-		TotalSize:    totalSize,
+		TotalSize:    counter.n,
 		GeneratedAt:  startTime,
 	}
 
+	// Step 5: Record manifest for reproducibility if requested
+	if config.EmitManifest {
+		manifest, err := buildManifest(config.Source, config.Template, variables, config.SelectedFiles, hex.EncodeToString(hasher.Sum(nil)), startTime)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build manifest: %w", err)
+		}
+		manifest.Compaction = config.Compaction.Modes()
+		manifest.recordRenderModes(config.RenderModes)
+		manifest.recordSlices(config.Slices)
+		if config.TreeFormat != nil {
+			format := *config.TreeFormat
+			manifest.TreeFormat = &format
+		}
+		manifest.recordPaths(config.Paths)
+		manifest.recordDelta(config.Delta)
+		manifest.Annotations = config.Annotations.Specs()
+		manifest.Transformers = config.Transformers.Names()
+		manifest.MaxFileSize = config.MaxFileSize
+		if truncation.Enabled() {
+			manifest.Truncation = &truncation
+		}
+		manifest.NormalizeNewlines = config.NormalizeNewlines
+		if config.BinaryDetection != nil {
+			detection := *config.BinaryDetection
+			manifest.BinaryDetection = &detection
+		}
+		result.Manifest = manifest
+	}
+
 	return result, nil
 }
 
+// fileStructurePlaceholder marks where the streamed file structure is written
+const fileStructurePlaceholder = "{{FILE_STRUCTURE}}"
+
+// newVariableReplacer builds a deterministic single-pass replacer for {{KEY}} placeholders
+func newVariableReplacer(variables map[string]string) *strings.Replacer {
+	keys := make([]string, 0, len(variables))
+	for key := range variables {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	replacements := make([]string, 0, len(keys)*2)
+	for _, key := range keys {
+		replacements = append(replacements, "{{"+key+"}}", variables[key])
+	}
+	return strings.NewReplacer(replacements...)
+}
+
+// countingWriter counts bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+// Write implements io.Writer
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// boundaryCountingWriter forwards file boundaries through a countingWriter
+type boundaryCountingWriter struct {
+	*countingWriter
+	target FileBoundaryWriter
+}
+
+// FileBoundary implements FileBoundaryWriter
+func (b *boundaryCountingWriter) FileBoundary() error {
+	return b.target.FileBoundary()
+}
+
 // GenerateAsync performs prompt generation asynchronously with progress updates
 func (pg *PromptGenerator) GenerateAsync(config GenerationConfig, callback GenerationProgressCallback) tea.Cmd {
 	return func() tea.Msg {
@@ -193,8 +425,14 @@ func (pg *PromptGenerator) GenerateAsync(config GenerationConfig, callback Gener
 			callback(string(StageAssemblingStructure), 0.75)
 		}
 
-		// Generate the prompt synchronously
-		result, err := pg.GeneratePrompt(ctx, config)
+		// Generate the prompt synchronously, streaming to disk when requested
+		var result *GeneratedPrompt
+		var err error
+		if config.StreamToFile || config.Chunking != nil {
+			result, err = pg.GenerateToFile(ctx, config, NewFileWriter())
+		} else {
+			result, err = pg.GeneratePrompt(ctx, config)
+		}
 
 		// Step 4: Complete (100% progress)
 		if callback != nil {
@@ -2,10 +2,17 @@ package builder
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
+	"testing/fstest"
 	"time"
 
+	"github.com/diogopedro/shotgun/internal/core/scanner"
 	"github.com/diogopedro/shotgun/internal/models"
 )
 
@@ -178,3 +185,185 @@ func TestGenerateAsync(t *testing.T) {
 		t.Error("Async generation returned nil result")
 	}
 }
+
+func TestGeneratePromptTo_StreamsFileStructure(t *testing.T) {
+	tempDir := t.TempDir()
+	file := filepath.Join(tempDir, "main.go")
+	os.WriteFile(file, []byte("package main // {{TASK}}\n"), 0644)
+
+	generator := NewPromptGenerator()
+	config := GenerationConfig{
+		Template: &models.Template{
+			ID:      "stream",
+			Content: "Task: {{TASK}}\n{{FILE_STRUCTURE}}\nEnd {{SELECTED_FILES_COUNT}}",
+		},
+		SelectedFiles: []string{file},
+		TaskContent:   "stream it",
+	}
+
+	var out strings.Builder
+	result, err := generator.GeneratePromptTo(context.Background(), &out, config)
+	if err != nil {
+		t.Fatalf("GeneratePromptTo failed: %v", err)
+	}
+
+	content := out.String()
+	if !strings.HasPrefix(content, "Task: stream it\n") || !strings.HasSuffix(content, "\nEnd 1") {
+		t.Errorf("Unexpected prompt framing: %q", content)
+	}
+	// Placeholders inside file contents must not be substituted
+	if !strings.Contains(content, "package main // {{TASK}}") {
+		t.Errorf("File content should be copied verbatim, got: %s", content)
+	}
+	if result.TotalSize != int64(len(content)) {
+		t.Errorf("TotalSize %d does not match written bytes %d", result.TotalSize, len(content))
+	}
+
+	buffered, err := generator.GeneratePrompt(context.Background(), config)
+	if err != nil {
+		t.Fatalf("GeneratePrompt failed: %v", err)
+	}
+	if buffered.Content != content {
+		t.Error("GeneratePrompt and GeneratePromptTo should produce identical output")
+	}
+}
+
+func TestGenerateToFile(t *testing.T) {
+	tempDir := t.TempDir()
+	file := filepath.Join(tempDir, "a.txt")
+	os.WriteFile(file, []byte("hello"), 0644)
+
+	config := GenerationConfig{
+		Template:      &models.Template{ID: "file", Content: "{{FILE_STRUCTURE}}"},
+		SelectedFiles: []string{file},
+		OutputPath:    tempDir,
+		EmitManifest:  true,
+	}
+
+	result, err := NewPromptGenerator().GenerateToFile(context.Background(), config, NewFileWriter())
+	if err != nil {
+		t.Fatalf("GenerateToFile failed: %v", err)
+	}
+
+	if result.Content != "" {
+		t.Error("Streamed result should not hold content in memory")
+	}
+
+	data, err := os.ReadFile(result.OutputFile)
+	if err != nil {
+		t.Fatalf("Output file not written: %v", err)
+	}
+	if int64(len(data)) != result.TotalSize || !strings.Contains(string(data), "hello") {
+		t.Errorf("Unexpected output file content: %s", data)
+	}
+	if result.Manifest == nil || result.Manifest.OutputSHA256 != hashString(string(data)) {
+		t.Error("Manifest output hash should match the written file")
+	}
+}
+
+// BenchmarkGeneratePromptTo_50kFiles measures streaming generation over a synthetic
+// 50k-file tree. Run with -benchmem; peak-heap reports the high-water mark observed.
+func BenchmarkGeneratePromptTo_50kFiles(b *testing.B) {
+	const fileCount = 50000
+
+	tempDir := b.TempDir()
+	body := []byte(strings.Repeat("// synthetic source line\n", 40))
+	files := make([]string, 0, fileCount)
+	for i := 0; i < fileCount; i++ {
+		dir := filepath.Join(tempDir, fmt.Sprintf("d%03d", i%500), fmt.Sprintf("s%02d", i%20))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			b.Fatal(err)
+		}
+		file := filepath.Join(dir, fmt.Sprintf("f%05d.go", i))
+		if err := os.WriteFile(file, body, 0644); err != nil {
+			b.Fatal(err)
+		}
+		files = append(files, file)
+	}
+
+	generator := NewPromptGenerator()
+	config := GenerationConfig{
+		Template:      &models.Template{ID: "bench", Content: "{{TASK}}\n{{FILE_STRUCTURE}}"},
+		SelectedFiles: files,
+		TaskContent:   "benchmark",
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	var peak uint64
+	for i := 0; i < b.N; i++ {
+		done := make(chan struct{})
+		stopped := make(chan struct{})
+		go func() {
+			defer close(stopped)
+			var stats runtime.MemStats
+			for {
+				select {
+				case <-done:
+					return
+				case <-time.After(10 * time.Millisecond):
+					runtime.ReadMemStats(&stats)
+					if stats.HeapInuse > peak {
+						peak = stats.HeapInuse
+					}
+				}
+			}
+		}()
+
+		_, err := generator.GeneratePromptTo(context.Background(), io.Discard, config)
+		close(done)
+		<-stopped
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ReportMetric(float64(peak)/(1024*1024), "peak-heap-MB")
+}
+
+func TestGeneratePrompt_Source(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "release.tar.gz")
+	modTime := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	source := &scanner.Source{
+		FS: fstest.MapFS{
+			"src/main.go":     {Data: []byte("package main\n\nfunc main() {}\n"), ModTime: modTime},
+			"assets/blob.dat": {Data: []byte{0x00, 0x01, 0x02, 0xff}, ModTime: modTime},
+		},
+		Root: root,
+		Spec: scanner.SourceSpec{Archive: root},
+	}
+
+	config := GenerationConfig{
+		Template:      &models.Template{ID: "source", Content: "# {{PROJECT_NAME}}\n{{FILE_STRUCTURE}}"},
+		SelectedFiles: []string{filepath.Join(root, "src", "main.go"), filepath.Join(root, "assets", "blob.dat")},
+		Paths:         PathOptions{Root: root},
+		Annotations:   Annotations{Header: true},
+		EmitManifest:  true,
+		Source:        source,
+	}
+	result, err := NewPromptGenerator().GeneratePrompt(context.Background(), config)
+	if err != nil {
+		t.Fatalf("GeneratePrompt failed: %v", err)
+	}
+
+	for _, want := range []string{
+		"# release.tar.gz\n",
+		`<file path="src/main.go" language="go" lines="3" size="29">package main`,
+		`size="4">Binary file (4 bytes)</file>`,
+	} {
+		if !strings.Contains(result.Content, want) {
+			t.Errorf("expected %q in:\n%s", want, result.Content)
+		}
+	}
+
+	manifest := result.Manifest
+	if manifest.Source == nil || manifest.Source.Archive != root {
+		t.Fatalf("manifest should record the source, got %+v", manifest.Source)
+	}
+	for _, f := range manifest.Files {
+		if f.Size == 0 || f.SHA256 == "" {
+			t.Errorf("file %s should be hashed from the source", f.Path)
+		}
+	}
+}
@@ -0,0 +1,457 @@
+package builder
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/diogopedro/shotgun/internal/core/scanner"
+	"github.com/diogopedro/shotgun/internal/models"
+)
+
+// ToolVersion is the shotgun version recorded in manifests (set via -ldflags at build time)
+var ToolVersion = "dev"
+
+const (
+	// ManifestSchemaVersion identifies the manifest JSON layout
+	ManifestSchemaVersion = 1
+
+	// ManifestSuffix is appended to the prompt file path to name its manifest
+	ManifestSuffix = ".lock.json"
+)
+
+// Manifest records everything needed to audit or reproduce a generated prompt
+type Manifest struct {
+	SchemaVersion     int                           `json:"schema_version"`
+	ToolVersion       string                        `json:"tool_version"`
+	GeneratedAt       time.Time                     `json:"generated_at"`
+	Template          ManifestTemplate              `json:"template"`
+	Variables         map[string]string             `json:"variables"`
+	Files             []ManifestFile                `json:"files"`
+	OutputSHA256      string                        `json:"output_sha256"`
+	Compaction        []string                      `json:"compaction,omitempty"`
+	RenderModes       map[string]models.RenderMode  `json:"render_modes,omitempty"`
+	Slices            map[string][]string           `json:"slices,omitempty"`
+	Chunking          *ChunkConfig                  `json:"chunking,omitempty"`
+	TreeFormat        *TreeFormat                   `json:"tree_format,omitempty"`
+	Paths             *PathOptions                  `json:"paths,omitempty"`
+	Annotations       []string                      `json:"annotations,omitempty"`
+	Transformers      []string                      `json:"transformers,omitempty"`
+	MaxFileSize       int64                         `json:"max_file_size,omitempty"`
+	Truncation        *Truncation                   `json:"truncation,omitempty"`
+	NormalizeNewlines bool                          `json:"normalize_newlines,omitempty"`
+	BinaryDetection   *scanner.BinaryDetectorConfig `json:"binary_detection,omitempty"`
+	Source            *scanner.SourceSpec           `json:"source,omitempty"`
+	Delta             *DeltaContext                 `json:"delta,omitempty"` // Previous generation of a delta prompt, limited to its files
+	Parts             []ManifestPart                `json:"parts,omitempty"`
+}
+
+// ManifestPart records a single part of a split prompt
+type ManifestPart struct {
+	Part   int    `json:"part"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// ManifestTemplate identifies the template used for generation
+type ManifestTemplate struct {
+	ID            string `json:"id"`
+	Version       string `json:"version"`
+	ContentSHA256 string `json:"content_sha256"`
+}
+
+// ManifestFile records a single included file
+type ManifestFile struct {
+	Path        string `json:"path"`
+	DisplayPath string `json:"display_path,omitempty"` // Path as written in the prompt, when it differs
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"`
+}
+
+// DriftStatus describes how a file changed since the manifest was recorded
+type DriftStatus string
+
+const (
+	DriftUnchanged DriftStatus = "unchanged"
+	DriftModified  DriftStatus = "modified"
+	DriftMissing   DriftStatus = "missing"
+)
+
+// FileDrift reports the verification result for a single manifest file
+type FileDrift struct {
+	Path   string
+	Status DriftStatus
+	Size   int64
+	SHA256 string
+}
+
+// ManifestPath returns the manifest path for a prompt file
+func ManifestPath(promptPath string) string {
+	return promptPath + ManifestSuffix
+}
+
+// PromptPathFromManifest returns the prompt file path a manifest belongs to
+func PromptPathFromManifest(manifestPath string) string {
+	return strings.TrimSuffix(manifestPath, ManifestSuffix)
+}
+
+// GenerationTime returns the timestamp to use for generation, honouring SOURCE_DATE_EPOCH
+func GenerationTime() time.Time {
+	if epoch := os.Getenv("SOURCE_DATE_EPOCH"); epoch != "" {
+		if seconds, err := strconv.ParseInt(epoch, 10, 64); err == nil {
+			return time.Unix(seconds, 0).UTC()
+		}
+	}
+	return time.Now()
+}
+
+// BuildManifest creates a manifest for a generated prompt with the given output hash
+func BuildManifest(template *models.Template, variables map[string]string, files []string, outputSHA256 string, generatedAt time.Time) (*Manifest, error) {
+	return buildManifest(nil, template, variables, files, outputSHA256, generatedAt)
+}
+
+// buildManifest is BuildManifest for files of an archive or git revision, which it records
+func buildManifest(source *scanner.Source, template *models.Template, variables map[string]string, files []string, outputSHA256 string, generatedAt time.Time) (*Manifest, error) {
+	if template == nil {
+		return nil, fmt.Errorf("template is required to build a manifest")
+	}
+
+	// FILE_STRUCTURE is derived from the files themselves and is not recorded
+	recorded := make(map[string]string, len(variables))
+	for k, v := range variables {
+		if k == "FILE_STRUCTURE" {
+			continue
+		}
+		recorded[k] = v
+	}
+
+	manifest := &Manifest{
+		SchemaVersion: ManifestSchemaVersion,
+		ToolVersion:   ToolVersion,
+		GeneratedAt:   generatedAt,
+		Template: ManifestTemplate{
+			ID:            template.ID,
+			Version:       template.Version,
+			ContentSHA256: hashString(template.Content),
+		},
+		Variables:    recorded,
+		Files:        make([]ManifestFile, 0, len(files)),
+		OutputSHA256: outputSHA256,
+	}
+	if source != nil {
+		spec := source.Spec
+		manifest.Source = &spec
+	}
+
+	for _, path := range files {
+		size, sum, err := hashFile(source, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash %s: %w", path, err)
+		}
+		manifest.Files = append(manifest.Files, ManifestFile{Path: path, Size: size, SHA256: sum})
+	}
+
+	sort.Slice(manifest.Files, func(i, j int) bool {
+		return manifest.Files[i].Path < manifest.Files[j].Path
+	})
+
+	return manifest, nil
+}
+
+// FilePaths returns the recorded file paths in manifest order
+func (m *Manifest) FilePaths() []string {
+	paths := make([]string, 0, len(m.Files))
+	for _, f := range m.Files {
+		paths = append(paths, f.Path)
+	}
+	return paths
+}
+
+// RegenerationConfig rebuilds the generation config recorded by the manifest.
+// The template must match the recorded ID and content hash for output to be reproducible.
+// A reopened archive or git revision in config.Source must be closed by the caller.
+func (m *Manifest) RegenerationConfig(template *models.Template) (GenerationConfig, error) {
+	if template == nil {
+		return GenerationConfig{}, fmt.Errorf("template is required for regeneration")
+	}
+	if template.ID != m.Template.ID {
+		return GenerationConfig{}, fmt.Errorf("template ID mismatch: manifest has %q, got %q", m.Template.ID, template.ID)
+	}
+	if hashString(template.Content) != m.Template.ContentSHA256 {
+		return GenerationConfig{}, fmt.Errorf("template %q content changed since manifest was recorded (version %s, now %s)",
+			m.Template.ID, m.Template.Version, template.Version)
+	}
+
+	variables := make(map[string]string, len(m.Variables))
+	for k, v := range m.Variables {
+		variables[k] = v
+	}
+
+	config := GenerationConfig{
+		Template:      template,
+		Variables:     variables,
+		SelectedFiles: m.FilePaths(),
+		TaskContent:   variables["TASK"],
+		RulesContent:  variables["RULES"],
+		EmitManifest:  true,
+		Timestamp:     m.GeneratedAt,
+	}
+	compaction, err := ParseCompaction(m.Compaction)
+	if err != nil {
+		return GenerationConfig{}, err
+	}
+	config.Compaction = compaction
+
+	if len(m.RenderModes) > 0 {
+		config.RenderModes = make(map[string]models.RenderMode, len(m.RenderModes))
+		for path, mode := range m.RenderModes {
+			config.RenderModes[path] = mode
+		}
+	}
+
+	if len(m.Slices) > 0 {
+		config.Slices = make(map[string][]Slice, len(m.Slices))
+		for path, specs := range m.Slices {
+			slices, err := ParseSlices(specs)
+			if err != nil {
+				return GenerationConfig{}, fmt.Errorf("invalid slices for %s: %w", path, err)
+			}
+			config.Slices[path] = slices
+		}
+	}
+
+	if m.Chunking != nil {
+		chunking := *m.Chunking
+		config.Chunking = &chunking
+	}
+
+	if m.Delta != nil {
+		config.Delta = &DeltaContext{Hashes: m.Delta.Hashes, PreviousContents: m.Delta.PreviousContents, ShowDiffs: m.Delta.ShowDiffs}
+	}
+
+	if m.TreeFormat != nil {
+		format := *m.TreeFormat
+		config.TreeFormat = &format
+	}
+
+	if m.Paths != nil {
+		config.Paths = *m.Paths
+	}
+
+	annotations, err := ParseAnnotations(m.Annotations)
+	if err != nil {
+		return GenerationConfig{}, err
+	}
+	config.Annotations = annotations
+
+	transformers, err := ParseTransformers(m.Transformers)
+	if err != nil {
+		return GenerationConfig{}, err
+	}
+	config.Transformers = transformers
+
+	config.MaxFileSize = m.MaxFileSize
+	config.NormalizeNewlines = m.NormalizeNewlines
+	if m.Truncation != nil {
+		config.Truncation = *m.Truncation
+	}
+	if m.BinaryDetection != nil {
+		detection := *m.BinaryDetection
+		config.BinaryDetection = &detection
+	}
+	if m.Source != nil {
+		if config.Source, err = m.Source.Open(); err != nil {
+			return GenerationConfig{}, fmt.Errorf("failed to reopen %s: %w", m.Source, err)
+		}
+	}
+
+	return config, nil
+}
+
+// recordRenderModes records the non-default render modes of included files
+func (m *Manifest) recordRenderModes(modes map[string]models.RenderMode) {
+	for _, f := range m.Files {
+		mode, ok := modes[f.Path]
+		if !ok || mode == "" || mode == models.RenderFull {
+			continue
+		}
+		if m.RenderModes == nil {
+			m.RenderModes = make(map[string]models.RenderMode)
+		}
+		m.RenderModes[f.Path] = mode
+	}
+}
+
+// recordPaths records the path options and the prompt path of every included file
+func (m *Manifest) recordPaths(paths PathOptions) {
+	if !paths.IsZero() {
+		m.Paths = &paths
+	}
+	display := paths.DisplayPaths(m.FilePaths())
+	for i, f := range m.Files {
+		if d := display[f.Path]; d != f.Path {
+			m.Files[i].DisplayPath = d
+		}
+	}
+}
+
+// recordDelta records the previous hashes of included files and, when diffs were shown,
+// the previous content of changed files, so a delta prompt can be regenerated
+func (m *Manifest) recordDelta(delta *DeltaContext) {
+	if delta == nil {
+		return
+	}
+	m.Delta = &DeltaContext{Hashes: make(map[string]string), ShowDiffs: delta.ShowDiffs}
+	for _, f := range m.Files {
+		previous, ok := delta.Hashes[f.Path]
+		if !ok {
+			continue
+		}
+		m.Delta.Hashes[f.Path] = previous
+		if previous == f.SHA256 || !delta.ShowDiffs {
+			continue
+		}
+		display := f.DisplayPath
+		if display == "" {
+			display = f.Path
+		}
+		if content, known := delta.previousContent(f.Path, display); known {
+			if m.Delta.PreviousContents == nil {
+				m.Delta.PreviousContents = make(map[string]string)
+			}
+			m.Delta.PreviousContents[f.Path] = content
+		}
+	}
+}
+
+// recordSlices records the slices of included files in selector syntax
+func (m *Manifest) recordSlices(slices map[string][]Slice) {
+	for _, f := range m.Files {
+		for _, slice := range slices[f.Path] {
+			if m.Slices == nil {
+				m.Slices = make(map[string][]string)
+			}
+			m.Slices[f.Path] = append(m.Slices[f.Path], slice.String())
+		}
+	}
+}
+
+// recordParts records the chunk settings and per-part hashes of a split prompt
+func (m *Manifest) recordParts(chunking ChunkConfig, parts []string) {
+	m.Chunking = &chunking
+	m.Parts = make([]ManifestPart, 0, len(parts))
+	for i, part := range parts {
+		m.Parts = append(m.Parts, ManifestPart{Part: i + 1, Size: int64(len(part)), SHA256: hashString(part)})
+	}
+}
+
+// Verify compares the recorded files against the filesystem, or against the recorded
+// archive or git revision. Files of a source that cannot be opened are missing.
+func (m *Manifest) Verify() []FileDrift {
+	var source *scanner.Source
+	var sourceErr error
+	if m.Source != nil {
+		source, sourceErr = m.Source.Open()
+		defer source.Close()
+	}
+
+	drifts := make([]FileDrift, 0, len(m.Files))
+	for _, f := range m.Files {
+		drift := FileDrift{Path: f.Path, Status: DriftUnchanged}
+
+		size, sum, err := hashFile(source, f.Path)
+		switch {
+		case err != nil || sourceErr != nil:
+			drift.Status = DriftMissing
+		case size != f.Size || sum != f.SHA256:
+			drift.Status = DriftModified
+			drift.Size = size
+			drift.SHA256 = sum
+		default:
+			drift.Size = size
+			drift.SHA256 = sum
+		}
+
+		drifts = append(drifts, drift)
+	}
+	return drifts
+}
+
+// HasDrift reports whether any verification result differs from the manifest
+func HasDrift(drifts []FileDrift) bool {
+	for _, d := range drifts {
+		if d.Status != DriftUnchanged {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteManifest writes the manifest next to the prompt file and returns its path
+func WriteManifest(manifest *Manifest, promptPath string) (string, error) {
+	if manifest == nil {
+		return "", fmt.Errorf("manifest cannot be nil")
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	// Written like the prompt, so verify and regen never read a truncated manifest
+	path := ManifestPath(promptPath)
+	if err := NewFileWriter().writeAtomic(path, writeString(string(data)+"\n")); err != nil {
+		return "", fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	return path, nil
+}
+
+// LoadManifest reads a manifest from disk
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	if manifest.SchemaVersion > ManifestSchemaVersion {
+		return nil, fmt.Errorf("unsupported manifest schema version %d", manifest.SchemaVersion)
+	}
+
+	return &manifest, nil
+}
+
+// hashFile returns the size and SHA-256 of a file
+func hashFile(source *scanner.Source, path string) (int64, string, error) {
+	file, err := source.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	size, err := io.Copy(hasher, file)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return size, hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// hashString returns the hex SHA-256 of a string
+func hashString(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
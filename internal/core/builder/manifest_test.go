@@ -0,0 +1,197 @@
+package builder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/diogopedro/shotgun/internal/models"
+)
+
+func manifestTestTemplate() *models.Template {
+	return &models.Template{
+		ID:      "manifest-test",
+		Name:    "Manifest Test",
+		Version: "1.2.0",
+		Content: "Date: {{CURRENT_DATE}}\nTask: {{TASK}}\n{{FILE_STRUCTURE}}",
+	}
+}
+
+func TestGenerationTime_SourceDateEpoch(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+
+	got := GenerationTime()
+	if !got.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("Expected SOURCE_DATE_EPOCH time, got %v", got)
+	}
+
+	t.Setenv("SOURCE_DATE_EPOCH", "not-a-number")
+	if time.Since(GenerationTime()) > time.Minute {
+		t.Error("Invalid SOURCE_DATE_EPOCH should fall back to now")
+	}
+}
+
+func TestBuildManifest(t *testing.T) {
+	tempDir := t.TempDir()
+	fileB := filepath.Join(tempDir, "b.go")
+	fileA := filepath.Join(tempDir, "a.go")
+	os.WriteFile(fileB, []byte("package b\n"), 0644)
+	os.WriteFile(fileA, []byte("package a\n"), 0644)
+
+	vars := map[string]string{"TASK": "do it", "FILE_STRUCTURE": "huge"}
+	manifest, err := BuildManifest(manifestTestTemplate(), vars, []string{fileB, fileA}, "output", time.Unix(0, 0))
+	if err != nil {
+		t.Fatalf("BuildManifest failed: %v", err)
+	}
+
+	if manifest.Template.ID != "manifest-test" || manifest.Template.Version != "1.2.0" {
+		t.Errorf("Unexpected template record: %+v", manifest.Template)
+	}
+	if _, ok := manifest.Variables["FILE_STRUCTURE"]; ok {
+		t.Error("FILE_STRUCTURE should not be recorded")
+	}
+	if manifest.Variables["TASK"] != "do it" {
+		t.Errorf("Expected TASK variable to be recorded, got %q", manifest.Variables["TASK"])
+	}
+	if len(manifest.Files) != 2 || manifest.Files[0].Path != fileA {
+		t.Fatalf("Expected files sorted by path, got %+v", manifest.Files)
+	}
+	if manifest.Files[0].Size != int64(len("package a\n")) || len(manifest.Files[0].SHA256) != 64 {
+		t.Errorf("Unexpected file record: %+v", manifest.Files[0])
+	}
+
+	if _, err := BuildManifest(nil, vars, nil, "", time.Time{}); err == nil {
+		t.Error("Expected error for nil template")
+	}
+	if _, err := BuildManifest(manifestTestTemplate(), vars, []string{filepath.Join(tempDir, "missing")}, "", time.Time{}); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
+
+func TestManifest_Verify(t *testing.T) {
+	tempDir := t.TempDir()
+	kept := filepath.Join(tempDir, "kept.txt")
+	changed := filepath.Join(tempDir, "changed.txt")
+	removed := filepath.Join(tempDir, "removed.txt")
+	for _, f := range []string{kept, changed, removed} {
+		os.WriteFile(f, []byte("original"), 0644)
+	}
+
+	manifest, err := BuildManifest(manifestTestTemplate(), nil, []string{kept, changed, removed}, "", time.Now())
+	if err != nil {
+		t.Fatalf("BuildManifest failed: %v", err)
+	}
+
+	if HasDrift(manifest.Verify()) {
+		t.Fatal("Expected no drift before changes")
+	}
+
+	os.WriteFile(changed, []byte("modified"), 0644)
+	os.Remove(removed)
+
+	statuses := make(map[string]DriftStatus)
+	for _, d := range manifest.Verify() {
+		statuses[d.Path] = d.Status
+	}
+
+	if statuses[kept] != DriftUnchanged {
+		t.Errorf("Expected kept file unchanged, got %s", statuses[kept])
+	}
+	if statuses[changed] != DriftModified {
+		t.Errorf("Expected changed file modified, got %s", statuses[changed])
+	}
+	if statuses[removed] != DriftMissing {
+		t.Errorf("Expected removed file missing, got %s", statuses[removed])
+	}
+}
+
+func TestManifest_WriteLoadRoundTrip(t *testing.T) {
+	tempDir := t.TempDir()
+	promptPath := filepath.Join(tempDir, "prompt.md")
+
+	manifest, _ := BuildManifest(manifestTestTemplate(), map[string]string{"TASK": "x"}, nil, "out", time.Unix(1700000000, 0).UTC())
+
+	path, err := WriteManifest(manifest, promptPath)
+	if err != nil {
+		t.Fatalf("WriteManifest failed: %v", err)
+	}
+	if path != promptPath+".lock.json" {
+		t.Errorf("Unexpected manifest path %s", path)
+	}
+	if PromptPathFromManifest(path) != promptPath {
+		t.Errorf("PromptPathFromManifest did not invert ManifestPath")
+	}
+
+	loaded, err := LoadManifest(path)
+	if err != nil {
+		t.Fatalf("LoadManifest failed: %v", err)
+	}
+	if !loaded.GeneratedAt.Equal(manifest.GeneratedAt) || loaded.OutputSHA256 != manifest.OutputSHA256 {
+		t.Errorf("Round trip mismatch: %+v vs %+v", loaded, manifest)
+	}
+}
+
+func TestManifest_RegenerationIsByteIdentical(t *testing.T) {
+	tempDir := t.TempDir()
+	file := filepath.Join(tempDir, "main.go")
+	os.WriteFile(file, []byte("package main\n\nfunc main() {}\n"), 0644)
+
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+
+	generator := NewPromptGenerator()
+	config := GenerationConfig{
+		Template:      manifestTestTemplate(),
+		SelectedFiles: []string{file},
+		TaskContent:   "Explain {{RULES}}",
+		EmitManifest:  true,
+	}
+
+	original, err := generator.GeneratePrompt(context.Background(), config)
+	if err != nil {
+		t.Fatalf("GeneratePrompt failed: %v", err)
+	}
+	if original.Manifest == nil {
+		t.Fatal("Expected manifest to be recorded")
+	}
+
+	// Regeneration must not depend on the environment clock
+	t.Setenv("SOURCE_DATE_EPOCH", "")
+
+	regenConfig, err := original.Manifest.RegenerationConfig(manifestTestTemplate())
+	if err != nil {
+		t.Fatalf("RegenerationConfig failed: %v", err)
+	}
+
+	regenerated, err := generator.GeneratePrompt(context.Background(), regenConfig)
+	if err != nil {
+		t.Fatalf("GeneratePrompt failed: %v", err)
+	}
+
+	if regenerated.Content != original.Content {
+		t.Errorf("Regenerated content differs:\n%s\n---\n%s", regenerated.Content, original.Content)
+	}
+
+	changedTemplate := manifestTestTemplate()
+	changedTemplate.Content += "\nextra"
+	if _, err := original.Manifest.RegenerationConfig(changedTemplate); err == nil {
+		t.Error("Expected error when template content changed")
+	}
+}
+
+func TestWriteManifest_LeavesNoTempFile(t *testing.T) {
+	dir := t.TempDir()
+	promptPath := filepath.Join(dir, "prompt.md")
+
+	path, err := WriteManifest(&Manifest{SchemaVersion: ManifestSchemaVersion}, promptPath)
+	if err != nil {
+		t.Fatalf("WriteManifest failed: %v", err)
+	}
+	if _, err := LoadManifest(path); err != nil {
+		t.Errorf("written manifest should load: %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected only the manifest in %s, got %d entries", dir, len(entries))
+	}
+}
@@ -0,0 +1,176 @@
+package builder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"html"
+	"path/filepath"
+	"strings"
+
+	"github.com/diogopedro/shotgun/internal/models"
+)
+
+// GoOutline renders the API shape of a Go source file: the package clause, imports,
+// type declarations and func/method signatures with their doc comments. Bodies are elided.
+func GoOutline(filename string, src []byte) (string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+
+	var decls []ast.Decl
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			d.Body = nil
+			decls = append(decls, d)
+		case *ast.GenDecl:
+			if d.Tok == token.IMPORT || d.Tok == token.TYPE {
+				decls = append(decls, d)
+			}
+		}
+	}
+	file.Decls = decls
+	file.Comments = outlineComments(file)
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return "", fmt.Errorf("failed to print outline of %s: %w", filename, err)
+	}
+
+	return buf.String(), nil
+}
+
+// outlineComments keeps the comments that belong to the retained declarations:
+// header comments (build constraints, package docs), doc comments, and comments
+// inside import and type declarations
+func outlineComments(file *ast.File) []*ast.CommentGroup {
+	type span struct{ pos, end token.Pos }
+
+	spans := []span{{token.NoPos, file.Package}}
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Doc != nil {
+				spans = append(spans, span{d.Doc.Pos(), d.Doc.End()})
+			}
+			spans = append(spans, span{d.Pos(), d.End()})
+		case *ast.GenDecl:
+			if d.Doc != nil {
+				spans = append(spans, span{d.Doc.Pos(), d.Doc.End()})
+			}
+			spans = append(spans, span{d.Pos(), d.End()})
+		}
+	}
+
+	var kept []*ast.CommentGroup
+	for _, group := range file.Comments {
+		for _, s := range spans {
+			if group.Pos() >= s.pos && group.End() <= s.end {
+				kept = append(kept, group)
+				break
+			}
+		}
+	}
+	return kept
+}
+
+// renderMode returns the effective render mode for a file. Outline only applies to Go files.
+func (b *FileStructureBuilder) renderMode(path string) models.RenderMode {
+	b.mu.RLock()
+	mode := b.renderModes[path]
+	b.mu.RUnlock()
+
+	switch mode {
+	case models.RenderPathOnly:
+		return models.RenderPathOnly
+	case models.RenderOutline:
+		if SupportsOutline(path) {
+			return models.RenderOutline
+		}
+	}
+	return models.RenderFull
+}
+
+// SupportsOutline reports whether a file can be rendered as an outline (Go files only)
+func SupportsOutline(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".go")
+}
+
+// loadFile reads a file according to its render mode and adds header attributes when enabled
+func (b *FileStructureBuilder) loadFile(ctx context.Context, path string) fileContent {
+	content := b.loadContent(ctx, path)
+	if b.annotations.Header && content.mode != models.RenderPathOnly && content.err == nil {
+		content.header, _ = fileHeader(b.source, path)
+	}
+	return content
+}
+
+// loadContent reads a file according to its render mode. Slices take precedence over
+// outline and full rendering; path-only files are never read. Sensitive and binary
+// files keep their usual placeholders, unless the tree format hides binary placeholders.
+func (b *FileStructureBuilder) loadContent(ctx context.Context, path string) fileContent {
+	mode := b.renderMode(path)
+	b.mu.RLock()
+	showBinary := b.treeFormat.ShowBinary
+	b.mu.RUnlock()
+	if mode != models.RenderPathOnly && !showBinary && b.binaryDetector.IsBinaryIn(b.source, path) {
+		mode = models.RenderPathOnly
+	}
+	if mode != models.RenderPathOnly {
+		if slices := b.slicesFor(path); len(slices) > 0 && !b.isSensitiveFile(path) && !b.binaryDetector.IsBinaryIn(b.source, path) {
+			content, err := b.readSlices(ctx, path, slices)
+			return fileContent{path: path, content: content, err: err, mode: models.RenderFull, sliced: true}
+		}
+	}
+
+	switch mode {
+	case models.RenderPathOnly:
+		return fileContent{path: path, mode: models.RenderPathOnly}
+
+	case models.RenderOutline:
+		// Sensitive and binary files get readContent's warning or placeholder, and files
+		// that cannot be outlined fall back to full content
+		if b.isSensitiveFile(path) || b.binaryDetector.IsBinaryIn(b.source, path) {
+			break
+		}
+		if outline, err := b.readGoOutline(ctx, path); err == nil {
+			return fileContent{path: path, content: html.EscapeString(b.scrub(outline)), mode: models.RenderOutline}
+		}
+	}
+
+	return b.readContent(ctx, path)
+}
+
+// readGoOutline reads a Go file and renders its outline
+func (b *FileStructureBuilder) readGoOutline(ctx context.Context, path string) (string, error) {
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+
+	info, err := b.source.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	b.mu.RLock()
+	maxSize := b.maxFileSize
+	b.mu.RUnlock()
+
+	if info.Size() > maxSize {
+		return "", fmt.Errorf("file too large to outline")
+	}
+
+	src, err := b.source.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return GoOutline(path, []byte(b.decode(src)))
+}
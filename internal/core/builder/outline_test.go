@@ -0,0 +1,182 @@
+package builder
+
+import (
+	"context"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/diogopedro/shotgun/internal/models"
+)
+
+const outlineSource = `//go:build linux
+
+// Package shapes draws shapes.
+package shapes
+
+import (
+	"fmt"
+	"math"
+)
+
+// Version is not part of the outline
+const Version = "1.0"
+
+var registry = map[string]Shape{}
+
+// Shape is anything with an area.
+type Shape interface {
+	Area() float64 // Area in square units
+}
+
+// Circle is a round shape.
+type Circle struct {
+	R float64 // Radius
+}
+
+// Area returns the circle area.
+func (c Circle) Area() float64 {
+	// secret implementation detail
+	return math.Pi * c.R * c.R
+}
+
+func describe(s Shape) string {
+	return fmt.Sprint(s.Area())
+}
+`
+
+func TestGoOutline(t *testing.T) {
+	outline, err := GoOutline("shapes.go", []byte(outlineSource))
+	if err != nil {
+		t.Fatalf("GoOutline failed: %v", err)
+	}
+
+	mustContain := []string{
+		"//go:build linux",
+		"// Package shapes draws shapes.",
+		"package shapes",
+		`"math"`,
+		"// Shape is anything with an area.",
+		"Area() float64 // Area in square units",
+		"R float64 // Radius",
+		"// Area returns the circle area.\nfunc (c Circle) Area() float64\n",
+		"func describe(s Shape) string",
+	}
+	for _, want := range mustContain {
+		if !strings.Contains(outline, want) {
+			t.Errorf("Outline missing %q:\n%s", want, outline)
+		}
+	}
+
+	mustNotContain := []string{"secret implementation detail", "math.Pi", "Version", "registry", "return fmt"}
+	for _, unwanted := range mustNotContain {
+		if strings.Contains(outline, unwanted) {
+			t.Errorf("Outline should not contain %q:\n%s", unwanted, outline)
+		}
+	}
+}
+
+func TestGoOutline_InvalidSource(t *testing.T) {
+	if _, err := GoOutline("bad.go", []byte("package bad\nfunc {")); err == nil {
+		t.Error("Expected parse error")
+	}
+}
+
+func TestGeneratePrompt_RenderModes(t *testing.T) {
+	tempDir := t.TempDir()
+	goFile := filepath.Join(tempDir, "shapes.go")
+	textFile := filepath.Join(tempDir, "notes.txt")
+	hidden := filepath.Join(tempDir, "big.txt")
+	os.WriteFile(goFile, []byte(outlineSource), 0644)
+	os.WriteFile(textFile, []byte("outline does not apply"), 0644)
+	os.WriteFile(hidden, []byte("path only content"), 0644)
+
+	config := GenerationConfig{
+		Template:      &models.Template{ID: "modes", Content: "{{FILE_STRUCTURE}}"},
+		SelectedFiles: []string{goFile, textFile, hidden},
+		RenderModes: map[string]models.RenderMode{
+			goFile:   models.RenderOutline,
+			textFile: models.RenderOutline,
+			hidden:   models.RenderPathOnly,
+		},
+		EmitManifest: true,
+	}
+
+	result, err := NewPromptGenerator().GeneratePrompt(context.Background(), config)
+	if err != nil {
+		t.Fatalf("GeneratePrompt failed: %v", err)
+	}
+
+	if !strings.Contains(result.Content, `<file path="`+filepath.Base(goFile)+`" mode="outline">`) {
+		t.Errorf("Go file should be rendered as an outline:\n%s", result.Content)
+	}
+	if strings.Contains(result.Content, "secret implementation detail") {
+		t.Error("Outline should elide function bodies")
+	}
+	if !strings.Contains(result.Content, `<file path="`+filepath.Base(textFile)+`">outline does not apply`) {
+		t.Error("Outline mode should fall back to full content for non-Go files")
+	}
+	if !strings.Contains(result.Content, "big.txt") || strings.Contains(result.Content, "path only content") {
+		t.Error("Path-only file should appear in the tree without content")
+	}
+	if result.Manifest.RenderModes[hidden] != models.RenderPathOnly {
+		t.Errorf("Manifest should record render modes, got %v", result.Manifest.RenderModes)
+	}
+}
+
+func TestGeneratePrompt_OutlineSensitiveFile(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "secrets.go")
+	os.WriteFile(secret, []byte("package config\n\n// APIKey is the production key sk-live-123\nfunc APIKey() string { return \"\" }\n"), 0644)
+
+	result, err := NewPromptGenerator().GeneratePrompt(context.Background(), GenerationConfig{
+		Template:      &models.Template{ID: "modes", Content: "{{FILE_STRUCTURE}}"},
+		SelectedFiles: []string{secret},
+		RenderModes:   map[string]models.RenderMode{secret: models.RenderOutline},
+	})
+	if err != nil {
+		t.Fatalf("GeneratePrompt failed: %v", err)
+	}
+
+	if strings.Contains(result.Content, "sk-live-123") || strings.Contains(result.Content, `mode="outline"`) {
+		t.Errorf("Sensitive files should not be outlined:\n%s", result.Content)
+	}
+	if !strings.Contains(result.Content, "Potentially sensitive file detected") {
+		t.Errorf("Expected the sensitive file warning:\n%s", result.Content)
+	}
+}
+
+func TestEstimatePromptSize_RenderModes(t *testing.T) {
+	tempDir := t.TempDir()
+	goFile := filepath.Join(tempDir, "shapes.go")
+	hidden := filepath.Join(tempDir, "big.txt")
+	os.WriteFile(goFile, []byte(outlineSource), 0644)
+	os.WriteFile(hidden, []byte(strings.Repeat("x", 1000)), 0644)
+
+	estimator := NewSizeEstimator(nil)
+	config := EstimationConfig{
+		Template:      &models.Template{Content: "{{FILE_STRUCTURE}}"},
+		SelectedFiles: []string{goFile, hidden},
+	}
+
+	full, err := estimator.EstimatePromptSize(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config.RenderModes = map[string]models.RenderMode{goFile: models.RenderOutline, hidden: models.RenderPathOnly}
+	reduced, err := estimator.EstimatePromptSize(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outline, _ := GoOutline(goFile, []byte(outlineSource))
+	outline = html.EscapeString(outline)
+	if reduced.FileContentSize != int64(len(outline)) {
+		t.Errorf("Expected content size %d (outline only), got %d", len(outline), reduced.FileContentSize)
+	}
+	if reduced.TotalSize >= full.TotalSize {
+		t.Errorf("Render modes should shrink the estimate: %d >= %d", reduced.TotalSize, full.TotalSize)
+	}
+}
@@ -0,0 +1,188 @@
+package builder
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// PathOptions controls how file paths and local identity appear in prompt output
+type PathOptions struct {
+	Root      string   `json:"root,omitempty"`      // Project root paths are relative to; empty uses the files' common directory
+	Roots     []string `json:"roots,omitempty"`     // Several project roots; files appear under each root's label, overriding Root
+	Prefix    string   `json:"prefix,omitempty"`    // Virtual prefix for relative paths, e.g. "repo/"
+	Absolute  bool     `json:"absolute,omitempty"`  // Keep paths as given, exposing the local directory layout
+	Anonymize bool     `json:"anonymize,omitempty"` // Scrub the local username, home directory and hostname from contents
+}
+
+// IsZero reports whether the options are all defaults
+func (o PathOptions) IsZero() bool {
+	return o.Root == "" && len(o.Roots) == 0 && o.Prefix == "" && !o.Absolute && !o.Anonymize
+}
+
+// DisplayPaths maps each file to the path written in the prompt
+func (o PathOptions) DisplayPaths(files []string) map[string]string {
+	var paths map[string]string
+	var rest []string
+	if len(o.Roots) > 0 && !o.Absolute {
+		paths = o.rootDisplay(files)
+		for _, file := range files {
+			if _, ok := paths[file]; !ok {
+				rest = append(rest, file)
+			}
+		}
+	} else {
+		paths = make(map[string]string, len(files))
+		rest = files
+	}
+
+	root := o.root(rest)
+	for _, file := range rest {
+		paths[file] = o.display(root, file, "")
+	}
+	return paths
+}
+
+// root returns the directory paths are made relative to
+func (o PathOptions) root(files []string) string {
+	if o.Absolute {
+		return ""
+	}
+	if o.Root != "" {
+		if abs, err := filepath.Abs(o.Root); err == nil {
+			return abs
+		}
+		return o.Root
+	}
+	return commonDir(files)
+}
+
+// display renders one path relative to root, under the root label and virtual prefix
+func (o PathOptions) display(root, path, label string) string {
+	if root == "" {
+		return path
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return path
+	}
+
+	rel = filepath.ToSlash(rel)
+	if label != "" {
+		rel = label + "/" + rel
+	}
+	if o.Prefix == "" {
+		return rel
+	}
+	return strings.TrimSuffix(o.Prefix, "/") + "/" + rel
+}
+
+// commonDir returns the deepest directory containing every file
+func commonDir(files []string) string {
+	if len(files) == 0 {
+		return ""
+	}
+
+	dir := filepath.Dir(files[0])
+	for _, file := range files[1:] {
+		for !withinDir(file, dir) {
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				return dir
+			}
+			dir = parent
+		}
+	}
+	return dir
+}
+
+// withinDir reports whether path lies beneath dir
+func withinDir(path, dir string) bool {
+	if strings.HasSuffix(dir, string(filepath.Separator)) {
+		return strings.HasPrefix(path, dir)
+	}
+	return strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// genericNames are account and host names that identify nobody and are common words in code
+var genericNames = map[string]bool{
+	"root": true, "admin": true, "user": true, "runner": true, "ubuntu": true,
+	"localhost": true, "vagrant": true, "docker": true,
+}
+
+// Anonymizer scrubs the local home directory, username and hostname from text
+type Anonymizer struct {
+	patterns     []*regexp.Regexp
+	replacements []string
+}
+
+// NewAnonymizer creates an anonymizer for the current user and machine
+func NewAnonymizer() *Anonymizer {
+	home, _ := os.UserHomeDir()
+	hostname, _ := os.Hostname()
+	username := ""
+	if current, err := user.Current(); err == nil {
+		username = current.Username
+	}
+	return newAnonymizer(home, username, hostname)
+}
+
+// newAnonymizer builds the scrub patterns. The home directory is replaced first so
+// paths collapse to ~ before the username inside them is considered. Names shorter
+// than three characters and generic names are left alone to avoid mangling code.
+func newAnonymizer(home, username, hostname string) *Anonymizer {
+	a := &Anonymizer{}
+	add := func(pattern, replacement string) {
+		a.patterns = append(a.patterns, regexp.MustCompile(pattern))
+		a.replacements = append(a.replacements, replacement)
+	}
+	word := func(name string) bool {
+		return len(name) >= 3 && !genericNames[strings.ToLower(name)]
+	}
+
+	if home != "" && home != string(filepath.Separator) {
+		add(regexp.QuoteMeta(home)+`\b`, "~")
+	}
+	if word(hostname) {
+		add(`(?i)\b`+regexp.QuoteMeta(hostname)+`\b`, "<host>")
+		if short, _, found := strings.Cut(hostname, "."); found && word(short) {
+			add(`(?i)\b`+regexp.QuoteMeta(short)+`\b`, "<host>")
+		}
+	}
+	if i := strings.LastIndex(username, `\`); i >= 0 {
+		username = username[i+1:] // DOMAIN\name on Windows
+	}
+	if word(username) {
+		add(`\b`+regexp.QuoteMeta(username)+`\b`, "<user>")
+	}
+	return a
+}
+
+// Scrub replaces identifying strings in text
+func (a *Anonymizer) Scrub(text string) string {
+	if a == nil {
+		return text
+	}
+	for i, pattern := range a.patterns {
+		text = pattern.ReplaceAllLiteralString(text, a.replacements[i])
+	}
+	return text
+}
+
+// WithPaths sets how paths are rendered and whether contents are anonymized
+func WithPaths(paths PathOptions) Option {
+	return func(b *FileStructureBuilder) {
+		b.paths = paths
+		b.anonymizer = nil
+		if paths.Anonymize {
+			b.anonymizer = NewAnonymizer()
+		}
+	}
+}
+
+// scrub anonymizes content when enabled
+func (b *FileStructureBuilder) scrub(text string) string {
+	return b.anonymizer.Scrub(text)
+}
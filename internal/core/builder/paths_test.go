@@ -0,0 +1,104 @@
+package builder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/diogopedro/shotgun/internal/models"
+)
+
+func TestPathOptions_DisplayPaths(t *testing.T) {
+	files := []string{"/home/alice/work/proj/cmd/main.go", "/home/alice/work/proj/go.mod"}
+
+	tests := []struct {
+		name    string
+		options PathOptions
+		want    string
+	}{
+		{"common directory by default", PathOptions{}, "cmd/main.go"},
+		{"explicit root", PathOptions{Root: "/home/alice/work"}, "proj/cmd/main.go"},
+		{"virtual prefix", PathOptions{Prefix: "repo"}, "repo/cmd/main.go"},
+		{"prefix with slash", PathOptions{Prefix: "repo/"}, "repo/cmd/main.go"},
+		{"absolute", PathOptions{Absolute: true, Prefix: "repo/"}, files[0]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.options.DisplayPaths(files)[files[0]]; got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnonymizer_Scrub(t *testing.T) {
+	a := newAnonymizer("/home/alice", "alice", "alice-laptop.corp.example")
+	got := a.Scrub("cd /home/alice/src && ssh alice@alice-laptop.corp.example # alice-laptop, malice, alice")
+	want := "cd ~/src && ssh <user>@<host> # <host>, malice, <user>"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	generic := newAnonymizer("", "root", "localhost")
+	if got := generic.Scrub("root of the tree on localhost"); got != "root of the tree on localhost" {
+		t.Errorf("generic names should be kept, got %q", got)
+	}
+}
+
+func TestGeneratePrompt_PathOptions(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "internal", "config.go")
+	os.MkdirAll(filepath.Dir(file), 0755)
+	home, _ := os.UserHomeDir()
+	os.WriteFile(file, []byte("package internal\n\nconst cache = \""+home+"/.cache\"\n"), 0644)
+
+	config := GenerationConfig{
+		Template:      &models.Template{ID: "paths", Content: "{{FILE_STRUCTURE}}"},
+		SelectedFiles: []string{file},
+		Paths:         PathOptions{Root: root, Prefix: "repo/", Anonymize: true},
+		EmitManifest:  true,
+	}
+
+	result, err := NewPromptGenerator().GeneratePrompt(context.Background(), config)
+	if err != nil {
+		t.Fatalf("GeneratePrompt failed: %v", err)
+	}
+
+	if strings.Contains(result.Content, root) {
+		t.Errorf("prompt should not contain the local root:\n%s", result.Content)
+	}
+	if !strings.Contains(result.Content, `<file path="repo/internal/config.go">`) {
+		t.Errorf("expected a prefixed relative path:\n%s", result.Content)
+	}
+	if home != "" && home != "/" && !strings.Contains(result.Content, "~/.cache") {
+		t.Errorf("expected the home directory to be scrubbed:\n%s", result.Content)
+	}
+
+	manifest := result.Manifest
+	if manifest.Paths == nil || manifest.Files[0].DisplayPath != "repo/internal/config.go" {
+		t.Errorf("manifest should record path options and display paths: %+v", manifest.Files)
+	}
+	regenerated, err := manifest.RegenerationConfig(config.Template)
+	if err != nil || !reflect.DeepEqual(regenerated.Paths, config.Paths) {
+		t.Errorf("regeneration should restore path options, got %+v (%v)", regenerated.Paths, err)
+	}
+
+	estimate, err := NewSizeEstimator(nil).EstimatePromptSize(context.Background(), EstimationConfig{
+		Template:      config.Template,
+		SelectedFiles: config.SelectedFiles,
+		Paths:         config.Paths,
+	})
+	if err != nil {
+		t.Fatalf("EstimatePromptSize failed: %v", err)
+	}
+	open := `<file path="repo/internal/config.go">`
+	body := result.Content[strings.Index(result.Content, open)+len(open):]
+	body = body[:strings.Index(body, "</file>")]
+	if estimate.FileContentSize != int64(len(body)) {
+		t.Errorf("estimate should match the anonymized content: %d, want %d", estimate.FileContentSize, len(body))
+	}
+}
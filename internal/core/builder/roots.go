@@ -0,0 +1,118 @@
+package builder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ResolveRoots turns project root arguments into absolute, existing directories.
+// Duplicates and roots nested inside another root are dropped, keeping argument
+// order; no arguments means the current directory.
+func ResolveRoots(paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	var roots []string
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", path, err)
+		}
+		info, err := os.Stat(abs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("%s is not a directory", path)
+		}
+		roots = append(roots, abs)
+	}
+
+	var kept []string
+	for i, root := range roots {
+		covered := false
+		for j, other := range roots {
+			if i != j && (withinDir(root, other) || root == other && j < i) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			kept = append(kept, root)
+		}
+	}
+	return kept, nil
+}
+
+// RootLabels returns the top-level name each root's files appear under: its base
+// name, joined with its parent's name when two roots share a base name, and
+// numbered when that is still ambiguous
+func RootLabels(roots []string) []string {
+	bases := make(map[string]int, len(roots))
+	for _, root := range roots {
+		bases[filepath.Base(root)]++
+	}
+
+	labels := make([]string, len(roots))
+	for i, root := range roots {
+		labels[i] = filepath.Base(root)
+		if bases[labels[i]] > 1 {
+			labels[i] = filepath.Base(filepath.Dir(root)) + "-" + labels[i]
+		}
+	}
+	seen := make(map[string]int)
+	for i, label := range labels {
+		seen[label]++
+		if seen[label] > 1 {
+			labels[i] = label + "-" + strconv.Itoa(seen[label])
+		}
+	}
+	return labels
+}
+
+// ProjectName returns the PROJECT_NAME template variable: the root labels joined
+// with "+", the base name of Root, or of the working directory
+func (o PathOptions) ProjectName() string {
+	switch {
+	case len(o.Roots) > 0:
+		return strings.Join(RootLabels(o.Roots), "+")
+	case o.Root != "":
+		if abs, err := filepath.Abs(o.Root); err == nil {
+			return filepath.Base(abs)
+		}
+		return filepath.Base(o.Root)
+	}
+	if wd, err := os.Getwd(); err == nil {
+		return filepath.Base(wd)
+	}
+	return ""
+}
+
+// rootDisplay maps files under the roots to "<label>/<relative path>". Files outside
+// every root are left out. The deepest containing root wins.
+func (o PathOptions) rootDisplay(files []string) map[string]string {
+	labels := RootLabels(o.Roots)
+	order := make([]int, len(o.Roots))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return len(o.Roots[order[a]]) > len(o.Roots[order[b]])
+	})
+
+	paths := make(map[string]string, len(files))
+	for _, file := range files {
+		for _, i := range order {
+			if withinDir(file, o.Roots[i]) {
+				paths[file] = o.display(o.Roots[i], file, labels[i])
+				break
+			}
+		}
+	}
+	return paths
+}
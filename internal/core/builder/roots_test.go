@@ -0,0 +1,85 @@
+package builder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/diogopedro/shotgun/internal/models"
+)
+
+func TestResolveRoots(t *testing.T) {
+	base := t.TempDir()
+	orders := filepath.Join(base, "orders")
+	billing := filepath.Join(base, "billing")
+	nested := filepath.Join(orders, "internal")
+	for _, dir := range []string{nested, billing} {
+		os.MkdirAll(dir, 0755)
+	}
+	file := filepath.Join(base, "README.md")
+	os.WriteFile(file, []byte("# services\n"), 0644)
+
+	roots, err := ResolveRoots([]string{orders, billing, nested, orders})
+	if err != nil {
+		t.Fatalf("ResolveRoots failed: %v", err)
+	}
+	if want := []string{orders, billing}; !reflect.DeepEqual(roots, want) {
+		t.Errorf("ResolveRoots() = %v, want %v", roots, want)
+	}
+
+	if _, err := ResolveRoots([]string{file}); err == nil {
+		t.Error("expected an error for a file root")
+	}
+	if _, err := ResolveRoots([]string{filepath.Join(base, "missing")}); err == nil {
+		t.Error("expected an error for a missing root")
+	}
+}
+
+func TestRootLabels(t *testing.T) {
+	roots := []string{"/src/team-a/api", "/src/team-b/api", "/src/web", "/x/team-a/api", "/y/team-a/api"}
+	want := []string{"team-a-api", "team-b-api", "web", "team-a-api-2", "team-a-api-3"}
+	if got := RootLabels(roots); !reflect.DeepEqual(got, want) {
+		t.Errorf("RootLabels() = %v, want %v", got, want)
+	}
+}
+
+func TestGeneratePrompt_MultipleRoots(t *testing.T) {
+	base := t.TempDir()
+	orders := filepath.Join(base, "orders")
+	billing := filepath.Join(base, "billing")
+	files := map[string]string{
+		filepath.Join(orders, "cmd", "main.go"):   "package main\n",
+		filepath.Join(billing, "invoice.go"):      "package billing\n",
+		filepath.Join(base, "shared", "proto.go"): "package shared\n",
+	}
+	var selected []string
+	for path, content := range files {
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+		selected = append(selected, path)
+	}
+
+	config := GenerationConfig{
+		Template:      &models.Template{ID: "roots", Content: "# {{PROJECT_NAME}}\n{{FILE_STRUCTURE}}"},
+		SelectedFiles: selected,
+		Paths:         PathOptions{Roots: []string{orders, billing}},
+	}
+	result, err := NewPromptGenerator().GeneratePrompt(context.Background(), config)
+	if err != nil {
+		t.Fatalf("GeneratePrompt failed: %v", err)
+	}
+
+	for _, want := range []string{
+		"# orders+billing\n",
+		`<file path="orders/cmd/main.go">`,
+		`<file path="billing/invoice.go">`,
+		"proto.go", // Files outside every root keep their plain relative path
+	} {
+		if !strings.Contains(result.Content, want) {
+			t.Errorf("expected %q in:\n%s", want, result.Content)
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"html"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/diogopedro/shotgun/internal/core/scanner"
+)
+
+// Slice selects part of a file: an inclusive 1-based line range or a named Go symbol
+type Slice struct {
+	Start  int    // First line; 0 with End 0 means a symbol slice
+	End    int    // Last line; 0 means to the end of the file
+	Symbol string // Function, type or Type.Method name
+}
+
+// String formats the slice in selector syntax without the path
+func (s Slice) String() string {
+	switch {
+	case s.Symbol != "":
+		return "#" + s.Symbol
+	case s.End == 0:
+		return fmt.Sprintf(":%d-", s.Start)
+	default:
+		return fmt.Sprintf(":%d-%d", s.Start, s.End)
+	}
+}
+
+// lineRangeSuffix matches ":120-240", ":120-" and ":120" at the end of a selector
+var lineRangeSuffix = regexp.MustCompile(`:(\d+)(?:-(\d*))?$`)
+
+// symbolSuffix matches "#Name" and "#Type.Method" at the end of a selector
+var symbolSuffix = regexp.MustCompile(`#([A-Za-z_][A-Za-z0-9_]*(?:\.[A-Za-z_][A-Za-z0-9_]*)?)$`)
+
+// ParseSelector splits a "path:120-240" or "path#FuncName" selector into its path and slice.
+// A plain path returns a nil slice.
+func ParseSelector(selector string) (string, *Slice, error) {
+	if m := symbolSuffix.FindStringSubmatchIndex(selector); m != nil {
+		return selector[:m[0]], &Slice{Symbol: selector[m[2]:m[3]]}, nil
+	}
+
+	m := lineRangeSuffix.FindStringSubmatch(selector)
+	if m == nil {
+		return selector, nil, nil
+	}
+
+	start, _ := strconv.Atoi(m[1])
+	end := start
+	if strings.Contains(m[0], "-") {
+		end, _ = strconv.Atoi(m[2]) // Empty end means to end of file
+	}
+	if start < 1 || (end != 0 && end < start) {
+		return "", nil, fmt.Errorf("invalid line range in %q", selector)
+	}
+
+	return strings.TrimSuffix(selector, m[0]), &Slice{Start: start, End: end}, nil
+}
+
+// ParseSlices parses slice strings as produced by Slice.String
+func ParseSlices(specs []string) ([]Slice, error) {
+	slices := make([]Slice, 0, len(specs))
+	for _, spec := range specs {
+		_, slice, err := ParseSelector(spec)
+		if err != nil {
+			return nil, err
+		}
+		if slice == nil {
+			return nil, fmt.Errorf("invalid slice %q", spec)
+		}
+		slices = append(slices, *slice)
+	}
+	return slices, nil
+}
+
+// mentionPattern finds @path, @path:10-20 and @path#Symbol tokens in free text
+var mentionPattern = regexp.MustCompile(`(?:^|\s)@([^\s@]+)`)
+
+// ResolveMentions finds @mentions in text that name existing files relative to root.
+// It returns the mentioned files and the slices requested for them; other @tokens are ignored.
+func ResolveMentions(text, root string) ([]string, map[string][]Slice) {
+	var files []string
+	slices := make(map[string][]Slice)
+	seen := make(map[string]bool)
+
+	for _, m := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		selector := strings.TrimRight(m[1], ".,;:!?)]}'\"")
+		path, slice, err := ParseSelector(selector)
+		if err != nil || path == "" {
+			continue
+		}
+
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(root, path)
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			continue
+		}
+
+		if !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+		if slice != nil {
+			slices[path] = append(slices[path], *slice)
+		}
+	}
+
+	return files, slices
+}
+
+// lineRange is an inclusive 1-based range of lines
+type lineRange struct{ start, end int }
+
+// resolveSlices turns slices into sorted, merged line ranges within the file
+func resolveSlices(path string, src []byte, lineCount int, slices []Slice) ([]lineRange, error) {
+	var ranges []lineRange
+	var symbols map[string]lineRange
+
+	for _, s := range slices {
+		if s.Symbol == "" {
+			end := s.End
+			if end == 0 || end > lineCount {
+				end = lineCount
+			}
+			if s.Start > lineCount {
+				return nil, fmt.Errorf("%s: line %d is past the end of the file (%d lines)", path, s.Start, lineCount)
+			}
+			ranges = append(ranges, lineRange{s.Start, end})
+			continue
+		}
+
+		if symbols == nil {
+			var err error
+			if symbols, err = goSymbolRanges(path, src); err != nil {
+				return nil, err
+			}
+		}
+		r, ok := symbols[s.Symbol]
+		if !ok {
+			return nil, fmt.Errorf("%s: symbol %s not found", path, s.Symbol)
+		}
+		ranges = append(ranges, r)
+	}
+
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].start < ranges[j].start })
+	var merged []lineRange
+	for _, r := range ranges {
+		if n := len(merged); n > 0 && r.start <= merged[n-1].end+1 {
+			merged[n-1].end = max(merged[n-1].end, r.end)
+			continue
+		}
+		merged = append(merged, r)
+	}
+
+	return merged, nil
+}
+
+// goSymbolRanges maps top-level Go functions, types, and methods (as Type.Method) to their
+// line ranges, including doc comments
+func goSymbolRanges(path string, src []byte) (map[string]lineRange, error) {
+	if !strings.EqualFold(filepath.Ext(path), ".go") {
+		return nil, fmt.Errorf("%s: symbol selectors are only supported for Go files", path)
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	span := func(doc *ast.CommentGroup, node ast.Node) lineRange {
+		start := node.Pos()
+		if doc != nil {
+			start = doc.Pos()
+		}
+		return lineRange{fset.Position(start).Line, fset.Position(node.End()).Line}
+	}
+
+	symbols := make(map[string]lineRange)
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			name := d.Name.Name
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				name = receiverType(d.Recv.List[0].Type) + "." + name
+			}
+			symbols[name] = span(d.Doc, d)
+
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range d.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if len(d.Specs) == 1 {
+					symbols[ts.Name.Name] = span(d.Doc, d)
+				} else {
+					symbols[ts.Name.Name] = span(ts.Doc, ts)
+				}
+			}
+		}
+	}
+
+	return symbols, nil
+}
+
+// receiverType returns the base type name of a method receiver
+func receiverType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverType(t.X)
+	case *ast.IndexExpr:
+		return receiverType(t.X)
+	case *ast.IndexListExpr:
+		return receiverType(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+// RenderSlices renders the selected parts of a file. Each slice gets a line-number header and
+// the lines between and around slices are replaced by elision markers.
+func RenderSlices(path string, src []byte, slices []Slice) (string, error) {
+	lines := strings.SplitAfter(string(src), "\n")
+	if n := len(lines); n > 0 && lines[n-1] == "" {
+		lines = lines[:n-1]
+	}
+
+	ranges, err := resolveSlices(path, src, len(lines), slices)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	next := 1
+	for _, r := range ranges {
+		if r.start > next {
+			fmt.Fprintf(&b, "... lines %d-%d elided ...\n", next, r.start-1)
+		}
+		fmt.Fprintf(&b, "--- lines %d-%d of %d ---\n", r.start, r.end, len(lines))
+		for _, line := range lines[r.start-1 : r.end] {
+			b.WriteString(line)
+		}
+		if !strings.HasSuffix(lines[r.end-1], "\n") {
+			b.WriteString("\n")
+		}
+		next = r.end + 1
+	}
+	if next <= len(lines) {
+		fmt.Fprintf(&b, "... lines %d-%d elided ...\n", next, len(lines))
+	}
+
+	return b.String(), nil
+}
+
+// slicesFor returns the slices requested for a file
+func (b *FileStructureBuilder) slicesFor(path string) []Slice {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.slices[path]
+}
+
+// readSlices reads a file and renders only its selected slices
+func (b *FileStructureBuilder) readSlices(ctx context.Context, path string, slices []Slice) (string, error) {
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+
+	src, err := b.source.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+
+	rendered, err := RenderSlices(path, []byte(b.decode(src)), slices)
+	if err != nil {
+		return "", err
+	}
+
+	return html.EscapeString(b.scrub(rendered)), nil
+}
+
+// ValidateSlices checks that every slice resolves against the current file contents
+func ValidateSlices(slices map[string][]Slice) error {
+	return validateSlices(nil, slices)
+}
+
+// validateSlices is ValidateSlices for files of an archive or git revision
+func validateSlices(source *scanner.Source, slices map[string][]Slice) error {
+	paths := make([]string, 0, len(slices))
+	for path := range slices {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		src, err := source.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if _, err := RenderSlices(path, []byte(decodeText(src, false)), slices[path]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
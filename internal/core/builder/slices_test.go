@@ -0,0 +1,199 @@
+package builder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/diogopedro/shotgun/internal/models"
+)
+
+const sliceSource = `package shapes
+
+import "math"
+
+// Circle is a round shape
+type Circle struct {
+	R float64
+}
+
+// Area returns the area of the circle
+func (c *Circle) Area() float64 {
+	return math.Pi * c.R * c.R
+}
+
+// Scale multiplies a length
+func Scale(v, f float64) float64 {
+	return v * f
+}
+`
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		selector string
+		path     string
+		slice    *Slice
+	}{
+		{"main.go", "main.go", nil},
+		{"a/b.go:120-240", "a/b.go", &Slice{Start: 120, End: 240}},
+		{"a/b.go:7", "a/b.go", &Slice{Start: 7, End: 7}},
+		{"a/b.go:30-", "a/b.go", &Slice{Start: 30}},
+		{"a/b.go#Scale", "a/b.go", &Slice{Symbol: "Scale"}},
+		{"a/b.go#Circle.Area", "a/b.go", &Slice{Symbol: "Circle.Area"}},
+	}
+
+	for _, tt := range tests {
+		path, slice, err := ParseSelector(tt.selector)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.selector, err)
+			continue
+		}
+		if path != tt.path || !reflect.DeepEqual(slice, tt.slice) {
+			t.Errorf("%s: got (%s, %+v), want (%s, %+v)", tt.selector, path, slice, tt.path, tt.slice)
+		}
+		if slice != nil {
+			if _, roundTrip, _ := ParseSelector("x" + slice.String()); !reflect.DeepEqual(roundTrip, slice) {
+				t.Errorf("%s: String() does not round-trip: %s", tt.selector, slice.String())
+			}
+		}
+	}
+
+	for _, bad := range []string{"a.go:0-3", "a.go:9-3"} {
+		if _, _, err := ParseSelector(bad); err == nil {
+			t.Errorf("expected error for %s", bad)
+		}
+	}
+}
+
+func TestRenderSlices(t *testing.T) {
+	src := []byte("one\ntwo\nthree\nfour\nfive\n")
+
+	got, err := RenderSlices("f.txt", src, []Slice{{Start: 4, End: 4}, {Start: 2, End: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "... lines 1-1 elided ...\n" +
+		"--- lines 2-2 of 5 ---\ntwo\n" +
+		"... lines 3-3 elided ...\n" +
+		"--- lines 4-4 of 5 ---\nfour\n" +
+		"... lines 5-5 elided ...\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Overlapping and adjacent ranges merge; an open end runs to the last line
+	got, _ = RenderSlices("f.txt", src, []Slice{{Start: 1, End: 2}, {Start: 3}})
+	if got != "--- lines 1-5 of 5 ---\none\ntwo\nthree\nfour\nfive\n" {
+		t.Errorf("unexpected merged output:\n%s", got)
+	}
+
+	if _, err := RenderSlices("f.txt", src, []Slice{{Start: 9, End: 10}}); err == nil {
+		t.Error("expected error for a range past the end of the file")
+	}
+}
+
+func TestRenderSlices_GoSymbols(t *testing.T) {
+	got, err := RenderSlices("shapes.go", []byte(sliceSource), []Slice{{Symbol: "Circle.Area"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "--- lines 10-13 of 18 ---\n// Area returns") || strings.Contains(got, "func Scale") {
+		t.Errorf("unexpected method slice:\n%s", got)
+	}
+
+	got, _ = RenderSlices("shapes.go", []byte(sliceSource), []Slice{{Symbol: "Circle"}})
+	if !strings.Contains(got, "// Circle is a round shape\ntype Circle struct") {
+		t.Errorf("type slice should include its doc comment:\n%s", got)
+	}
+
+	if _, err := RenderSlices("shapes.go", []byte(sliceSource), []Slice{{Symbol: "Missing"}}); err == nil {
+		t.Error("expected error for unknown symbol")
+	}
+	if _, err := RenderSlices("notes.txt", []byte("text"), []Slice{{Symbol: "Scale"}}); err == nil {
+		t.Error("expected error for symbol selector on a non-Go file")
+	}
+}
+
+func TestResolveMentions(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "pkg"), 0755)
+	os.WriteFile(filepath.Join(root, "pkg", "shapes.go"), []byte(sliceSource), 0644)
+
+	task := "Fix @pkg/shapes.go#Scale, see also @pkg/shapes.go:1-3. Ping @alice about @missing.go"
+	files, slices := ResolveMentions(task, root)
+
+	path := filepath.Join(root, "pkg", "shapes.go")
+	if !reflect.DeepEqual(files, []string{path}) {
+		t.Errorf("unexpected files: %v", files)
+	}
+	want := []Slice{{Symbol: "Scale"}, {Start: 1, End: 3}}
+	if !reflect.DeepEqual(slices[path], want) {
+		t.Errorf("unexpected slices: %v", slices[path])
+	}
+}
+
+func TestGeneratePrompt_Slices(t *testing.T) {
+	tempDir := t.TempDir()
+	goFile := filepath.Join(tempDir, "shapes.go")
+	os.WriteFile(goFile, []byte(sliceSource), 0644)
+
+	slices := map[string][]Slice{goFile: {{Symbol: "Scale"}}}
+	config := GenerationConfig{
+		Template:      &models.Template{ID: "slices", Content: "{{FILE_STRUCTURE}}"},
+		SelectedFiles: []string{goFile},
+		Slices:        slices,
+		EmitManifest:  true,
+	}
+
+	result, err := NewPromptGenerator().GeneratePrompt(context.Background(), config)
+	if err != nil {
+		t.Fatalf("GeneratePrompt failed: %v", err)
+	}
+
+	if !strings.Contains(result.Content, `<file path="`+filepath.Base(goFile)+`" mode="slice">... lines 1-14 elided ...`) {
+		t.Errorf("expected sliced file block:\n%s", result.Content)
+	}
+	if strings.Contains(result.Content, "math.Pi") {
+		t.Error("content outside the slice should be elided")
+	}
+	if got := result.Manifest.Slices[goFile]; !reflect.DeepEqual(got, []string{"#Scale"}) {
+		t.Errorf("manifest should record slices, got %v", got)
+	}
+
+	regen, err := result.Manifest.RegenerationConfig(config.Template)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(regen.Slices, slices) {
+		t.Errorf("regeneration config should restore slices, got %v", regen.Slices)
+	}
+
+	config.Slices = map[string][]Slice{goFile: {{Symbol: "Missing"}}}
+	if _, err := NewPromptGenerator().GeneratePrompt(context.Background(), config); err == nil {
+		t.Error("expected unresolvable slice to fail generation")
+	}
+}
+
+func TestEstimatePromptSize_Slices(t *testing.T) {
+	tempDir := t.TempDir()
+	goFile := filepath.Join(tempDir, "shapes.go")
+	os.WriteFile(goFile, []byte(sliceSource), 0644)
+
+	slices := map[string][]Slice{goFile: {{Start: 15, End: 18}}}
+	estimate, err := NewSizeEstimator(nil).EstimatePromptSize(context.Background(), EstimationConfig{
+		Template:      &models.Template{Content: "{{FILE_STRUCTURE}}"},
+		SelectedFiles: []string{goFile},
+		Slices:        slices,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rendered, _ := RenderSlices(goFile, []byte(sliceSource), slices[goFile])
+	if estimate.FileContentSize != int64(len(rendered)) {
+		t.Errorf("expected content size %d (slice with header and markers), got %d", len(rendered), estimate.FileContentSize)
+	}
+}
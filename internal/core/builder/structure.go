@@ -1,11 +1,12 @@
 package builder
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"html"
 	"io"
-	"os"
 	"path/filepath"
 	"regexp"
 	"sort"
@@ -13,14 +14,18 @@ import (
 	"sync"
 
 	"github.com/diogopedro/shotgun/internal/core/scanner"
+	"github.com/diogopedro/shotgun/internal/models"
 )
 
 // TreeFormat defines formatting options for tree visualization
 type TreeFormat struct {
-	UseUnicode bool // Use Unicode or ASCII tree characters
-	ShowSizes  bool // Include file sizes in output
-	ShowBinary bool // Show binary file placeholders
-	IndentSize int  // Spaces per indentation level
+	UseUnicode   bool `json:"use_unicode"`             // Use Unicode or ASCII tree characters
+	ShowSizes    bool `json:"show_sizes,omitempty"`    // Include file sizes in output
+	ShowBinary   bool `json:"show_binary"`             // Show binary file placeholders; otherwise binaries are listed without content
+	IndentSize   int  `json:"indent_size"`             // Spaces per indentation level
+	CollapseDirs bool `json:"collapse_dirs,omitempty"` // Join single-child directory chains into one line (a/b/c)
+	MaxDepth     int  `json:"max_depth,omitempty"`     // Levels shown in the summary overview; 0 is unlimited
+	Summary      bool `json:"summary,omitempty"`       // Write a tree-only overview, then the file contents
 }
 
 // DefaultTreeFormat provides sensible defaults for tree formatting
@@ -33,12 +38,24 @@ var DefaultTreeFormat = TreeFormat{
 
 // FileStructureBuilder generates tree-structured file content representations
 type FileStructureBuilder struct {
-	maxFileSize    int64
-	maxConcurrency int
-	treeFormat     TreeFormat
-	binaryDetector *scanner.BinaryDetector
-	sensitiveRegex []*regexp.Regexp
-	mu             sync.RWMutex
+	maxFileSize       int64
+	maxConcurrency    int
+	readAhead         int
+	treeFormat        TreeFormat
+	binaryDetector    *scanner.BinaryDetector
+	sensitiveRegex    []*regexp.Regexp
+	delta             *DeltaContext
+	compaction        Compaction
+	renderModes       map[string]models.RenderMode
+	slices            map[string][]Slice
+	paths             PathOptions
+	anonymizer        *Anonymizer
+	annotations       Annotations
+	transformers      Transformers
+	truncation        Truncation
+	normalizeNewlines bool
+	source            *scanner.Source
+	mu                sync.RWMutex
 }
 
 // FileStructureBuilderInterface defines the contract for file structure assembly
@@ -55,10 +72,11 @@ type Option func(*FileStructureBuilder)
 // NewFileStructureBuilder creates a new FileStructureBuilder with functional options
 func NewFileStructureBuilder(opts ...Option) *FileStructureBuilder {
 	builder := &FileStructureBuilder{
-		maxFileSize:    10 * 1024 * 1024, // 10MB default
-		maxConcurrency: 10,               // 10 workers default
+		maxFileSize:    DefaultMaxFileSize,
+		maxConcurrency: 10, // 10 workers default
+		readAhead:      32, // 32 files buffered ahead of the writer
 		treeFormat:     DefaultTreeFormat,
-		binaryDetector: scanner.NewBinaryDetectorWithMaxSize(10 * 1024 * 1024), // 10MB for binary detection
+		binaryDetector: scanner.SharedBinaryDetector(), // Shares cached results with the scanner
 		sensitiveRegex: initSensitivePatterns(),
 	}
 
@@ -113,6 +131,29 @@ func WithMaxConcurrency(workers int) Option {
 	}
 }
 
+// WithReadAhead bounds how many file contents may be buffered ahead of the writer
+func WithReadAhead(files int) Option {
+	return func(b *FileStructureBuilder) {
+		b.readAhead = files
+	}
+}
+
+// WithBinaryDetector sets the detector deciding which files are embedded as binary placeholders
+func WithBinaryDetector(detector *scanner.BinaryDetector) Option {
+	return func(b *FileStructureBuilder) {
+		if detector != nil {
+			b.binaryDetector = detector
+		}
+	}
+}
+
+// WithSource reads files from an archive or git revision instead of the disk
+func WithSource(source *scanner.Source) Option {
+	return func(b *FileStructureBuilder) {
+		b.source = source
+	}
+}
+
 // WithTreeFormat sets the tree formatting options
 func WithTreeFormat(format TreeFormat) Option {
 	return func(b *FileStructureBuilder) {
@@ -120,6 +161,66 @@ func WithTreeFormat(format TreeFormat) Option {
 	}
 }
 
+// WithDelta renders files relative to a previous generation
+func WithDelta(delta *DeltaContext) Option {
+	return func(b *FileStructureBuilder) {
+		b.delta = delta
+	}
+}
+
+// WithCompaction applies content transforms to every file before it is embedded
+func WithCompaction(compaction Compaction) Option {
+	return func(b *FileStructureBuilder) {
+		b.compaction = compaction
+	}
+}
+
+// WithRenderModes sets per-file render modes (full, outline or path-only) keyed by path
+func WithRenderModes(modes map[string]models.RenderMode) Option {
+	return func(b *FileStructureBuilder) {
+		b.renderModes = modes
+	}
+}
+
+// WithSlices renders only the given line ranges or symbols of files, keyed by path
+func WithSlices(slices map[string][]Slice) Option {
+	return func(b *FileStructureBuilder) {
+		b.slices = slices
+	}
+}
+
+// With returns a copy of the builder with additional options applied,
+// leaving the original untouched for concurrent or later use
+func (b *FileStructureBuilder) With(opts ...Option) *FileStructureBuilder {
+	b.mu.RLock()
+	clone := &FileStructureBuilder{
+		maxFileSize:       b.maxFileSize,
+		maxConcurrency:    b.maxConcurrency,
+		readAhead:         b.readAhead,
+		treeFormat:        b.treeFormat,
+		binaryDetector:    b.binaryDetector,
+		sensitiveRegex:    b.sensitiveRegex,
+		delta:             b.delta,
+		compaction:        b.compaction,
+		renderModes:       b.renderModes,
+		slices:            b.slices,
+		paths:             b.paths,
+		anonymizer:        b.anonymizer,
+		annotations:       b.annotations,
+		transformers:      b.transformers,
+		truncation:        b.truncation,
+		normalizeNewlines: b.normalizeNewlines,
+		source:            b.source,
+	}
+	b.mu.RUnlock()
+
+	for _, opt := range opts {
+		opt(clone)
+	}
+
+	return clone
+}
+
 // SetMaxFileSize updates the maximum file size limit
 func (b *FileStructureBuilder) SetMaxFileSize(size int64) error {
 	if size <= 0 {
@@ -155,94 +256,152 @@ type fileContent struct {
 	path    string
 	content string
 	err     error
+	mode    models.RenderMode
+	sliced  bool   // Content holds only the selected slices
+	header  string // Header attributes for the <file> tag, when enabled
+	attrs   string // Attributes describing transformed or truncated content
+
+	size      int64 // Size of the file as stored
+	truncated bool  // Content was cut down to fit the size limit
+	compacted int64 // Bytes removed by compaction
 }
 
-// GenerateStructure creates a tree-structured representation with file contents
-func (b *FileStructureBuilder) GenerateStructure(ctx context.Context, files []string) (string, error) {
-	if len(files) == 0 {
-		return "", nil
-	}
-
-	// Build tree structure from file paths
-	tree := b.buildDirectoryTree(files)
-
-	// Pre-load all file contents
-	fileContents, err := b.readAllFilesConcurrently(ctx, files)
-	if err != nil {
-		return "", fmt.Errorf("failed to read file contents: %w", err)
+// attributes returns the <file> tag attributes after the path
+func (c fileContent) attributes() string {
+	attrs := c.header
+	switch {
+	case c.sliced:
+		attrs += ` mode="slice"`
+	case c.attrs != "":
+		attrs += c.attrs
+	case c.mode == models.RenderOutline:
+		attrs += ` mode="outline"`
 	}
+	return attrs
+}
 
-	// Generate tree visualization with content
+// GenerateStructure creates a tree-structured representation with file contents
+func (b *FileStructureBuilder) GenerateStructure(ctx context.Context, files []string) (string, error) {
 	var result strings.Builder
-	err = b.generateTreeWithContentMap(ctx, tree, "", true, fileContents, &result)
-	if err != nil {
-		return "", fmt.Errorf("failed to generate tree structure: %w", err)
+	if err := b.WriteStructure(ctx, &result, files); err != nil {
+		return "", err
 	}
-
 	return result.String(), nil
 }
 
-// readAllFilesConcurrently reads all files using a worker pool
-func (b *FileStructureBuilder) readAllFilesConcurrently(ctx context.Context, files []string) (map[string]fileContent, error) {
+// WriteStructure streams the tree-structured representation to w in tree order.
+// Files are read concurrently, but at most readAhead contents are held in memory at once.
+func (b *FileStructureBuilder) WriteStructure(ctx context.Context, w io.Writer, files []string) error {
+	if len(files) == 0 {
+		return nil
+	}
+
 	// Check context first
 	if ctx.Err() != nil {
-		return nil, ctx.Err()
+		return fmt.Errorf("failed to read file contents: %w", ctx.Err())
 	}
 
-	// Simplified version: read files sequentially instead of concurrently
-	fileContents := make(map[string]fileContent)
+	// Build tree structure from the paths as they will be displayed
+	b.mu.RLock()
+	style := newTreeStyle(b.treeFormat, b.paths, files)
+	style.source = b.source
+	b.mu.RUnlock()
+	tree := b.buildTree(files, style.filePath)
 
-	for _, filePath := range files {
-		// Check context before each file
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		default:
-		}
+	// Start reading files in the same order the tree will be written
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	contents := b.streamFileContents(streamCtx, b.orderedFilePaths(tree))
 
-		// Read file content using the existing readFileContent method
-		content, err := b.readFileContent(ctx, filePath)
+	// Generate tree visualization with content
+	out := bufio.NewWriterSize(w, 64*1024)
+	var sink io.Writer = out
+	if fb, ok := w.(FileBoundaryWriter); ok {
+		sink = &bufferedBoundary{Writer: out, target: fb}
+	}
+	if style.format.Summary {
+		err := b.writeSummary(ctx, tree, style, contents, sink)
 		if err != nil {
-			// Skip files that can't be read instead of failing completely
-			fileContents[filePath] = fileContent{
-				path:    filePath,
-				content: "",
-				err:     err,
-			}
-			continue
-		}
-
-		fileContents[filePath] = fileContent{
-			path:    filePath,
-			content: content,
-			err:     nil,
+			return fmt.Errorf("failed to generate tree structure: %w", err)
 		}
+	} else if err := b.generateTreeWithContent(ctx, tree, "", true, style, contents, sink); err != nil {
+		return fmt.Errorf("failed to generate tree structure: %w", err)
 	}
 
-	return fileContents, nil
+	return out.Flush()
 }
 
-// fileReader is a worker that reads files from the channel
-func (b *FileStructureBuilder) fileReader(ctx context.Context, paths <-chan string, results chan<- fileContent, wg *sync.WaitGroup) {
-	defer wg.Done()
+// contentStream delivers file contents in submission order with a bounded read-ahead window
+type contentStream struct {
+	pending <-chan chan fileContent
+	window  chan struct{}
+}
 
-	for {
-		select {
-		case path, ok := <-paths:
-			if !ok {
-				return
-			}
+// streamFileContents starts reading paths concurrently and returns an ordered stream of results
+func (b *FileStructureBuilder) streamFileContents(ctx context.Context, paths []string) *contentStream {
+	b.mu.RLock()
+	readAhead := b.readAhead
+	workers := b.maxConcurrency
+	b.mu.RUnlock()
+
+	if readAhead <= 0 {
+		readAhead = 1
+	}
+	if workers <= 0 {
+		workers = 1
+	}
 
-			content, err := b.readFileContent(ctx, path)
+	pending := make(chan chan fileContent, readAhead)
+	window := make(chan struct{}, readAhead)
+	readers := make(chan struct{}, workers)
+
+	go func() {
+		defer close(pending)
+
+		for _, path := range paths {
+			// Wait for a free slot in the read-ahead window
 			select {
-			case results <- fileContent{path: path, content: content, err: err}:
+			case window <- struct{}{}:
 			case <-ctx.Done():
 				return
 			}
 
+			slot := make(chan fileContent, 1)
+			pending <- slot
+
+			go func(path string) {
+				readers <- struct{}{}
+				content := b.loadFile(ctx, path)
+				<-readers
+				slot <- content
+			}(path)
+		}
+	}()
+
+	return &contentStream{pending: pending, window: window}
+}
+
+// next returns the next file content in order, releasing its read-ahead slot
+func (s *contentStream) next(ctx context.Context) (fileContent, error) {
+	select {
+	case slot, ok := <-s.pending:
+		if !ok {
+			if ctx.Err() != nil {
+				return fileContent{}, ctx.Err()
+			}
+			return fileContent{}, fmt.Errorf("file content stream ended early")
+		}
+
+		select {
+		case content := <-slot:
+			<-s.window
+			return content, nil
 		case <-ctx.Done():
-			return
+			return fileContent{}, ctx.Err()
 		}
+
+	case <-ctx.Done():
+		return fileContent{}, ctx.Err()
 	}
 }
 
@@ -257,6 +416,11 @@ type DirectoryNode struct {
 
 // buildDirectoryTree constructs a tree structure from file paths
 func (b *FileStructureBuilder) buildDirectoryTree(files []string) *DirectoryNode {
+	return b.buildTree(files, func(path string) string { return path })
+}
+
+// buildTree constructs a tree shaped by each file's display path; nodes keep the original path
+func (b *FileStructureBuilder) buildTree(files []string, display func(string) string) *DirectoryNode {
 	root := &DirectoryNode{
 		Name:        "",
 		Path:        "",
@@ -266,7 +430,7 @@ func (b *FileStructureBuilder) buildDirectoryTree(files []string) *DirectoryNode
 
 	for _, file := range files {
 		// Normalize path to use forward slashes and store original path
-		normalizedFile := filepath.ToSlash(file)
+		normalizedFile := filepath.ToSlash(display(file))
 		parts := strings.Split(normalizedFile, "/")
 		current := root
 
@@ -298,26 +462,42 @@ func (b *FileStructureBuilder) buildDirectoryTree(files []string) *DirectoryNode
 	return root
 }
 
-// generateTreeWithContentMap recursively generates tree visualization with pre-loaded file contents
-func (b *FileStructureBuilder) generateTreeWithContentMap(ctx context.Context, node *DirectoryNode, prefix string, isLast bool, fileContents map[string]fileContent, result *strings.Builder) error {
-	// Skip empty root node
-	if node.Name == "" {
-		// Sort children for consistent output
-		children := make([]*DirectoryNode, 0, len(node.Children))
-		for _, child := range node.Children {
-			children = append(children, child)
+// sortedChildren returns a node's children with directories first, then files, alphabetically
+func sortedChildren(node *DirectoryNode) []*DirectoryNode {
+	children := make([]*DirectoryNode, 0, len(node.Children))
+	for _, child := range node.Children {
+		children = append(children, child)
+	}
+	sort.Slice(children, func(i, j int) bool {
+		// Directories first, then files
+		if children[i].IsDirectory != children[j].IsDirectory {
+			return children[i].IsDirectory
 		}
-		sort.Slice(children, func(i, j int) bool {
-			// Directories first, then files
-			if children[i].IsDirectory != children[j].IsDirectory {
-				return children[i].IsDirectory
-			}
-			return children[i].Name < children[j].Name
-		})
+		return children[i].Name < children[j].Name
+	})
+	return children
+}
 
+// orderedFilePaths returns file paths in the order they appear in the rendered tree
+func (b *FileStructureBuilder) orderedFilePaths(node *DirectoryNode) []string {
+	var paths []string
+	if node.IsFile {
+		paths = append(paths, node.Path)
+	}
+	for _, child := range sortedChildren(node) {
+		paths = append(paths, b.orderedFilePaths(child)...)
+	}
+	return paths
+}
+
+// generateTreeWithContent recursively writes the tree visualization, pulling file contents from the stream
+func (b *FileStructureBuilder) generateTreeWithContent(ctx context.Context, node *DirectoryNode, prefix string, isLast bool, style *treeStyle, contents *contentStream, result io.Writer) error {
+	// Skip empty root node
+	if node.Name == "" {
+		children := sortedChildren(node)
 		for i, child := range children {
 			isChildLast := i == len(children)-1
-			if err := b.generateTreeWithContentMap(ctx, child, "", isChildLast, fileContents, result); err != nil {
+			if err := b.generateTreeWithContent(ctx, child, "", isChildLast, style, contents, result); err != nil {
 				return err
 			}
 		}
@@ -325,53 +505,39 @@ func (b *FileStructureBuilder) generateTreeWithContentMap(ctx context.Context, n
 	}
 
 	// Generate tree characters
-	treeChar := "├── "
+	treeChar := style.branch
 	if isLast {
-		treeChar = "└── "
+		treeChar = style.last
 	}
 
-	// Write node line
-	result.WriteString(prefix + treeChar + node.Name + "\n")
+	// Write node line; collapsed directory chains continue from their last directory
+	label, node := style.label(node)
+	if _, err := io.WriteString(result, prefix+treeChar+label+"\n"); err != nil {
+		return err
+	}
 
-	// Handle files - add content from pre-loaded map
+	// Handle files - add content from the ordered stream
 	if node.IsFile {
-		if fileContent, exists := fileContents[node.Path]; exists {
-			if fileContent.err != nil {
-				result.WriteString(fmt.Sprintf("<file path=\"%s\">ERROR: %v</file>\n", node.Path, fileContent.err))
-			} else {
-				result.WriteString(fmt.Sprintf("<file path=\"%s\">%s</file>\n", node.Path, fileContent.content))
-			}
-		} else {
-			result.WriteString(fmt.Sprintf("<file path=\"%s\">ERROR: File not found in content map</file>\n", node.Path))
+		if err := b.writeFile(ctx, node.Path, style, contents, result); err != nil {
+			return err
 		}
 	}
 
 	// Handle directories - process children
 	if node.IsDirectory && len(node.Children) > 0 {
-		// Sort children for consistent output
-		children := make([]*DirectoryNode, 0, len(node.Children))
-		for _, child := range node.Children {
-			children = append(children, child)
-		}
-		sort.Slice(children, func(i, j int) bool {
-			// Directories first, then files
-			if children[i].IsDirectory != children[j].IsDirectory {
-				return children[i].IsDirectory
-			}
-			return children[i].Name < children[j].Name
-		})
+		children := sortedChildren(node)
 
 		// Generate prefix for children
 		childPrefix := prefix
 		if isLast {
-			childPrefix += "    "
+			childPrefix += style.blank
 		} else {
-			childPrefix += "│   "
+			childPrefix += style.vertical
 		}
 
 		for i, child := range children {
 			isChildLast := i == len(children)-1
-			if err := b.generateTreeWithContentMap(ctx, child, childPrefix, isChildLast, fileContents, result); err != nil {
+			if err := b.generateTreeWithContent(ctx, child, childPrefix, isChildLast, style, contents, result); err != nil {
 				return err
 			}
 		}
@@ -380,53 +546,158 @@ func (b *FileStructureBuilder) generateTreeWithContentMap(ctx context.Context, n
 	return nil
 }
 
+// writeFile renders the next file from the stream as a <file> block
+func (b *FileStructureBuilder) writeFile(ctx context.Context, path string, style *treeStyle, contents *contentStream, result io.Writer) error {
+	fileContent, err := contents.next(ctx)
+	if err != nil {
+		return err
+	}
+
+	display := style.filePath(path)
+	var rendered string
+	switch {
+	case fileContent.mode == models.RenderPathOnly && fileContent.path == path:
+		// Path-only files appear in the tree without a content block
+	case fileContent.path != path:
+		rendered = fmt.Sprintf("<file path=\"%s\">ERROR: File content out of order</file>\n", display)
+	case fileContent.err != nil:
+		// Errors name the file by its local path; keep them as anonymous as the path attribute
+		message := b.scrub(strings.ReplaceAll(fileContent.err.Error(), path, display))
+		rendered = fmt.Sprintf("<file path=\"%s\">ERROR: %s</file>\n", display, message)
+	default:
+		attrs, content := b.applyDelta(path, display, fileContent, fileContent.attributes())
+		rendered = fmt.Sprintf("<file path=\"%s\"%s>%s</file>\n", display, attrs, content)
+	}
+
+	if _, err := io.WriteString(result, rendered); err != nil {
+		return err
+	}
+
+	// Let chunking consumers split after each complete file
+	if fb, ok := result.(FileBoundaryWriter); ok {
+		if err := fb.FileBoundary(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // readFileContent reads file content with size limits and binary detection
 func (b *FileStructureBuilder) readFileContent(ctx context.Context, filePath string) (string, error) {
+	content := b.readContent(ctx, filePath)
+	return content.content, content.err
+}
+
+// readContent reads a file like readFileContent, along with <file> attributes describing
+// transformed or truncated content. Such content is neither compacted nor line-numbered,
+// as it no longer matches the file's lines.
+func (b *FileStructureBuilder) readContent(ctx context.Context, filePath string) fileContent {
+	result := fileContent{path: filePath, mode: models.RenderFull}
 	select {
 	case <-ctx.Done():
-		return "", ctx.Err()
+		result.err = ctx.Err()
+		return result
 	default:
 	}
 
 	// Get file info
-	info, err := os.Stat(filePath)
+	info, err := b.source.Stat(filePath)
 	if err != nil {
-		return "", fmt.Errorf("failed to stat file: %w", err)
+		result.err = fmt.Errorf("failed to stat file: %w", err)
+		return result
 	}
+	result.size = info.Size()
 
 	// Check file size limit
 	b.mu.RLock()
 	maxSize := b.maxFileSize
 	b.mu.RUnlock()
 
-	if info.Size() > maxSize {
-		return fmt.Sprintf("File too large (%d bytes, limit %d bytes)", info.Size(), maxSize), nil
+	// Sensitive files are never truncated into the prompt
+	if info.Size() > maxSize && (!b.truncation.Enabled() || b.isSensitiveFile(filePath)) {
+		result.content = tooLargePlaceholder(info.Size(), maxSize)
+		return result
 	}
 
 	// Check if potentially sensitive file
 	if b.isSensitiveFile(filePath) {
-		return fmt.Sprintf("⚠️ Potentially sensitive file detected (%d bytes) - Use caution with file contents", info.Size()), nil
+		result.content = fmt.Sprintf("⚠️ Potentially sensitive file detected (%d bytes) - Use caution with file contents", info.Size())
+		return result
 	}
 
-	// Check if binary file
-	if b.binaryDetector.IsBinary(filePath) {
-		return fmt.Sprintf("Binary file (%d bytes)", info.Size()), nil
+	// Check if binary file or a Git LFS pointer without its object
+	if detection := b.binaryDetector.DetectIn(b.source, filePath); detection.LFSPointer {
+		result.content = lfsPlaceholder(detection.LFSSize)
+		return result
+	} else if detection.Binary {
+		result.content = binaryPlaceholder(info.Size())
+		return result
+	}
+
+	if info.Size() > maxSize {
+		text, err := b.truncation.truncateFile(b.source, filePath, maxSize)
+		if errors.Is(err, errBinaryContent) {
+			result.content = binaryPlaceholder(info.Size())
+			return result
+		}
+		if err != nil {
+			result.err = err
+			return result
+		}
+		if b.normalizeNewlines {
+			text = scanner.NormalizeNewlines(text)
+		}
+		result.content = html.EscapeString(b.scrub(text))
+		result.attrs = fmt.Sprintf(` truncated="%s"`, b.truncation.Strategy)
+		result.truncated = true
+		return result
 	}
 
 	// Read file content
-	file, err := os.Open(filePath)
+	file, err := b.source.Open(filePath)
 	if err != nil {
-		return "", fmt.Errorf("failed to open file: %w", err)
+		result.err = fmt.Errorf("failed to open file: %w", err)
+		return result
 	}
 	defer file.Close()
 
 	content, err := io.ReadAll(file)
 	if err != nil {
-		return "", fmt.Errorf("failed to read file: %w", err)
+		result.err = fmt.Errorf("failed to read file: %w", err)
+		return result
+	}
+
+	text := b.decode(content)
+	if transformed, name, ok := b.transformers.Apply(filePath, []byte(text)); ok {
+		result.content = html.EscapeString(b.scrub(transformed))
+		result.attrs = fmt.Sprintf(` transform="%s"`, name)
+		return result
+	}
+
+	if b.compaction.Enabled() {
+		compacted := b.compaction.Apply(filePath, text)
+		result.compacted = int64(len(text) - len(compacted))
+		text = compacted
+	}
+	text = b.scrub(text)
+	if b.annotations.LineNumbers {
+		text = b.annotations.NumberLines(text)
 	}
 
 	// Escape XML special characters for proper XML wrapping
-	return html.EscapeString(string(content)), nil
+	result.content = html.EscapeString(text)
+	return result
+}
+
+// IsSensitiveFile reports whether a file matches the sensitive patterns and will be
+// embedded as a warning instead of its content
+func (b *FileStructureBuilder) IsSensitiveFile(filePath string) bool {
+	return b.isSensitiveFile(filePath)
+}
+
+// IsBinaryFile reports whether a file will be embedded as a binary placeholder
+func (b *FileStructureBuilder) IsBinaryFile(filePath string) bool {
+	return b.binaryDetector.IsBinaryIn(b.source, filePath)
 }
 
 // isSensitiveFile checks if a file path matches sensitive file patterns
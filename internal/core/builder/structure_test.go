@@ -7,6 +7,9 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/diogopedro/shotgun/internal/core/scanner"
+	"github.com/diogopedro/shotgun/internal/models"
 )
 
 func TestNewFileStructureBuilder(t *testing.T) {
@@ -247,8 +250,8 @@ func TestFileStructureBuilder_GenerateStructure_WithFiles(t *testing.T) {
 		t.Error("Expected non-empty result")
 	}
 
-	// Check for tree structure
-	if !strings.Contains(result, "├──") && !strings.Contains(result, "└──") {
+	// Check for tree structure, drawn with ASCII by default
+	if !strings.Contains(result, "|-- ") && !strings.Contains(result, "`-- ") {
 		t.Error("Expected tree characters in result")
 	}
 
@@ -684,3 +687,158 @@ func BenchmarkFileStructureBuilder_LargeFileSet(b *testing.B) {
 		}
 	}
 }
+
+func TestFileStructureBuilder_WriteStructure_MatchesGenerateStructure(t *testing.T) {
+	tempDir := t.TempDir()
+	var files []string
+	for i := 0; i < 40; i++ {
+		dir := filepath.Join(tempDir, fmt.Sprintf("pkg%d", i%4))
+		os.MkdirAll(dir, 0755)
+		file := filepath.Join(dir, fmt.Sprintf("file%02d.go", i))
+		os.WriteFile(file, []byte(fmt.Sprintf("package pkg%d // %d\n", i%4, i)), 0644)
+		files = append(files, file)
+	}
+
+	// A read-ahead window of one forces strictly sequential hand-off
+	builder := NewFileStructureBuilder(WithReadAhead(1), WithMaxConcurrency(1))
+
+	var streamed strings.Builder
+	if err := builder.WriteStructure(context.Background(), &streamed, files); err != nil {
+		t.Fatalf("WriteStructure failed: %v", err)
+	}
+
+	generated, err := NewFileStructureBuilder().GenerateStructure(context.Background(), files)
+	if err != nil {
+		t.Fatalf("GenerateStructure failed: %v", err)
+	}
+
+	if streamed.String() != generated {
+		t.Error("Streamed output should match buffered output regardless of read-ahead")
+	}
+
+	// Every file must appear in tree order, under its root-relative path
+	lastIndex := -1
+	display := PathOptions{}.DisplayPaths(files)
+	for _, path := range builder.orderedFilePaths(builder.buildDirectoryTree(files)) {
+		idx := strings.Index(generated, fmt.Sprintf("<file path=\"%s\">", display[path]))
+		if idx <= lastIndex {
+			t.Fatalf("File %s out of order", path)
+		}
+		lastIndex = idx
+	}
+}
+
+func TestFileStructureBuilder_WriteStructure_WriterError(t *testing.T) {
+	tempDir, cleanup := setupTestFiles(t)
+	defer cleanup()
+
+	builder := NewFileStructureBuilder()
+	err := builder.WriteStructure(context.Background(), failingWriter{}, []string{filepath.Join(tempDir, "simple.txt")})
+	if err == nil {
+		t.Error("Expected writer error to be returned")
+	}
+}
+
+// failingWriter rejects every write
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, fmt.Errorf("disk full")
+}
+
+func TestFileStructureBuilder_TreeFormat(t *testing.T) {
+	root := t.TempDir()
+	files := []string{
+		filepath.Join(root, "cmd", "app", "main.go"),
+		filepath.Join(root, "pkg", "a.go"),
+		filepath.Join(root, "pkg", "b.go"),
+		filepath.Join(root, "logo.png"),
+	}
+	for _, file := range files[:3] {
+		os.MkdirAll(filepath.Dir(file), 0755)
+		os.WriteFile(file, []byte("package x\n"), 0644)
+	}
+	os.WriteFile(files[3], []byte{0x89, 'P', 'N', 'G', 0, 0, 0, 0}, 0644)
+
+	generate := func(format TreeFormat) string {
+		t.Helper()
+		output, err := NewFileStructureBuilder(WithTreeFormat(format)).GenerateStructure(context.Background(), files)
+		if err != nil {
+			t.Fatalf("GenerateStructure failed: %v", err)
+		}
+		return output
+	}
+
+	ascii := generate(TreeFormat{ShowBinary: true, IndentSize: 4})
+	if strings.ContainsAny(ascii, "├└│─") || !strings.Contains(ascii, "|-- ") || !strings.Contains(ascii, "`-- ") {
+		t.Errorf("Expected ASCII tree characters, got:\n%s", ascii)
+	}
+	if !strings.Contains(ascii, "Binary file") {
+		t.Error("Expected a binary placeholder when ShowBinary is set")
+	}
+
+	output := generate(TreeFormat{
+		UseUnicode:   true,
+		ShowSizes:    true,
+		IndentSize:   2,
+		CollapseDirs: true,
+	})
+	if !strings.Contains(output, "├ cmd/app\n") {
+		t.Errorf("Expected a collapsed cmd/app chain with 2-column connectors, got:\n%s", output)
+	}
+	if !strings.Contains(output, "main.go (10 B)") {
+		t.Errorf("Expected file sizes in tree lines, got:\n%s", output)
+	}
+	if !strings.Contains(output, `<file path="`+filepath.Join("cmd", "app", "main.go")+`">`) {
+		t.Errorf("Expected relative file paths, got:\n%s", output)
+	}
+	if strings.Contains(output, "Binary file") || !strings.Contains(output, "logo.png") {
+		t.Errorf("Expected the binary file listed without a placeholder, got:\n%s", output)
+	}
+
+	summary := generate(TreeFormat{UseUnicode: true, Summary: true, MaxDepth: 1, CollapseDirs: true, ShowBinary: true})
+	overview, contents, found := strings.Cut(summary, "\n\n")
+	if !found || strings.Contains(overview, "<file") || !strings.Contains(overview, "pkg (2 files)") {
+		t.Errorf("Expected a depth-limited overview before contents, got:\n%s", summary)
+	}
+	if strings.Count(contents, "<file path=") != 4 || strings.Contains(contents, "├") {
+		t.Errorf("Expected only file blocks after the overview, got:\n%s", contents)
+	}
+}
+
+func TestGeneratePrompt_BinaryDetection(t *testing.T) {
+	root := t.TempDir()
+	pointer := filepath.Join(root, "weights.bin.txt")
+	fixture := filepath.Join(root, "fixture.golden")
+	os.WriteFile(pointer, []byte("version https://git-lfs.github.com/spec/v1\noid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393\nsize 2048\n"), 0644)
+	os.WriteFile(fixture, []byte("expected output\n"), 0644)
+
+	template := &models.Template{ID: "binary", Content: "{{FILE_STRUCTURE}}"}
+	config := GenerationConfig{
+		Template:        template,
+		SelectedFiles:   []string{fixture, pointer},
+		BinaryDetection: &scanner.BinaryDetectorConfig{BinaryExtensions: []string{".golden"}},
+		EmitManifest:    true,
+	}
+	result, err := NewPromptGenerator().GeneratePrompt(context.Background(), config)
+	if err != nil {
+		t.Fatalf("GeneratePrompt failed: %v", err)
+	}
+
+	for _, want := range []string{
+		"Git LFS pointer (2048 byte object not checked out)",
+		"<file path=\"fixture.golden\">Binary file (16 bytes)</file>",
+	} {
+		if !strings.Contains(result.Content, want) {
+			t.Errorf("expected %q in:\n%s", want, result.Content)
+		}
+	}
+
+	regenerated, err := result.Manifest.RegenerationConfig(template)
+	if err != nil {
+		t.Fatalf("RegenerationConfig failed: %v", err)
+	}
+	if regenerated.BinaryDetection == nil || len(regenerated.BinaryDetection.BinaryExtensions) != 1 {
+		t.Errorf("manifest should restore binary detection, got %+v", regenerated.BinaryDetection)
+	}
+}
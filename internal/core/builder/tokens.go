@@ -0,0 +1,12 @@
+package builder
+
+// BytesPerToken is the average number of bytes per LLM token used for estimates
+const BytesPerToken = 4
+
+// EstimateTokens estimates the LLM token count for a byte size
+func EstimateTokens(size int64) int64 {
+	if size <= 0 {
+		return 0
+	}
+	return (size + BytesPerToken - 1) / BytesPerToken
+}
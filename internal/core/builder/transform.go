@@ -0,0 +1,390 @@
+package builder
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Transformer names accepted on the command line and recorded in manifests
+const (
+	TransformNotebook = "notebook"
+	TransformSample   = "sample"
+	TransformLockfile = "lockfile"
+	TransformMinified = "minified"
+)
+
+// Sampling and minified-detection limits
+const (
+	sampleRows         = 20   // Data rows kept from CSV, TSV and JSONL files
+	minifiedMinSize    = 1024 // Smaller files are never treated as minified
+	minifiedLongLine   = 1000 // A line at least this long marks a file as minified...
+	minifiedAvgLineLen = 200  // ...when the average line is also at least this long
+)
+
+// Transformer replaces the content of files that are text but useless at full size
+type Transformer interface {
+	// Name identifies the transformer in prompts and manifests
+	Name() string
+	// Match reports whether the transformer applies, by extension or content sniffing
+	Match(path string, content []byte) bool
+	// Transform renders the replacement content
+	Transform(path string, content []byte) (string, error)
+}
+
+// Transformers is an ordered pipeline; the first matching transformer handles a file
+type Transformers []Transformer
+
+// DefaultTransformers returns every built-in transformer in pipeline order
+func DefaultTransformers() Transformers {
+	return Transformers{notebookTransformer{}, sampleTransformer{}, lockfileTransformer{}, minifiedTransformer{}}
+}
+
+// ParseTransformers builds a pipeline from transformer names ("all" selects every transformer)
+func ParseTransformers(names []string) (Transformers, error) {
+	builtin := make(map[string]Transformer)
+	for _, t := range DefaultTransformers() {
+		builtin[t.Name()] = t
+	}
+
+	selected := make(map[string]bool)
+	for _, name := range names {
+		name = strings.TrimSpace(strings.ToLower(name))
+		switch {
+		case name == "all":
+			for n := range builtin {
+				selected[n] = true
+			}
+		case name == "" || name == "none":
+		case builtin[name] != nil:
+			selected[name] = true
+		default:
+			return nil, fmt.Errorf("unknown transformer %q", name)
+		}
+	}
+
+	// Keep pipeline order regardless of the order names were given in
+	var t Transformers
+	for _, transformer := range DefaultTransformers() {
+		if selected[transformer.Name()] {
+			t = append(t, transformer)
+		}
+	}
+	return t, nil
+}
+
+// Names returns the transformer names in pipeline order
+func (t Transformers) Names() []string {
+	var names []string
+	for _, transformer := range t {
+		names = append(names, transformer.Name())
+	}
+	return names
+}
+
+// Apply runs the first matching transformer. It returns the transformed content and the
+// transformer's name, or ok=false when no transformer applies or the matching one fails.
+func (t Transformers) Apply(path string, content []byte) (text, name string, ok bool) {
+	for _, transformer := range t {
+		if !transformer.Match(path, content) {
+			continue
+		}
+		text, err := transformer.Transform(path, content)
+		if err != nil {
+			// Malformed input is embedded as-is rather than hidden
+			return "", "", false
+		}
+		return text, transformer.Name(), true
+	}
+	return "", "", false
+}
+
+// WithTransformers replaces useless-at-full-size file contents using the given pipeline
+func WithTransformers(transformers Transformers) Option {
+	return func(b *FileStructureBuilder) {
+		b.transformers = transformers
+	}
+}
+
+// notebookTransformer renders Jupyter notebooks as their cell sources without outputs
+type notebookTransformer struct{}
+
+func (notebookTransformer) Name() string { return TransformNotebook }
+
+func (notebookTransformer) Match(path string, _ []byte) bool {
+	return strings.EqualFold(filepath.Ext(path), ".ipynb")
+}
+
+func (notebookTransformer) Transform(_ string, content []byte) (string, error) {
+	var notebook struct {
+		Cells []struct {
+			CellType string          `json:"cell_type"`
+			Source   json.RawMessage `json:"source"`
+		} `json:"cells"`
+		Metadata struct {
+			Kernelspec struct {
+				Language string `json:"language"`
+			} `json:"kernelspec"`
+			LanguageInfo struct {
+				Name string `json:"name"`
+			} `json:"language_info"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(content, &notebook); err != nil {
+		return "", fmt.Errorf("invalid notebook: %w", err)
+	}
+
+	language := notebook.Metadata.LanguageInfo.Name
+	if language == "" {
+		language = notebook.Metadata.Kernelspec.Language
+	}
+	if language == "" {
+		language = "unknown"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Jupyter notebook (%s): %d cells, outputs omitted\n", language, len(notebook.Cells))
+	for i, cell := range notebook.Cells {
+		source, err := notebookSource(cell.Source)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "\n# %%%% [%s] %d\n%s", cell.CellType, i+1, source)
+		if !strings.HasSuffix(source, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	return b.String(), nil
+}
+
+// notebookSource decodes a cell source, stored either as one string or as a list of lines
+func notebookSource(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 {
+		return "", nil
+	}
+	var lines []string
+	if err := json.Unmarshal(raw, &lines); err == nil {
+		return strings.Join(lines, ""), nil
+	}
+	var source string
+	if err := json.Unmarshal(raw, &source); err != nil {
+		return "", fmt.Errorf("invalid cell source: %w", err)
+	}
+	return source, nil
+}
+
+// sampleTransformer keeps the header and first rows of CSV, TSV and JSONL data files
+type sampleTransformer struct{}
+
+func (sampleTransformer) Name() string { return TransformSample }
+
+func (sampleTransformer) Match(path string, content []byte) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv", ".tsv", ".jsonl", ".ndjson":
+		// Small files are kept whole
+		return bytes.Count(content, []byte{'\n'}) > sampleRows+1
+	}
+	return false
+}
+
+func (sampleTransformer) Transform(path string, content []byte) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return sampleRecords(content, ',')
+	case ".tsv":
+		return sampleRecords(content, '\t')
+	default:
+		return sampleLines(content), nil
+	}
+}
+
+// sampleRecords keeps the header and first sampleRows records of delimited data. Records
+// are parsed so quoted fields spanning lines are never cut in half.
+func sampleRecords(content []byte, delimiter rune) (string, error) {
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	records, cut := 0, int64(0)
+	for {
+		_, err := reader.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return "", fmt.Errorf("invalid %c-delimited data: %w", delimiter, err)
+		}
+		records++
+		if records == sampleRows+1 { // Header plus sampleRows rows
+			cut = reader.InputOffset()
+		}
+	}
+
+	rows := records - 1
+	if rows <= sampleRows {
+		return string(content), nil
+	}
+	return sampleSummary(string(content[:cut]), rows, rows-sampleRows), nil
+}
+
+// sampleLines keeps the first sampleRows non-empty lines of line-delimited data
+func sampleLines(content []byte) string {
+	lines := strings.SplitAfter(string(content), "\n")
+	var kept strings.Builder
+	rows := 0
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		rows++
+		if rows <= sampleRows {
+			kept.WriteString(line)
+		}
+	}
+	if rows <= sampleRows {
+		return string(content)
+	}
+	return sampleSummary(kept.String(), rows, rows-sampleRows)
+}
+
+// sampleSummary appends the row counts to sampled content
+func sampleSummary(sample string, rows, omitted int) string {
+	if !strings.HasSuffix(sample, "\n") {
+		sample += "\n"
+	}
+	return sample + fmt.Sprintf("... %d more rows omitted (%d rows total)\n", omitted, rows)
+}
+
+// lockfileTransformer summarizes dependency lockfiles into name/version lists
+type lockfileTransformer struct{}
+
+func (lockfileTransformer) Name() string { return TransformLockfile }
+
+func (lockfileTransformer) Match(path string, _ []byte) bool {
+	switch filepath.Base(path) {
+	case "go.sum", "package-lock.json", "npm-shrinkwrap.json":
+		return true
+	}
+	return false
+}
+
+func (lockfileTransformer) Transform(path string, content []byte) (string, error) {
+	var deps []string
+	if filepath.Base(path) == "go.sum" {
+		deps = goSumModules(content)
+	} else {
+		var err error
+		if deps, err = npmLockPackages(content); err != nil {
+			return "", err
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s summarized: %d dependencies (hashes and metadata omitted)\n", filepath.Base(path), len(deps))
+	for _, dep := range deps {
+		b.WriteString(dep + "\n")
+	}
+	return b.String(), nil
+}
+
+// goSumModules lists each module version in a go.sum once
+func goSumModules(content []byte) []string {
+	seen := make(map[string]bool)
+	var modules []string
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		module := fields[0] + " " + strings.TrimSuffix(fields[1], "/go.mod")
+		if !seen[module] {
+			seen[module] = true
+			modules = append(modules, module)
+		}
+	}
+	sort.Strings(modules)
+	return modules
+}
+
+// npmLockPackages lists the packages of an npm lockfile, from "packages" (lockfile v2/v3)
+// or "dependencies" (v1)
+func npmLockPackages(content []byte) ([]string, error) {
+	type dependency struct {
+		Version      string                     `json:"version"`
+		Dependencies map[string]json.RawMessage `json:"dependencies"`
+	}
+	var lock struct {
+		Packages     map[string]dependency `json:"packages"`
+		Dependencies map[string]dependency `json:"dependencies"`
+	}
+	if err := json.Unmarshal(content, &lock); err != nil {
+		return nil, fmt.Errorf("invalid npm lockfile: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var packages []string
+	add := func(name, version string) {
+		dep := strings.TrimSpace(name + " " + version)
+		if name != "" && !seen[dep] {
+			seen[dep] = true
+			packages = append(packages, dep)
+		}
+	}
+
+	if len(lock.Packages) > 0 {
+		for key, pkg := range lock.Packages {
+			// Keys are install paths such as node_modules/a/node_modules/@scope/b
+			if i := strings.LastIndex(key, "node_modules/"); i >= 0 {
+				add(key[i+len("node_modules/"):], pkg.Version)
+			}
+		}
+	} else {
+		for name, dep := range lock.Dependencies {
+			add(name, dep.Version)
+		}
+	}
+	sort.Strings(packages)
+	return packages, nil
+}
+
+// minifiedTransformer replaces minified or very-long-line files with a stub
+type minifiedTransformer struct{}
+
+func (minifiedTransformer) Name() string { return TransformMinified }
+
+func (minifiedTransformer) Match(path string, content []byte) bool {
+	if len(content) < minifiedMinSize {
+		return false
+	}
+	if strings.Contains(strings.ToLower(filepath.Base(path)), ".min.") {
+		return true
+	}
+	lines, longest := lineStats(content)
+	return longest >= minifiedLongLine && len(content)/lines >= minifiedAvgLineLen
+}
+
+func (minifiedTransformer) Transform(_ string, content []byte) (string, error) {
+	lines, longest := lineStats(content)
+	return fmt.Sprintf("Minified file (%d bytes, %d lines, longest line %d chars) - content omitted", len(content), lines, longest), nil
+}
+
+// lineStats returns the number of lines and the length of the longest one
+func lineStats(content []byte) (lines, longest int) {
+	for len(content) > 0 {
+		end := bytes.IndexByte(content, '\n')
+		if end < 0 {
+			end = len(content)
+		}
+		lines++
+		longest = max(longest, end)
+		content = content[min(end+1, len(content)):]
+	}
+	return max(lines, 1), longest
+}
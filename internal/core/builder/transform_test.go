@@ -0,0 +1,190 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/diogopedro/shotgun/internal/models"
+)
+
+func TestTransformers_Apply(t *testing.T) {
+	var csvData strings.Builder
+	csvData.WriteString("id,comment\n")
+	for i := 1; i <= 30; i++ {
+		fmt.Fprintf(&csvData, "%d,\"line one\nline two\"\n", i)
+	}
+
+	var jsonl strings.Builder
+	for i := 1; i <= 25; i++ {
+		fmt.Fprintf(&jsonl, "{\"id\":%d}\n", i)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		content  string
+		wantName string
+		want     []string
+		notWant  []string
+	}{
+		{
+			name:     "notebook drops outputs",
+			path:     "analysis.ipynb",
+			content:  `{"cells":[{"cell_type":"markdown","source":"# Title"},{"cell_type":"code","source":["x = 1\n","print(x)"],"outputs":[{"text":"SECRET OUTPUT"}]}],"metadata":{"kernelspec":{"language":"python"}}}`,
+			wantName: TransformNotebook,
+			want:     []string{"(python): 2 cells", "# %% [markdown] 1\n# Title\n", "# %% [code] 2\nx = 1\nprint(x)\n"},
+			notWant:  []string{"SECRET OUTPUT"},
+		},
+		{
+			name:     "csv keeps whole quoted records",
+			path:     "data.csv",
+			content:  csvData.String(),
+			wantName: TransformSample,
+			want:     []string{"id,comment\n", "20,\"line one\nline two\"\n", "10 more rows omitted (30 rows total)"},
+			notWant:  []string{"21,"},
+		},
+		{
+			name:     "jsonl sampled by line",
+			path:     "events.jsonl",
+			content:  jsonl.String(),
+			wantName: TransformSample,
+			want:     []string{"{\"id\":20}\n", "5 more rows omitted (25 rows total)"},
+			notWant:  []string{"{\"id\":21}"},
+		},
+		{
+			name:     "go.sum lists each module once",
+			path:     "go.sum",
+			content:  "github.com/a/b v1.0.0 h1:abc=\ngithub.com/a/b v1.0.0/go.mod h1:def=\ngithub.com/c/d v0.2.0/go.mod h1:ghi=\n",
+			wantName: TransformLockfile,
+			want:     []string{"2 dependencies", "github.com/a/b v1.0.0\ngithub.com/c/d v0.2.0\n"},
+			notWant:  []string{"h1:"},
+		},
+		{
+			name:     "package-lock packages",
+			path:     "package-lock.json",
+			content:  `{"lockfileVersion":3,"packages":{"":{"name":"app"},"node_modules/left-pad":{"version":"1.3.0","integrity":"sha512-x"},"node_modules/a/node_modules/@scope/b":{"version":"2.0.0"}}}`,
+			wantName: TransformLockfile,
+			want:     []string{"2 dependencies", "@scope/b 2.0.0\nleft-pad 1.3.0\n"},
+			notWant:  []string{"sha512"},
+		},
+		{
+			name:     "minified bundle",
+			path:     "bundle.js",
+			content:  strings.Repeat("var a=1;", 400),
+			wantName: TransformMinified,
+			want:     []string{"Minified file (3200 bytes, 1 lines, longest line 3200 chars)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, name, ok := DefaultTransformers().Apply(tt.path, []byte(tt.content))
+			if !ok || name != tt.wantName {
+				t.Fatalf("Apply() = %q, %v; want %q", name, ok, tt.wantName)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(text, want) {
+					t.Errorf("expected %q in:\n%s", want, text)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(text, notWant) {
+					t.Errorf("unexpected %q in:\n%s", notWant, text)
+				}
+			}
+		})
+	}
+
+	// Ordinary and small files are untouched, as are malformed ones
+	for path, content := range map[string]string{
+		"main.go":        "package main\n",
+		"small.csv":      "a,b\n1,2\n",
+		"broken.ipynb":   "{not json",
+		"readme.min.txt": "short",
+	} {
+		if _, name, ok := DefaultTransformers().Apply(path, []byte(content)); ok {
+			t.Errorf("%s: unexpectedly transformed by %q", path, name)
+		}
+	}
+}
+
+func TestParseTransformers(t *testing.T) {
+	transformers, err := ParseTransformers([]string{"minified", "notebook"})
+	if err != nil {
+		t.Fatalf("ParseTransformers failed: %v", err)
+	}
+	if names := strings.Join(transformers.Names(), ","); names != "notebook,minified" {
+		t.Errorf("Names() = %q, want pipeline order", names)
+	}
+	if all, _ := ParseTransformers([]string{"all"}); len(all) != len(DefaultTransformers()) {
+		t.Errorf("all selected %d transformers", len(all))
+	}
+	if _, err := ParseTransformers([]string{"zip"}); err == nil {
+		t.Error("expected an error for an unknown transformer")
+	}
+}
+
+func TestGeneratePrompt_Transformers(t *testing.T) {
+	root := t.TempDir()
+	sum := filepath.Join(root, "go.sum")
+	main := filepath.Join(root, "main.go")
+	os.WriteFile(sum, []byte("github.com/a/b v1.0.0 h1:abc=\ngithub.com/a/b v1.0.0/go.mod h1:def=\n"), 0644)
+	os.WriteFile(main, []byte("package main\n"), 0644)
+	files := []string{sum, main}
+
+	template := &models.Template{ID: "transform", Content: "{{FILE_STRUCTURE}}"}
+	generate := func(transformers Transformers) *GeneratedPrompt {
+		t.Helper()
+		result, err := NewPromptGenerator().GeneratePrompt(context.Background(), GenerationConfig{
+			Template:      template,
+			SelectedFiles: files,
+			Transformers:  transformers,
+			EmitManifest:  true,
+		})
+		if err != nil {
+			t.Fatalf("GeneratePrompt failed: %v", err)
+		}
+		return result
+	}
+
+	plain := generate(nil)
+	transformed := generate(DefaultTransformers())
+
+	if !strings.Contains(transformed.Content, `<file path="go.sum" transform="lockfile">`) {
+		t.Errorf("expected a transformed go.sum block:\n%s", transformed.Content)
+	}
+	if !strings.Contains(transformed.Content, "<file path=\"main.go\">package main\n</file>") {
+		t.Errorf("expected main.go in full:\n%s", transformed.Content)
+	}
+
+	config, err := transformed.Manifest.RegenerationConfig(template)
+	if err != nil {
+		t.Fatalf("RegenerationConfig failed: %v", err)
+	}
+	if len(config.Transformers) != len(DefaultTransformers()) {
+		t.Errorf("manifest restored %v", config.Transformers.Names())
+	}
+
+	// The estimate changes by the bytes the transformers actually removed
+	estimator := NewSizeEstimator(&mockTemplateProcessor{})
+	estimate := func(transformers Transformers) int64 {
+		t.Helper()
+		result, err := estimator.EstimatePromptSize(context.Background(), EstimationConfig{
+			Template:      template,
+			SelectedFiles: files,
+			Transformers:  transformers,
+		})
+		if err != nil {
+			t.Fatalf("EstimatePromptSize failed: %v", err)
+		}
+		return result.FileContentSize + result.OverheadSize
+	}
+	diff := estimate(nil) - estimate(DefaultTransformers())
+	if actual := int64(len(plain.Content) - len(transformed.Content)); diff < actual-20 || diff > actual+20 {
+		t.Errorf("estimated change %d, actual %d", diff, actual)
+	}
+}
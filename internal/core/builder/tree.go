@@ -0,0 +1,165 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/diogopedro/shotgun/internal/core/scanner"
+)
+
+// treeStyle holds the tree characters and path settings resolved from a TreeFormat for one run
+type treeStyle struct {
+	format   TreeFormat
+	branch   string            // Connector for all but the last child
+	last     string            // Connector for the last child
+	vertical string            // Prefix continuing a parent that has more children
+	blank    string            // Prefix under a parent's last child
+	display  map[string]string // Path written in the prompt for each file
+	source   *scanner.Source   // Where file sizes are read from; nil reads the disk
+}
+
+// newTreeStyle resolves the tree characters for format and the display paths of files.
+// Indents narrower than a connector are widened to two columns; zero uses the default width.
+func newTreeStyle(format TreeFormat, paths PathOptions, files []string) *treeStyle {
+	indent := format.IndentSize
+	if indent <= 0 {
+		indent = DefaultTreeFormat.IndentSize
+	}
+	indent = max(indent, 2)
+
+	tee, corner, line, dash := "|", "`", "|", "-"
+	if format.UseUnicode {
+		tee, corner, line, dash = "├", "└", "│", "─"
+	}
+
+	style := &treeStyle{
+		format:   format,
+		branch:   tee + strings.Repeat(dash, indent-2) + " ",
+		last:     corner + strings.Repeat(dash, indent-2) + " ",
+		vertical: line + strings.Repeat(" ", indent-1),
+		blank:    strings.Repeat(" ", indent),
+		display:  paths.DisplayPaths(files),
+	}
+	return style
+}
+
+// filePath returns the path written in a <file path> attribute
+func (s *treeStyle) filePath(path string) string {
+	if display, ok := s.display[path]; ok {
+		return display
+	}
+	return path
+}
+
+// label returns the text of a node's tree line and the node whose children follow it.
+// Collapsed single-child directory chains render as one a/b/c line.
+func (s *treeStyle) label(node *DirectoryNode) (string, *DirectoryNode) {
+	name := node.Name
+	if s.format.CollapseDirs {
+		for node.IsDirectory && len(node.Children) == 1 {
+			var only *DirectoryNode
+			for _, child := range node.Children {
+				only = child
+			}
+			if !only.IsDirectory {
+				break
+			}
+			name += "/" + only.Name
+			node = only
+		}
+	}
+
+	if s.format.ShowSizes && node.IsFile {
+		if info, err := s.source.Stat(node.Path); err == nil {
+			name += " (" + FormatFileSize(info.Size()) + ")"
+		}
+	}
+	return name, node
+}
+
+// FormatFileSize renders a byte count for display, e.g. "12.3 KB"
+func FormatFileSize(size int64) string {
+	switch {
+	case size >= 1024*1024:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1024*1024))
+	case size >= 1024:
+		return fmt.Sprintf("%.1f KB", float64(size)/1024)
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
+
+// writeSummary writes a tree-only overview limited to MaxDepth levels, then every
+// file block in tree order
+func (b *FileStructureBuilder) writeSummary(ctx context.Context, tree *DirectoryNode, style *treeStyle, contents *contentStream, result io.Writer) error {
+	if err := writeOverview(tree, "", true, 1, style, result); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(result, "\n"); err != nil {
+		return err
+	}
+
+	for _, path := range b.orderedFilePaths(tree) {
+		if err := b.writeFile(ctx, path, style, contents, result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeOverview writes the tree lines of node without contents. Directories at
+// MaxDepth are closed with a count of the files beneath them.
+func writeOverview(node *DirectoryNode, prefix string, isLast bool, depth int, style *treeStyle, result io.Writer) error {
+	if node.Name == "" {
+		children := sortedChildren(node)
+		for i, child := range children {
+			if err := writeOverview(child, "", i == len(children)-1, depth, style, result); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	treeChar := style.branch
+	if isLast {
+		treeChar = style.last
+	}
+
+	label, node := style.label(node)
+	truncated := node.IsDirectory && style.format.MaxDepth > 0 && depth >= style.format.MaxDepth
+	if truncated {
+		label += fmt.Sprintf(" (%d files)", countFiles(node))
+	}
+	if _, err := io.WriteString(result, prefix+treeChar+label+"\n"); err != nil {
+		return err
+	}
+	if truncated {
+		return nil
+	}
+
+	childPrefix := prefix + style.vertical
+	if isLast {
+		childPrefix = prefix + style.blank
+	}
+	children := sortedChildren(node)
+	for i, child := range children {
+		if err := writeOverview(child, childPrefix, i == len(children)-1, depth+1, style, result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// countFiles counts the files beneath a directory node
+func countFiles(node *DirectoryNode) int {
+	if node.IsFile {
+		return 1
+	}
+	count := 0
+	for _, child := range node.Children {
+		count += countFiles(child)
+	}
+	return count
+}
@@ -0,0 +1,375 @@
+package builder
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/diogopedro/shotgun/internal/core/scanner"
+)
+
+// DefaultMaxFileSize is the size above which files are truncated or replaced by a placeholder
+const DefaultMaxFileSize = 10 * 1024 * 1024
+
+// Truncation strategy names accepted on the command line and recorded in manifests
+const (
+	TruncateHeadTail = "head-tail" // First and last lines with an elision marker
+	TruncateHead     = "head"      // First bytes, cut at a line boundary
+	TruncateKeywords = "keywords"  // Only lines mentioning a keyword, for logs
+)
+
+// Truncation defaults
+const (
+	defaultHeadLines   = 200
+	defaultTailLines   = 100
+	defaultHeadBytes   = 64 * 1024
+	maxTaskKeywords    = 12
+	binarySniffSize    = 8 * 1024
+	minTaskKeywordSize = 4
+)
+
+// errBinaryContent reports that a file too large for binary detection contains NUL bytes
+var errBinaryContent = errors.New("binary content")
+
+// Truncation selects how files above the size limit are cut down instead of being
+// replaced by a placeholder. The zero value keeps the placeholder.
+type Truncation struct {
+	Strategy  string   `json:"strategy,omitempty"`
+	HeadLines int      `json:"head_lines,omitempty"` // head-tail: leading lines kept
+	TailLines int      `json:"tail_lines,omitempty"` // head-tail: trailing lines kept
+	HeadBytes int64    `json:"head_bytes,omitempty"` // head: leading bytes kept
+	Keywords  []string `json:"keywords,omitempty"`   // keywords: lines kept when they mention one of these
+}
+
+// ParseTruncation parses a strategy spec: "head-tail[=200,100]", "head[=64KB]",
+// "keywords[=timeout,panic]" or "none"
+func ParseTruncation(spec string) (Truncation, error) {
+	name, args, hasArgs := strings.Cut(strings.TrimSpace(spec), "=")
+	t := Truncation{Strategy: strings.ToLower(name)}
+
+	switch t.Strategy {
+	case "", "none":
+		return Truncation{}, nil
+
+	case TruncateHeadTail:
+		t.HeadLines, t.TailLines = defaultHeadLines, defaultTailLines
+		if hasArgs {
+			head, tail, _ := strings.Cut(args, ",")
+			var err error
+			if t.HeadLines, err = strconv.Atoi(strings.TrimSpace(head)); err != nil || t.HeadLines < 0 {
+				return Truncation{}, fmt.Errorf("invalid head line count %q", head)
+			}
+			if tail != "" {
+				if t.TailLines, err = strconv.Atoi(strings.TrimSpace(tail)); err != nil || t.TailLines < 0 {
+					return Truncation{}, fmt.Errorf("invalid tail line count %q", tail)
+				}
+			}
+		}
+
+	case TruncateHead:
+		t.HeadBytes = defaultHeadBytes
+		if hasArgs {
+			size, err := ParseByteSize(args)
+			if err != nil || size <= 0 {
+				return Truncation{}, fmt.Errorf("invalid head size %q", args)
+			}
+			t.HeadBytes = size
+		}
+
+	case TruncateKeywords:
+		if hasArgs {
+			for _, keyword := range strings.Split(args, ",") {
+				if keyword = strings.TrimSpace(keyword); keyword != "" {
+					t.Keywords = append(t.Keywords, keyword)
+				}
+			}
+		}
+
+	default:
+		return Truncation{}, fmt.Errorf("unknown truncation strategy %q", name)
+	}
+	return t, nil
+}
+
+// ParseByteSize parses sizes such as "65536", "64KB" or "1MB"
+func ParseByteSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"MB", 1024 * 1024}, {"KB", 1024}, {"B", 1}} {
+		if strings.HasSuffix(s, unit.suffix) {
+			s, multiplier = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix)), unit.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return n * multiplier, nil
+}
+
+// Enabled reports whether oversized files are truncated rather than replaced
+func (t Truncation) Enabled() bool {
+	return t.Strategy != ""
+}
+
+// ForTask fills in keywords from the task description when the keywords strategy has none
+func (t Truncation) ForTask(task string) Truncation {
+	if t.Strategy == TruncateKeywords && len(t.Keywords) == 0 {
+		t.Keywords = TaskKeywords(task)
+	}
+	return t
+}
+
+// stopWords are common task words that would match most log lines
+var stopWords = map[string]bool{
+	"about": true, "after": true, "also": true, "before": true, "could": true, "does": true,
+	"from": true, "have": true, "into": true, "should": true, "that": true, "them": true,
+	"there": true, "these": true, "they": true, "this": true, "what": true, "when": true,
+	"where": true, "which": true, "while": true, "with": true, "would": true, "your": true,
+	"file": true, "files": true, "code": true, "please": true, "issue": true, "error": true,
+}
+
+// TaskKeywords extracts distinctive words from a task description, in order of appearance
+func TaskKeywords(task string) []string {
+	seen := make(map[string]bool)
+	var keywords []string
+	words := strings.FieldsFunc(task, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' && r != '.'
+	})
+	for _, word := range words {
+		word = strings.ToLower(strings.Trim(word, ".-"))
+		if utf8.RuneCountInString(word) < minTaskKeywordSize || stopWords[word] || seen[word] {
+			continue
+		}
+		seen[word] = true
+		keywords = append(keywords, word)
+		if len(keywords) == maxTaskKeywords {
+			break
+		}
+	}
+	return keywords
+}
+
+// WithTruncation cuts down files above the size limit instead of replacing them
+func WithTruncation(truncation Truncation) Option {
+	return func(b *FileStructureBuilder) {
+		b.truncation = truncation
+	}
+}
+
+// TruncateFile renders a file above maxSize according to the strategy. The result stays
+// within roughly maxSize bytes plus markers; head-tail output that would not falls back
+// to the head strategy, as does the keywords strategy without keywords. Invalid UTF-8
+// sequences become U+FFFD, as in files embedded whole.
+func (t Truncation) TruncateFile(path string, maxSize int64) (string, error) {
+	return t.truncateFile(nil, path, maxSize)
+}
+
+// truncateFile is TruncateFile for a file of an archive or git revision
+func (t Truncation) truncateFile(source *scanner.Source, path string, maxSize int64) (string, error) {
+	file, err := source.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	// Legacy and UTF-16 encodings are transcoded while streaming
+	raw := bufio.NewReaderSize(file, 64*1024)
+	sniff, _ := raw.Peek(binarySniffSize)
+	if scanner.IsBinaryContent(sniff) {
+		return "", errBinaryContent
+	}
+	sniff = append([]byte(nil), sniff...)
+	reader := bufio.NewReaderSize(scanner.NewDecodingReader(raw, sniff), 64*1024)
+
+	switch t.Strategy {
+	case TruncateHeadTail:
+		text, err := truncateHeadTail(reader, t.HeadLines, t.TailLines)
+		text = strings.ToValidUTF8(text, "\uFFFD")
+		if err != nil || int64(len(text)) <= maxSize {
+			return text, err
+		}
+	case TruncateKeywords:
+		if len(t.Keywords) > 0 {
+			text, err := truncateKeywords(reader, t.Keywords, maxSize)
+			return strings.ToValidUTF8(text, "\uFFFD"), err
+		}
+	}
+
+	// Head strategy, and the fallback for the others; archive entries are reopened to rewind
+	if seeker, ok := file.(io.Seeker); ok {
+		_, err = seeker.Seek(0, io.SeekStart)
+	} else if file, err = source.Open(path); err == nil {
+		defer file.Close()
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	headBytes := t.HeadBytes
+	if headBytes <= 0 || headBytes > maxSize {
+		headBytes = min(defaultHeadBytes, maxSize)
+	}
+	text, err := truncateHead(scanner.NewDecodingReader(file, sniff), headBytes, info.Size())
+	return strings.ToValidUTF8(text, "\uFFFD"), err
+}
+
+// truncateHeadTail keeps the first head and last tail lines
+func truncateHeadTail(reader *bufio.Reader, head, tail int) (string, error) {
+	var kept strings.Builder
+	ring := make([]string, tail)
+	total := 0
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			if total < head {
+				kept.WriteString(line)
+			} else if tail > 0 {
+				ring[(total-head)%tail] = line
+			}
+			total++
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read file: %w", err)
+		}
+	}
+
+	rest := total - head
+	if rest <= 0 {
+		return kept.String(), nil
+	}
+	tailCount := min(rest, tail)
+	writeElision(&kept, fmt.Sprintf("%d lines omitted", rest-tailCount))
+	for i := rest - tailCount; i < rest; i++ {
+		kept.WriteString(ring[i%tail])
+	}
+	return kept.String(), nil
+}
+
+// truncateHead keeps the first size bytes, cut back to the last full line when there is one
+func truncateHead(file io.Reader, size, total int64) (string, error) {
+	buf := make([]byte, size)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	buf = buf[:n]
+	if i := bytes.LastIndexByte(buf, '\n'); i >= 0 {
+		buf = buf[:i+1]
+	}
+
+	// Never end inside a multi-byte character: drop an incomplete final rune only
+	for i := 1; i < utf8.UTFMax && i <= len(buf); i++ {
+		if start := len(buf) - i; utf8.RuneStart(buf[start]) {
+			if !utf8.FullRune(buf[start:]) {
+				buf = buf[:start]
+			}
+			break
+		}
+	}
+
+	var kept strings.Builder
+	kept.Write(buf)
+	writeElision(&kept, fmt.Sprintf("%d of %d bytes omitted", total-int64(len(buf)), total))
+	return kept.String(), nil
+}
+
+// truncateKeywords keeps lines that mention any keyword, case-insensitively, marking each
+// gap. Output stops growing at maxSize; later matches are only counted.
+func truncateKeywords(reader *bufio.Reader, keywords []string, maxSize int64) (string, error) {
+	lowered := make([]string, len(keywords))
+	for i, keyword := range keywords {
+		lowered[i] = strings.ToLower(keyword)
+	}
+
+	var kept strings.Builder
+	fmt.Fprintf(&kept, "[lines mentioning: %s]\n", strings.Join(keywords, ", "))
+	skipped, matched, dropped := 0, 0, 0
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			lower := strings.ToLower(line)
+			match := false
+			for _, keyword := range lowered {
+				if strings.Contains(lower, keyword) {
+					match = true
+					break
+				}
+			}
+
+			switch {
+			case !match:
+				skipped++
+			case int64(kept.Len()+len(line)) > maxSize:
+				dropped++
+			default:
+				if skipped > 0 {
+					writeElision(&kept, fmt.Sprintf("%d lines omitted", skipped))
+					skipped = 0
+				}
+				if !strings.HasSuffix(line, "\n") {
+					line += "\n"
+				}
+				kept.WriteString(line)
+				matched++
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read file: %w", err)
+		}
+	}
+
+	if skipped > 0 {
+		writeElision(&kept, fmt.Sprintf("%d lines omitted", skipped))
+	}
+	if dropped > 0 {
+		writeElision(&kept, fmt.Sprintf("%d more matching lines omitted at the size limit", dropped))
+	}
+	if matched == 0 && dropped == 0 {
+		kept.WriteString("[no matching lines]\n")
+	}
+	return kept.String(), nil
+}
+
+// writeElision writes a marker for omitted content on its own line
+func writeElision(b *strings.Builder, what string) {
+	if b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(b, "... [%s] ...\n", what)
+}
+
+// tooLargePlaceholder is embedded for files above the size limit when truncation is off
+func tooLargePlaceholder(size, limit int64) string {
+	return fmt.Sprintf("File too large (%d bytes, limit %d bytes)", size, limit)
+}
+
+// binaryPlaceholder is embedded for binary files
+func binaryPlaceholder(size int64) string {
+	return fmt.Sprintf("Binary file (%d bytes)", size)
+}
+
+// lfsPlaceholder is embedded for Git LFS pointers whose object is not checked out
+func lfsPlaceholder(size int64) string {
+	return fmt.Sprintf("Git LFS pointer (%d byte object not checked out)", size)
+}
@@ -0,0 +1,218 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/diogopedro/shotgun/internal/models"
+)
+
+func TestParseTruncation(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    Truncation
+		wantErr bool
+	}{
+		{"", Truncation{}, false},
+		{"none", Truncation{}, false},
+		{"head-tail", Truncation{Strategy: TruncateHeadTail, HeadLines: 200, TailLines: 100}, false},
+		{"head-tail=10,5", Truncation{Strategy: TruncateHeadTail, HeadLines: 10, TailLines: 5}, false},
+		{"head=2KB", Truncation{Strategy: TruncateHead, HeadBytes: 2048}, false},
+		{"keywords=timeout, panic", Truncation{Strategy: TruncateKeywords, Keywords: []string{"timeout", "panic"}}, false},
+		{"head-tail=x", Truncation{}, true},
+		{"head=-1", Truncation{}, true},
+		{"middle", Truncation{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTruncation(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%q: got %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestTaskKeywords(t *testing.T) {
+	got := TaskKeywords("Why does the worker hit a Timeout when the queue_depth grows? The timeout is from redis.")
+	want := "worker,timeout,queue_depth,grows,redis"
+	if strings.Join(got, ",") != want {
+		t.Errorf("got %v, want %s", got, want)
+	}
+}
+
+func TestTruncation_TruncateFile(t *testing.T) {
+	var log strings.Builder
+	for i := 1; i <= 100; i++ {
+		if i == 40 || i == 41 || i == 90 {
+			fmt.Fprintf(&log, "line %d: request TIMEOUT\n", i)
+		} else {
+			fmt.Fprintf(&log, "line %d: ok\n", i)
+		}
+	}
+	path := filepath.Join(t.TempDir(), "app.log")
+	os.WriteFile(path, []byte(log.String()), 0644)
+
+	tests := []struct {
+		name    string
+		spec    string
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "head and tail lines",
+			spec:    "head-tail=2,1",
+			want:    []string{"line 1: ok\nline 2: ok\n... [97 lines omitted] ...\nline 100: ok\n"},
+			notWant: []string{"line 3:"},
+		},
+		{
+			name:    "first bytes at a line boundary",
+			spec:    "head=30",
+			want:    []string{"line 1: ok\nline 2: ok\n... [", " bytes omitted] ...\n"},
+			notWant: []string{"line 3"},
+		},
+		{
+			name:    "matching lines with gaps",
+			spec:    "keywords=timeout",
+			want:    []string{"[lines mentioning: timeout]\n... [39 lines omitted] ...\nline 40: request TIMEOUT\nline 41: request TIMEOUT\n... [48 lines omitted] ...\nline 90: request TIMEOUT\n... [10 lines omitted] ...\n"},
+			notWant: []string{": ok"},
+		},
+		{
+			name: "keywords without keywords keep the head",
+			spec: "keywords",
+			want: []string{"line 1: ok\n", "bytes omitted"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			truncation, err := ParseTruncation(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseTruncation failed: %v", err)
+			}
+			got, err := truncation.TruncateFile(path, 500)
+			if err != nil {
+				t.Fatalf("TruncateFile failed: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("expected %q in:\n%s", want, got)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(got, notWant) {
+					t.Errorf("unexpected %q in:\n%s", notWant, got)
+				}
+			}
+		})
+	}
+}
+
+func TestTruncation_TruncateFile_InvalidUTF8(t *testing.T) {
+	dir := t.TempDir()
+	var log strings.Builder
+	log.WriteString("line 1: caf\xe9 TIMEOUT\n") // Stray Latin-1 byte in otherwise UTF-8 text
+	for i := 2; i <= 100; i++ {
+		fmt.Fprintf(&log, "line %d: naïve ok\n", i)
+	}
+	mixed := filepath.Join(dir, "mixed.log")
+	os.WriteFile(mixed, []byte(log.String()), 0644)
+
+	for _, spec := range []string{"head=200", "head-tail=2,1", "keywords=timeout"} {
+		truncation, _ := ParseTruncation(spec)
+		got, err := truncation.TruncateFile(mixed, 500)
+		if err != nil {
+			t.Fatalf("%s: TruncateFile failed: %v", spec, err)
+		}
+		if !utf8.ValidString(got) || !strings.Contains(got, "caf\uFFFD TIMEOUT\n") {
+			t.Errorf("%s: expected the stray byte replaced by U+FFFD, got:\n%s", spec, got)
+		}
+		if spec == "head=200" && !strings.Contains(got, "line 5: naïve ok\n") {
+			t.Errorf("%s: expected lines after the stray byte to be kept, got:\n%s", spec, got)
+		}
+	}
+
+	// A cut inside a multi-byte character drops only that character
+	accents := filepath.Join(dir, "accents.txt")
+	os.WriteFile(accents, []byte(strings.Repeat("é", 1000)), 0644)
+	got, err := Truncation{Strategy: TruncateHead, HeadBytes: 101}.TruncateFile(accents, 500)
+	if err != nil {
+		t.Fatalf("TruncateFile failed: %v", err)
+	}
+	if !strings.HasPrefix(got, strings.Repeat("é", 50)+"\n... [") || strings.Contains(got, "\uFFFD") {
+		t.Errorf("expected 50 whole characters, got:\n%s", got)
+	}
+}
+
+func TestGeneratePrompt_Truncation(t *testing.T) {
+	root := t.TempDir()
+	large := filepath.Join(root, "server.log")
+	small := filepath.Join(root, "main.go")
+	var log strings.Builder
+	for i := 1; i <= 200; i++ {
+		fmt.Fprintf(&log, "%03d connection reset by peer\n", i)
+	}
+	os.WriteFile(large, []byte(log.String()), 0644)
+	os.WriteFile(small, []byte("package main\n"), 0644)
+	files := []string{large, small}
+
+	template := &models.Template{ID: "truncate", Content: "{{FILE_STRUCTURE}}"}
+	truncation := Truncation{Strategy: TruncateHeadTail, HeadLines: 3, TailLines: 2}
+	generate := func(truncation Truncation) *GeneratedPrompt {
+		t.Helper()
+		result, err := NewPromptGenerator().GeneratePrompt(context.Background(), GenerationConfig{
+			Template:      template,
+			SelectedFiles: files,
+			MaxFileSize:   1024,
+			Truncation:    truncation,
+			EmitManifest:  true,
+		})
+		if err != nil {
+			t.Fatalf("GeneratePrompt failed: %v", err)
+		}
+		return result
+	}
+
+	if placeholder := generate(Truncation{}); !strings.Contains(placeholder.Content, "File too large") {
+		t.Errorf("expected a placeholder without truncation:\n%s", placeholder.Content)
+	}
+
+	result := generate(truncation)
+	want := "<file path=\"server.log\" truncated=\"head-tail\">001 connection reset by peer\n002 connection reset by peer\n003 connection reset by peer\n... [195 lines omitted] ...\n199 connection reset by peer\n200 connection reset by peer\n</file>"
+	if !strings.Contains(result.Content, want) {
+		t.Errorf("expected a truncated block:\n%s", result.Content)
+	}
+
+	config, err := result.Manifest.RegenerationConfig(template)
+	if err != nil {
+		t.Fatalf("RegenerationConfig failed: %v", err)
+	}
+	if config.MaxFileSize != 1024 || fmt.Sprint(config.Truncation) != fmt.Sprint(truncation) {
+		t.Errorf("manifest restored %d, %+v", config.MaxFileSize, config.Truncation)
+	}
+
+	estimate, err := NewSizeEstimator(&mockTemplateProcessor{}).EstimatePromptSize(context.Background(), EstimationConfig{
+		Template:      template,
+		SelectedFiles: files,
+		MaxFileSize:   1024,
+		Truncation:    truncation,
+	})
+	if err != nil {
+		t.Fatalf("EstimatePromptSize failed: %v", err)
+	}
+	if estimate.TruncatedFiles != 1 {
+		t.Errorf("TruncatedFiles = %d, want 1", estimate.TruncatedFiles)
+	}
+	body := strings.TrimSuffix(want[strings.Index(want, ">")+1:], "</file>")
+	if want := int64(len(body) + len("package main\n")); estimate.FileContentSize != want {
+		t.Errorf("FileContentSize = %d, want %d", estimate.FileContentSize, want)
+	}
+}
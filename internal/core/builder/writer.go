@@ -2,6 +2,7 @@ package builder
 
 import (
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -51,18 +52,127 @@ func (fw *FileWriter) WritePromptFile(content string, basePath string) (string,
 	}
 
 	// Handle filename collisions
-	finalFilename := fw.CheckCollisions(filepath.Join(basePath, baseFilename))
-	fullPath := finalFilename
+	fullPath := fw.CheckCollisions(filepath.Join(basePath, baseFilename))
 
-	// Use atomic file writing pattern: write to temp file, then rename
+	if err := fw.writeAtomic(fullPath, writeString(content)); err != nil {
+		return "", err
+	}
+
+	return fullPath, nil
+}
+
+// WritePromptStream creates a new timestamped prompt file and lets write stream content into it
+func (fw *FileWriter) WritePromptStream(basePath string, write func(w io.Writer) error) (string, error) {
+	// Resolve base path (use current directory if empty)
+	if basePath == "" {
+		var err error
+		basePath, err = os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("failed to get current working directory: %w", err)
+		}
+	}
+
+	// Validate write permissions
+	if err := fw.ValidateWritePermissions(basePath); err != nil {
+		return "", fmt.Errorf("write permission error: %w", err)
+	}
+
+	fullPath := fw.CheckCollisions(filepath.Join(basePath, fw.GenerateFilename(time.Now())))
+
+	if err := fw.writeAtomic(fullPath, write); err != nil {
+		return "", err
+	}
+
+	return fullPath, nil
+}
+
+// WritePromptParts writes numbered part files (..._part01.md, ...) sharing one timestamped base name
+func (fw *FileWriter) WritePromptParts(parts []string, basePath string) ([]string, error) {
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("no parts to write")
+	}
+
+	// Resolve base path (use current directory if empty)
+	if basePath == "" {
+		var err error
+		basePath, err = os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current working directory: %w", err)
+		}
+	}
+
+	// Validate write permissions
+	if err := fw.ValidateWritePermissions(basePath); err != nil {
+		return nil, fmt.Errorf("write permission error: %w", err)
+	}
+
+	// Pick a base name whose first part does not collide
+	promptPath := filepath.Join(basePath, fw.GenerateFilename(time.Now()))
+	firstPart := fw.CheckCollisions(PartFilename(promptPath, 1))
+
+	return fw.WritePromptPartsAt(parts, partBasePath(firstPart))
+}
+
+// WritePromptPartsAt writes numbered part files derived from an exact prompt path, replacing existing files
+func (fw *FileWriter) WritePromptPartsAt(parts []string, promptPath string) ([]string, error) {
+	files := make([]string, 0, len(parts))
+	for i, part := range parts {
+		path, err := fw.WritePromptFileAt(part, PartFilename(promptPath, i+1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to write part %d: %w", i+1, err)
+		}
+		files = append(files, path)
+	}
+	return files, nil
+}
+
+// WritePromptStreamAt streams prompt content to an exact path, replacing any existing file
+func (fw *FileWriter) WritePromptStreamAt(fullPath string, write func(w io.Writer) error) (string, error) {
+	if err := fw.ValidateWritePermissions(filepath.Dir(fullPath)); err != nil {
+		return "", fmt.Errorf("write permission error: %w", err)
+	}
+
+	if err := fw.writeAtomic(fullPath, write); err != nil {
+		return "", err
+	}
+
+	return fullPath, nil
+}
+
+// WritePromptFileAt writes the prompt content to an exact path, replacing any existing file
+func (fw *FileWriter) WritePromptFileAt(content string, fullPath string) (string, error) {
+	if content == "" {
+		return "", fmt.Errorf("content cannot be empty")
+	}
+
+	if err := fw.ValidateWritePermissions(filepath.Dir(fullPath)); err != nil {
+		return "", fmt.Errorf("write permission error: %w", err)
+	}
+
+	if err := fw.writeAtomic(fullPath, writeString(content)); err != nil {
+		return "", err
+	}
+
+	return fullPath, nil
+}
+
+// writeString adapts a string to a streaming write function
+func writeString(content string) func(w io.Writer) error {
+	return func(w io.Writer) error {
+		_, err := io.WriteString(w, content)
+		return err
+	}
+}
+
+// writeAtomic streams content to a temp file and renames it into place
+func (fw *FileWriter) writeAtomic(fullPath string, write func(w io.Writer) error) error {
 	tempPath := fullPath + ".tmp"
 
 	// Write to temporary file first
 	file, err := os.Create(tempPath)
 	if err != nil {
-		return "", fmt.Errorf("failed to create temporary file: %w", err)
+		return fmt.Errorf("failed to create temporary file: %w", err)
 	}
-
 	defer func() {
 		file.Close()
 		// Clean up temp file if it still exists
@@ -72,27 +182,26 @@ func (fw *FileWriter) WritePromptFile(content string, basePath string) (string,
 	}()
 
 	// Write content
-	_, err = file.WriteString(content)
-	if err != nil {
-		return "", fmt.Errorf("failed to write content to file: %w", err)
+	if err = write(file); err != nil {
+		return fmt.Errorf("failed to write content to file: %w", err)
 	}
 
 	// Sync to ensure data is written to disk
 	if err = file.Sync(); err != nil {
-		return "", fmt.Errorf("failed to sync file to disk: %w", err)
+		return fmt.Errorf("failed to sync file to disk: %w", err)
 	}
 
 	// Close file before rename
 	if err = file.Close(); err != nil {
-		return "", fmt.Errorf("failed to close temporary file: %w", err)
+		return fmt.Errorf("failed to close temporary file: %w", err)
 	}
 
 	// Atomic rename
 	if err = os.Rename(tempPath, fullPath); err != nil {
-		return "", fmt.Errorf("failed to rename temporary file: %w", err)
+		return fmt.Errorf("failed to rename temporary file: %w", err)
 	}
 
-	return fullPath, nil
+	return nil
 }
 
 // GenerateFilename creates a timestamp-based filename
@@ -0,0 +1,336 @@
+package deps
+
+import (
+	"bufio"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Resolver maps Go imports within a module to package directories and follows them
+// forwards (dependencies) or backwards (dependents)
+type Resolver struct {
+	moduleRoot string
+	modulePath string
+
+	mu          sync.Mutex
+	fileImports map[string][]string // file path -> in-module imported package dirs
+	dependents  map[string][]string // package dir -> files importing it, built on first use
+}
+
+// FindModule walks up from dir to the nearest go.mod and returns its directory and module path
+func FindModule(dir string) (string, string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve %s: %w", dir, err)
+	}
+
+	for current := abs; ; {
+		modFile := filepath.Join(current, "go.mod")
+		if _, err := os.Stat(modFile); err == nil {
+			modulePath, err := readModulePath(modFile)
+			if err != nil {
+				return "", "", err
+			}
+			return current, modulePath, nil
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			return "", "", fmt.Errorf("no go.mod found above %s", abs)
+		}
+		current = parent
+	}
+}
+
+// readModulePath reads the module directive from a go.mod file
+func readModulePath(modFile string) (string, error) {
+	file, err := os.Open(modFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", modFile, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "module") {
+			continue
+		}
+		rest := strings.TrimSpace(strings.TrimPrefix(line, "module"))
+		if i := strings.Index(rest, "//"); i >= 0 {
+			rest = strings.TrimSpace(rest[:i])
+		}
+		if unquoted, err := strconv.Unquote(rest); err == nil {
+			rest = unquoted
+		}
+		if rest != "" {
+			return rest, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", modFile, err)
+	}
+
+	return "", fmt.Errorf("no module directive in %s", modFile)
+}
+
+// NewResolver creates a resolver for the module containing dir
+func NewResolver(dir string) (*Resolver, error) {
+	root, modulePath, err := FindModule(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Resolver{
+		moduleRoot:  root,
+		modulePath:  modulePath,
+		fileImports: make(map[string][]string),
+	}, nil
+}
+
+// ModulePath returns the module path declared in go.mod
+func (r *Resolver) ModulePath() string {
+	return r.modulePath
+}
+
+// ModuleRoot returns the directory containing go.mod
+func (r *Resolver) ModuleRoot() string {
+	return r.moduleRoot
+}
+
+// PackageDir maps an in-module import path to its directory
+func (r *Resolver) PackageDir(importPath string) (string, bool) {
+	if importPath == r.modulePath {
+		return r.moduleRoot, true
+	}
+	if rest, ok := strings.CutPrefix(importPath, r.modulePath+"/"); ok {
+		return filepath.Join(r.moduleRoot, filepath.FromSlash(rest)), true
+	}
+	return "", false
+}
+
+// Imports returns the in-module package directories imported by a Go file
+func (r *Resolver) Imports(path string) ([]string, error) {
+	r.mu.Lock()
+	cached, ok := r.fileImports[path]
+	r.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.ImportsOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse imports of %s: %w", path, err)
+	}
+
+	var dirs []string
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		if dir, ok := r.PackageDir(importPath); ok {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	r.mu.Lock()
+	r.fileImports[path] = dirs
+	r.mu.Unlock()
+
+	return dirs, nil
+}
+
+// Expand returns the Go files of the in-module packages reachable from files within
+// the given number of import hops. The first hop follows the imports of the files
+// themselves; later hops follow every file of the packages already added.
+func (r *Resolver) Expand(files []string, hops int) ([]string, error) {
+	selected := toSet(files)
+	visited := make(map[string]bool)
+	var added []string
+
+	frontier := goFiles(files)
+	for hop := 0; hop < hops && len(frontier) > 0; hop++ {
+		var next []string
+		for _, file := range frontier {
+			dirs, err := r.Imports(file)
+			if err != nil {
+				continue // Unparseable files contribute no dependencies
+			}
+			for _, dir := range dirs {
+				if visited[dir] {
+					continue
+				}
+				visited[dir] = true
+
+				pkgFiles, err := packageFiles(dir)
+				if err != nil {
+					continue
+				}
+				for _, f := range pkgFiles {
+					if !selected[f] {
+						selected[f] = true
+						added = append(added, f)
+					}
+				}
+				next = append(next, pkgFiles...)
+			}
+		}
+		frontier = next
+	}
+
+	sort.Strings(added)
+	return added, nil
+}
+
+// Dependents returns the Go files in the module that import the packages of files,
+// following importers transitively for the given number of hops
+func (r *Resolver) Dependents(files []string, hops int) ([]string, error) {
+	index, err := r.dependentIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	selected := toSet(files)
+	visited := make(map[string]bool)
+	var added []string
+
+	targets := packageDirs(goFiles(files))
+	for hop := 0; hop < hops && len(targets) > 0; hop++ {
+		var nextFiles []string
+		for _, dir := range targets {
+			if visited[dir] {
+				continue
+			}
+			visited[dir] = true
+
+			for _, f := range index[dir] {
+				if !selected[f] {
+					selected[f] = true
+					added = append(added, f)
+					nextFiles = append(nextFiles, f)
+				}
+			}
+		}
+		targets = packageDirs(nextFiles)
+	}
+
+	sort.Strings(added)
+	return added, nil
+}
+
+// dependentIndex builds the reverse import index for the whole module
+func (r *Resolver) dependentIndex() (map[string][]string, error) {
+	r.mu.Lock()
+	index := r.dependents
+	r.mu.Unlock()
+	if index != nil {
+		return index, nil
+	}
+
+	index = make(map[string][]string)
+	err := filepath.WalkDir(r.moduleRoot, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip unreadable entries
+		}
+
+		if d.IsDir() {
+			if path != r.moduleRoot && skipDir(path, d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if filepath.Ext(path) != ".go" {
+			return nil
+		}
+
+		dirs, err := r.Imports(path)
+		if err != nil {
+			return nil
+		}
+		for _, dir := range dirs {
+			index[dir] = append(index[dir], path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to index module %s: %w", r.modulePath, err)
+	}
+
+	r.mu.Lock()
+	r.dependents = index
+	r.mu.Unlock()
+
+	return index, nil
+}
+
+// skipDir reports whether a directory is outside the module's own packages
+func skipDir(path, name string) bool {
+	if name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+		return true
+	}
+
+	// Nested modules are separate import graphs
+	_, err := os.Stat(filepath.Join(path, "go.mod"))
+	return err == nil
+}
+
+// packageFiles lists the non-test Go files of a package directory
+func packageFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".go" || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		files = append(files, filepath.Join(dir, name))
+	}
+	return files, nil
+}
+
+// goFiles filters paths down to Go source files
+func goFiles(paths []string) []string {
+	var files []string
+	for _, path := range paths {
+		if filepath.Ext(path) == ".go" {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// packageDirs returns the distinct directories of files
+func packageDirs(files []string) []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, f := range files {
+		dir := filepath.Dir(f)
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// toSet builds a lookup set from paths
+func toSet(paths []string) map[string]bool {
+	set := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		set[p] = true
+	}
+	return set
+}
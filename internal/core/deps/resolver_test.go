@@ -0,0 +1,126 @@
+package deps
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeModule creates a small module: cmd -> api -> store, with api_test importing util
+func writeModule(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+
+	files := map[string]string{
+		"go.mod":              "module example.com/app // app module\n\ngo 1.23\n",
+		"cmd/main.go":         "package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/app/api\"\n)\n\nfunc main() { fmt.Println(api.Name) }\n",
+		"api/api.go":          "package api\n\nimport \"example.com/app/store\"\n\nvar Name = store.Name\n",
+		"api/handlers.go":     "package api\n",
+		"api/api_test.go":     "package api\n\nimport _ \"example.com/app/util\"\n",
+		"store/store.go":      "package store\n\nconst Name = \"store\"\n",
+		"util/util.go":        "package util\n",
+		"vendor/x/x.go":       "package x\n\nimport _ \"example.com/app/store\"\n",
+		"nested/go.mod":       "module example.com/nested\n",
+		"nested/nested.go":    "package nested\n\nimport _ \"example.com/app/store\"\n",
+		"testdata/ignored.go": "package ignored\n\nimport _ \"example.com/app/store\"\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestFindModule(t *testing.T) {
+	root := writeModule(t)
+
+	gotRoot, modulePath, err := FindModule(filepath.Join(root, "api"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotRoot != root || modulePath != "example.com/app" {
+		t.Errorf("got (%s, %s), want (%s, example.com/app)", gotRoot, modulePath, root)
+	}
+
+	if _, _, err := FindModule(t.TempDir()); err == nil {
+		t.Error("expected error outside a module")
+	}
+}
+
+func TestPackageDir(t *testing.T) {
+	root := writeModule(t)
+	r, err := NewResolver(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if dir, ok := r.PackageDir("example.com/app/api"); !ok || dir != filepath.Join(root, "api") {
+		t.Errorf("unexpected mapping: %s %v", dir, ok)
+	}
+	if _, ok := r.PackageDir("example.com/application"); ok {
+		t.Error("prefix match must respect path boundaries")
+	}
+	if _, ok := r.PackageDir("fmt"); ok {
+		t.Error("standard library imports are outside the module")
+	}
+}
+
+func TestExpand(t *testing.T) {
+	root := writeModule(t)
+	r, err := NewResolver(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	main := filepath.Join(root, "cmd", "main.go")
+
+	oneHop, err := r.Expand([]string{main}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(root, "api", "api.go"), filepath.Join(root, "api", "handlers.go")}
+	if !reflect.DeepEqual(oneHop, want) {
+		t.Errorf("1 hop: got %v, want %v", oneHop, want)
+	}
+
+	twoHops, err := r.Expand([]string{main}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = append(want, filepath.Join(root, "store", "store.go"))
+	if !reflect.DeepEqual(twoHops, want) {
+		t.Errorf("2 hops: got %v, want %v", twoHops, want)
+	}
+}
+
+func TestDependents(t *testing.T) {
+	root := writeModule(t)
+	r, err := NewResolver(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := filepath.Join(root, "store", "store.go")
+
+	oneHop, err := r.Dependents([]string{store}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(root, "api", "api.go")}
+	if !reflect.DeepEqual(oneHop, want) {
+		t.Errorf("1 hop: got %v, want %v (vendor, testdata and nested modules are skipped)", oneHop, want)
+	}
+
+	twoHops, err := r.Dependents([]string{store}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = append(want, filepath.Join(root, "cmd", "main.go"))
+	if !reflect.DeepEqual(twoHops, want) {
+		t.Errorf("2 hops: got %v, want %v", twoHops, want)
+	}
+}
@@ -0,0 +1,186 @@
+package pairing
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Rule pairs source files with their tests. Source and Test are slash-separated
+// patterns containing exactly one "*" in the final element, which captures the shared
+// stem. Leading elements are matched against the file's parent directories, so
+// "*.ts" ↔ "__tests__/*.test.ts" pairs src/app.ts with src/__tests__/app.test.ts.
+type Rule struct {
+	Source   string
+	Test     string
+	TestData string // Directory next to the tests whose files belong to them (e.g. Go's testdata)
+}
+
+// DefaultRules returns the built-in pairing rules for Go, JavaScript/TypeScript and Python
+func DefaultRules() []Rule {
+	rules := []Rule{
+		{Source: "*.go", Test: "*_test.go", TestData: "testdata"},
+		{Source: "*.py", Test: "test_*.py"},
+		{Source: "*.py", Test: "*_test.py"},
+		{Source: "*.py", Test: "tests/test_*.py"},
+	}
+	for _, ext := range []string{"ts", "tsx", "js", "jsx"} {
+		rules = append(rules,
+			Rule{Source: "*." + ext, Test: "*.spec." + ext},
+			Rule{Source: "*." + ext, Test: "*.test." + ext},
+			Rule{Source: "*." + ext, Test: "__tests__/*.test." + ext},
+		)
+	}
+	return rules
+}
+
+// ParseRule parses a "SOURCE=TEST" rule such as "*.ts=*.spec.ts"
+func ParseRule(s string) (Rule, error) {
+	source, test, ok := strings.Cut(s, "=")
+	if !ok {
+		return Rule{}, fmt.Errorf("invalid pairing rule %q: expected SOURCE=TEST", s)
+	}
+
+	rule := Rule{Source: strings.TrimSpace(source), Test: strings.TrimSpace(test)}
+	if err := rule.validate(); err != nil {
+		return Rule{}, err
+	}
+	return rule, nil
+}
+
+// validate checks that both patterns capture a stem in their final element
+func (r Rule) validate() error {
+	for _, pattern := range []string{r.Source, r.Test} {
+		base := path.Base(pattern)
+		if strings.Count(pattern, "*") != 1 || strings.Count(base, "*") != 1 {
+			return fmt.Errorf("invalid pairing pattern %q: needs exactly one * in the file name", pattern)
+		}
+	}
+	return nil
+}
+
+// Pairer finds the tests of source files and the sources of test files
+type Pairer struct {
+	rules []Rule
+}
+
+// New creates a pairer; with no rules the defaults are used
+func New(rules ...Rule) *Pairer {
+	if len(rules) == 0 {
+		rules = DefaultRules()
+	}
+	return &Pairer{rules: rules}
+}
+
+// Tests returns the existing test files (and test data) paired with files, excluding files themselves
+func (p *Pairer) Tests(files []string) []string {
+	return p.counterparts(files, true)
+}
+
+// Sources returns the existing source files paired with test files, excluding files themselves
+func (p *Pairer) Sources(files []string) []string {
+	return p.counterparts(files, false)
+}
+
+// counterparts maps each file through every rule in the given direction
+func (p *Pairer) counterparts(files []string, toTests bool) []string {
+	seen := make(map[string]bool, len(files))
+	for _, f := range files {
+		seen[f] = true
+	}
+
+	var result []string
+	add := func(f string) {
+		if !seen[f] {
+			seen[f] = true
+			result = append(result, f)
+		}
+	}
+
+	for _, file := range files {
+		for _, rule := range p.rules {
+			from, to := rule.Source, rule.Test
+			if !toTests {
+				from, to = rule.Test, rule.Source
+			}
+
+			anchor, stem, ok := match(file, from)
+			if !ok {
+				continue
+			}
+
+			counterpart := filepath.Join(anchor, filepath.FromSlash(strings.Replace(to, "*", stem, 1)))
+			if !isFile(counterpart) {
+				continue
+			}
+			add(counterpart)
+
+			if toTests && rule.TestData != "" {
+				for _, f := range dirFiles(filepath.Join(filepath.Dir(counterpart), rule.TestData)) {
+					add(f)
+				}
+			}
+		}
+	}
+
+	sort.Strings(result)
+	return result
+}
+
+// match checks the trailing elements of file against pattern and returns the directory
+// above the matched elements and the stem captured by "*"
+func match(file, pattern string) (anchor, stem string, ok bool) {
+	patternParts := strings.Split(pattern, "/")
+	fileParts := strings.Split(filepath.ToSlash(file), "/")
+	if len(fileParts) < len(patternParts) {
+		return "", "", false
+	}
+
+	tail := fileParts[len(fileParts)-len(patternParts):]
+	last := len(patternParts) - 1
+	for i := 0; i < last; i++ {
+		if tail[i] != patternParts[i] {
+			return "", "", false
+		}
+	}
+
+	prefix, suffix, _ := strings.Cut(patternParts[last], "*")
+	name := tail[last]
+	if len(name) <= len(prefix)+len(suffix) || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+		return "", "", false
+	}
+
+	switch dirParts := fileParts[:len(fileParts)-len(patternParts)]; {
+	case len(dirParts) == 0:
+		anchor = "."
+	case len(dirParts) == 1 && dirParts[0] == "":
+		anchor = string(filepath.Separator)
+	default:
+		anchor = filepath.FromSlash(strings.Join(dirParts, "/"))
+	}
+	return anchor, name[len(prefix) : len(name)-len(suffix)], true
+}
+
+// isFile reports whether path exists and is a regular file
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// dirFiles lists the regular files beneath dir, or nothing when dir does not exist
+func dirFiles(dir string) []string {
+	var files []string
+	filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files
+}
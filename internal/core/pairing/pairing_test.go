@@ -0,0 +1,82 @@
+package pairing
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFiles(t *testing.T, root string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	rule, err := ParseRule("*.ts = __tests__/*.test.ts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rule.Source != "*.ts" || rule.Test != "__tests__/*.test.ts" {
+		t.Errorf("unexpected rule: %+v", rule)
+	}
+
+	for _, bad := range []string{"*.ts", "a.ts=*.test.ts", "*.ts=*/x.ts", "**.ts=*.spec.ts"} {
+		if _, err := ParseRule(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestTestsAndSources(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root,
+		"pkg/foo.go", "pkg/foo_test.go", "pkg/bar.go", "pkg/testdata/golden/out.txt",
+		"web/app.ts", "web/app.spec.ts", "web/__tests__/app.test.ts",
+		"py/util.py", "py/test_util.py", "py/tests/test_util.py",
+	)
+	p := New()
+	join := func(name string) string { return filepath.Join(root, filepath.FromSlash(name)) }
+
+	tests := p.Tests([]string{join("pkg/foo.go"), join("pkg/bar.go"), join("web/app.ts"), join("py/util.py")})
+	want := []string{
+		join("pkg/foo_test.go"),
+		join("pkg/testdata/golden/out.txt"),
+		join("py/test_util.py"),
+		join("py/tests/test_util.py"),
+		join("web/__tests__/app.test.ts"),
+		join("web/app.spec.ts"),
+	}
+	if !reflect.DeepEqual(tests, want) {
+		t.Errorf("tests:\ngot  %v\nwant %v", tests, want)
+	}
+
+	sources := p.Sources([]string{join("pkg/foo_test.go"), join("web/__tests__/app.test.ts"), join("py/tests/test_util.py")})
+	want = []string{join("pkg/foo.go"), join("py/util.py"), join("web/app.ts")}
+	if !reflect.DeepEqual(sources, want) {
+		t.Errorf("sources:\ngot  %v\nwant %v", sources, want)
+	}
+}
+
+func TestCustomRule(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, "src/api.ts", "src/api.e2e.ts")
+
+	rule, err := ParseRule("*.ts=*.e2e.ts")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := New(rule).Tests([]string{filepath.Join(root, "src", "api.ts")})
+	if len(got) != 1 || got[0] != filepath.Join(root, "src", "api.e2e.ts") {
+		t.Errorf("unexpected pairing: %v", got)
+	}
+}
@@ -0,0 +1,181 @@
+package scanner
+
+import (
+	"bufio"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// Attribute names read from .gitattributes
+const (
+	AttrGenerated    = "linguist-generated"
+	AttrVendored     = "linguist-vendored"
+	AttrExportIgnore = "export-ignore"
+	AttrBinary       = "binary"
+)
+
+// Attributes are the .gitattributes labels that affect scanning and selection
+type Attributes struct {
+	Generated    bool // linguist-generated: produced by a tool, not written by hand
+	Vendored     bool // linguist-vendored: third-party code
+	ExportIgnore bool // export-ignore: left out of release archives
+	Binary       bool // binary: never diffed or merged as text
+}
+
+// Excluded reports whether the file is left out of the default selection
+func (a Attributes) Excluded() bool {
+	return a.Generated || a.Vendored || a.ExportIgnore
+}
+
+// attributeRule is one pattern line of a .gitattributes file. Values are true when
+// set, false when unset, and missing when unspecified (!attr) or not mentioned.
+type attributeRule struct {
+	pattern string
+	values  map[string]*bool
+}
+
+// GitAttributes resolves .gitattributes files at every directory level below a root.
+// Files are loaded on first use and deeper files take precedence, as in git.
+type GitAttributes struct {
+	baseDir string
+	source  *Source
+	mu      sync.Mutex
+	rules   map[string][]attributeRule // Relative slash directory -> rules of its .gitattributes
+}
+
+// NewGitAttributes creates a resolver for the tree rooted at baseDir
+func NewGitAttributes(baseDir string) *GitAttributes {
+	return NewGitAttributesWithSource(baseDir, nil)
+}
+
+// NewGitAttributesWithSource creates a resolver reading .gitattributes files from an archive
+// or git revision
+func NewGitAttributesWithSource(baseDir string, source *Source) *GitAttributes {
+	return &GitAttributes{
+		baseDir: filepath.Clean(baseDir),
+		source:  source,
+		rules:   make(map[string][]attributeRule),
+	}
+}
+
+// Lookup returns the attributes of a file. Paths outside the root have none.
+func (ga *GitAttributes) Lookup(filePath string) Attributes {
+	rel, err := filepath.Rel(ga.baseDir, filePath)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, "../") {
+		return Attributes{}
+	}
+	rel = filepath.ToSlash(rel)
+
+	// Directories from the root down to the file's own directory
+	dirs := []string{"."}
+	for i, c := range rel {
+		if c == '/' {
+			dirs = append(dirs, rel[:i])
+		}
+	}
+
+	values := make(map[string]*bool)
+	for _, dir := range dirs {
+		within := rel
+		if dir != "." {
+			within = strings.TrimPrefix(rel, dir+"/")
+		}
+		for _, rule := range ga.rulesFor(dir) {
+			if !matchAttributePattern(rule.pattern, within) {
+				continue
+			}
+			for name, value := range rule.values {
+				values[name] = value
+			}
+		}
+	}
+
+	isSet := func(name string) bool {
+		return values[name] != nil && *values[name]
+	}
+	isUnset := func(name string) bool {
+		return values[name] != nil && !*values[name]
+	}
+	return Attributes{
+		Generated:    isSet(AttrGenerated),
+		Vendored:     isSet(AttrVendored),
+		ExportIgnore: isSet(AttrExportIgnore),
+		Binary:       isSet(AttrBinary) || isUnset("text") && isUnset("diff"), // binary is the -text -diff macro
+	}
+}
+
+// rulesFor returns the parsed .gitattributes of a directory relative to the root
+func (ga *GitAttributes) rulesFor(dir string) []attributeRule {
+	ga.mu.Lock()
+	defer ga.mu.Unlock()
+
+	if rules, ok := ga.rules[dir]; ok {
+		return rules
+	}
+	rules := loadAttributeRules(ga.source, filepath.Join(ga.baseDir, filepath.FromSlash(dir), ".gitattributes"))
+	ga.rules[dir] = rules
+	return rules
+}
+
+// loadAttributeRules parses a .gitattributes file; a missing or unreadable file has no rules
+func loadAttributeRules(source *Source, filename string) []attributeRule {
+	file, err := source.Open(filename)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var rules []attributeRule
+	lines := bufio.NewScanner(file)
+	for lines.Scan() {
+		if rule, ok := parseAttributeLine(lines.Text()); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// parseAttributeLine parses "pattern attr -attr !attr attr=value". Comments, blank
+// lines, macro definitions and negative patterns (which git rejects) are skipped.
+func parseAttributeLine(line string) (attributeRule, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || strings.HasPrefix(fields[0], "#") ||
+		strings.HasPrefix(fields[0], "[attr]") || strings.HasPrefix(fields[0], "!") {
+		return attributeRule{}, false
+	}
+
+	rule := attributeRule{pattern: fields[0], values: make(map[string]*bool)}
+	for _, field := range fields[1:] {
+		switch {
+		case strings.HasPrefix(field, "-"):
+			rule.values[field[1:]] = boolPtr(false)
+		case strings.HasPrefix(field, "!"):
+			rule.values[field[1:]] = nil
+		default:
+			name, value, hasValue := strings.Cut(field, "=")
+			rule.values[name] = boolPtr(!hasValue || value != "false")
+		}
+	}
+	return rule, true
+}
+
+// matchAttributePattern matches a .gitattributes pattern against a path relative to
+// the directory of the file defining it. Patterns without a slash match the base name
+// at any depth; others are anchored to that directory.
+func matchAttributePattern(pattern, rel string) bool {
+	if !strings.Contains(strings.TrimSuffix(pattern, "/"), "/") {
+		matched, _ := doublestar.Match(pattern, path.Base(rel))
+		return matched
+	}
+	matched, _ := doublestar.Match(strings.TrimPrefix(pattern, "/"), rel)
+	return matched
+}
+
+// boolPtr returns a pointer to a copy of value
+func boolPtr(value bool) *bool {
+	return &value
+}
@@ -0,0 +1,74 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGitAttributes_Lookup(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".gitattributes": "# labels\n" +
+			"*.pb.go linguist-generated=true\n" +
+			"vendor/** linguist-vendored\n" +
+			"/docs/** export-ignore\n" +
+			"*.dat binary\n" +
+			"*.bin -text -diff\n",
+		"web/.gitattributes": "dist/** linguist-generated\n*.pb.go -linguist-generated\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+
+	tests := []struct {
+		path string
+		want Attributes
+	}{
+		{"api/v1/service.pb.go", Attributes{Generated: true}},
+		{"service.go", Attributes{}},
+		{"vendor/github.com/x/y.go", Attributes{Vendored: true}},
+		{"docs/guide.md", Attributes{ExportIgnore: true}},
+		{"sub/docs/guide.md", Attributes{}},
+		{"assets/blob.dat", Attributes{Binary: true}},
+		{"assets/model.bin", Attributes{Binary: true}},
+		{"web/dist/app.js", Attributes{Generated: true}},
+		{"web/api.pb.go", Attributes{}},
+	}
+
+	attributes := NewGitAttributes(root)
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := attributes.Lookup(filepath.Join(root, filepath.FromSlash(tt.path))); got != tt.want {
+				t.Errorf("Lookup() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanDirectory_GitAttributes(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, ".gitattributes"), []byte("gen.go linguist-generated\n*.txt export-ignore\n"), 0644)
+	os.WriteFile(filepath.Join(root, "gen.go"), []byte("package main\n"), 0644)
+	os.WriteFile(filepath.Join(root, "notes.txt"), []byte("notes\n"), 0644)
+
+	nodes, err := NewSimpleConcurrentFileScanner().ScanDirectorySync(context.Background(), root)
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	for _, node := range nodes {
+		switch node.Name {
+		case "gen.go":
+			if !node.IsGenerated {
+				t.Error("gen.go should be marked generated")
+			}
+		case "notes.txt":
+			if !node.IsExportIgnored || node.IsVendored {
+				t.Error("export-ignore files should be marked export-ignored, not vendored")
+			}
+		}
+	}
+}
@@ -1,82 +1,296 @@
 package scanner
 
 import (
-	"os"
+	"bytes"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 
 	"github.com/h2non/filetype"
 )
 
-// BinaryDetector handles binary file detection
+// Binary detection defaults
+const (
+	DefaultSampleSize      = 8 * 1024 // Bytes sampled from the start of each file
+	DefaultMaxControlRatio = 0.10     // Share of control characters above which a sample is binary
+	DefaultMaxInvalidRatio = 0.30     // Share of invalid UTF-8 bytes above which a sample is binary
+)
+
+// lfsPointerPrefix starts every Git LFS pointer file
+const lfsPointerPrefix = "version https://git-lfs.github.com/spec/v1\n"
+
+// BinaryDetectorConfig configures binary detection. Extension lists are added to the
+// built-in ones; zero sizes and ratios use the defaults.
+type BinaryDetectorConfig struct {
+	TextExtensions   []string `json:"text_extensions,omitempty"`   // Always text, e.g. ".svg"
+	BinaryExtensions []string `json:"binary_extensions,omitempty"` // Always binary, without reading the file
+	SampleSize       int      `json:"sample_size,omitempty"`
+	MaxControlRatio  float64  `json:"max_control_ratio,omitempty"`
+	MaxInvalidRatio  float64  `json:"max_invalid_ratio,omitempty"`
+}
+
+// defaultTextExtensions are text formats that magic-byte matching can mistake for binaries
+var defaultTextExtensions = []string{".svg", ".xml", ".html", ".htm", ".xhtml", ".plist", ".rtf"}
+
+// defaultBinaryExtensions are formats that are never useful as text
+var defaultBinaryExtensions = []string{
+	".png", ".jpg", ".jpeg", ".gif", ".bmp", ".ico", ".webp", ".tiff", ".psd",
+	".pdf", ".zip", ".gz", ".tgz", ".bz2", ".xz", ".zst", ".7z", ".rar", ".jar", ".war",
+	".class", ".exe", ".dll", ".so", ".dylib", ".a", ".o", ".obj", ".wasm", ".pyc",
+	".mp3", ".mp4", ".mov", ".avi", ".wav", ".flac", ".ogg", ".webm",
+	".woff", ".woff2", ".ttf", ".otf", ".eot", ".sqlite", ".db",
+}
+
+// Detection describes what the detector found in a file
+type Detection struct {
+	Binary     bool   // Content is not useful as text; true for LFS pointers
+	LFSPointer bool   // The file is a Git LFS pointer whose object is not checked out
+	LFSSize    int64  // Size of the LFS object, from the pointer
+	Reason     string // Why the file was classified, e.g. "extension" or "control characters"
+}
+
+// BinaryDetector classifies files as text or binary from their extension, magic bytes
+// and a content sample. Results are cached by path, size and modification time.
 type BinaryDetector struct {
-	maxFileSize int64
+	maxFileSize      int64
+	sampleSize       int
+	maxControlRatio  float64
+	maxInvalidRatio  float64
+	textExtensions   map[string]bool
+	binaryExtensions map[string]bool
+	cache            sync.Map // path -> cachedDetection
+}
+
+// cachedDetection is a detection result valid while the file is unchanged
+type cachedDetection struct {
+	size      int64
+	modTime   time.Time
+	detection Detection
 }
 
 // NewBinaryDetector creates a new binary detector with default settings
 func NewBinaryDetector() *BinaryDetector {
-	return &BinaryDetector{
-		maxFileSize: MAX_FILE_SIZE_FOR_DETECTION,
-	}
+	return NewBinaryDetectorWithConfig(BinaryDetectorConfig{})
 }
 
-// NewBinaryDetectorWithMaxSize creates a new binary detector with custom max file size
+// NewBinaryDetectorWithMaxSize creates a new binary detector with custom max file size.
+// Large files are still classified from their first bytes; the size is kept for callers
+// that report it.
 func NewBinaryDetectorWithMaxSize(maxSize int64) *BinaryDetector {
-	return &BinaryDetector{
-		maxFileSize: maxSize,
+	detector := NewBinaryDetector()
+	detector.maxFileSize = maxSize
+	return detector
+}
+
+// NewBinaryDetectorWithConfig creates a binary detector with custom lists and thresholds
+func NewBinaryDetectorWithConfig(config BinaryDetectorConfig) *BinaryDetector {
+	bd := &BinaryDetector{
+		maxFileSize:      MAX_FILE_SIZE_FOR_DETECTION,
+		sampleSize:       config.SampleSize,
+		maxControlRatio:  config.MaxControlRatio,
+		maxInvalidRatio:  config.MaxInvalidRatio,
+		textExtensions:   extensionSet(defaultTextExtensions, config.TextExtensions),
+		binaryExtensions: extensionSet(defaultBinaryExtensions, config.BinaryExtensions),
+	}
+	if bd.sampleSize <= 0 {
+		bd.sampleSize = DefaultSampleSize
+	}
+	if bd.maxControlRatio <= 0 {
+		bd.maxControlRatio = DefaultMaxControlRatio
+	}
+	if bd.maxInvalidRatio <= 0 {
+		bd.maxInvalidRatio = DefaultMaxInvalidRatio
 	}
+	// A configured list wins over the built-in opposite list
+	for _, ext := range config.TextExtensions {
+		delete(bd.binaryExtensions, normalizeExtension(ext))
+	}
+	for _, ext := range config.BinaryExtensions {
+		delete(bd.textExtensions, normalizeExtension(ext))
+	}
+	return bd
 }
 
-// IsBinary determines if a file is binary using filetype detection
+var (
+	sharedDetector     *BinaryDetector
+	sharedDetectorOnce sync.Once
+)
+
+// SharedBinaryDetector returns the process-wide default detector, so the scanner, builder
+// and search share one cache of results
+func SharedBinaryDetector() *BinaryDetector {
+	sharedDetectorOnce.Do(func() {
+		sharedDetector = NewBinaryDetector()
+	})
+	return sharedDetector
+}
+
+// extensionSet builds a lookup set from extension lists
+func extensionSet(lists ...[]string) map[string]bool {
+	set := make(map[string]bool)
+	for _, list := range lists {
+		for _, ext := range list {
+			set[normalizeExtension(ext)] = true
+		}
+	}
+	return set
+}
+
+// normalizeExtension lowercases an extension and adds the leading dot
+func normalizeExtension(ext string) string {
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+// IsBinary reports whether a file is binary. Unreadable files are treated as text.
 func (bd *BinaryDetector) IsBinary(path string) bool {
-	// Get file info first to check size
-	info, err := os.Stat(path)
-	if err != nil {
-		// If we can't stat the file, assume it's not binary
-		return false
+	return bd.Detect(path).Binary
+}
+
+// IsBinaryIn reports whether a file of an archive or git revision is binary
+func (bd *BinaryDetector) IsBinaryIn(source *Source, path string) bool {
+	return bd.DetectIn(source, path).Binary
+}
+
+// Detect classifies a file, using the cached result while the file is unchanged
+func (bd *BinaryDetector) Detect(path string) Detection {
+	return bd.DetectIn(nil, path)
+}
+
+// DetectIn classifies a file of an archive or git revision; a nil source reads the disk
+func (bd *BinaryDetector) DetectIn(source *Source, path string) Detection {
+	info, err := source.Stat(path)
+	if err != nil || info.IsDir() {
+		return Detection{}
 	}
 
-	// Skip detection for large files to avoid performance issues
-	if info.Size() > bd.maxFileSize {
-		return false
+	if cached, ok := bd.cache.Load(path); ok {
+		entry := cached.(cachedDetection)
+		if entry.size == info.Size() && entry.modTime.Equal(info.ModTime()) {
+			return entry.detection
+		}
 	}
 
-	file, err := os.Open(path)
+	detection := bd.detect(source, path)
+	bd.cache.Store(path, cachedDetection{size: info.Size(), modTime: info.ModTime(), detection: detection})
+	return detection
+}
+
+// detect classifies a file without the cache
+func (bd *BinaryDetector) detect(source *Source, path string) Detection {
+	ext := strings.ToLower(filepath.Ext(path))
+	if bd.binaryExtensions[ext] {
+		return Detection{Binary: true, Reason: "extension"}
+	}
+
+	file, err := source.Open(path)
 	if err != nil {
-		// If we can't open the file, assume it's not binary
-		return false
+		return Detection{}
 	}
 	defer file.Close()
 
-	// Read first 262 bytes for filetype detection
-	buffer := make([]byte, 262)
-	n, err := file.Read(buffer)
-	if err != nil || n == 0 {
-		return false
+	buffer := make([]byte, bd.sampleSize)
+	n, err := io.ReadFull(file, buffer)
+	if err != nil && err != io.ErrUnexpectedEOF || n == 0 {
+		return Detection{}
 	}
+	sample := buffer[:n]
 
-	// Use filetype library to detect if it's a known binary type
-	kind, err := filetype.Match(buffer[:n])
-	if err != nil {
-		// If filetype detection fails, fall back to simple heuristic
-		return bd.containsNullBytes(buffer[:n])
+	if size, ok := parseLFSPointer(sample); ok {
+		return Detection{Binary: true, LFSPointer: true, LFSSize: size, Reason: "git lfs pointer"}
+	}
+	if bd.textExtensions[ext] {
+		return Detection{Reason: "extension"}
+	}
+
+	// Magic bytes of known binary formats; text formats filetype knows are ignored
+	if kind, err := filetype.Match(sample); err == nil && kind != filetype.Unknown && !isTextMIME(kind.MIME.Value) {
+		return Detection{Binary: true, Reason: "magic bytes"}
 	}
 
-	// If filetype can identify a specific type, it's likely binary
-	if kind != filetype.Unknown {
-		return true
+	enc := DetectEncoding(sample)
+	if enc != EncodingUTF16LE && enc != EncodingUTF16BE {
+		if bd.containsNullBytes(sample) {
+			return Detection{Binary: true, Reason: "null bytes"}
+		}
+		if invalidUTF8Ratio(sample) > bd.maxInvalidRatio {
+			return Detection{Binary: true, Reason: "invalid utf-8"}
+		}
 	}
 
-	// For unknown types, use null byte detection
-	return bd.containsNullBytes(buffer[:n])
+	text, _ := DecodeText(sample)
+	if controlRatio(text) > bd.maxControlRatio {
+		return Detection{Binary: true, Reason: "control characters"}
+	}
+	return Detection{}
 }
 
-// containsNullBytes checks if the buffer contains null bytes (common indicator of binary files)
-func (bd *BinaryDetector) containsNullBytes(buffer []byte) bool {
-	for _, b := range buffer {
-		if b == 0 {
-			return true
+// isTextMIME reports whether a MIME type matched by magic bytes is a text format
+func isTextMIME(mime string) bool {
+	return strings.HasPrefix(mime, "text/") || strings.Contains(mime, "xml") ||
+		strings.Contains(mime, "json") || strings.Contains(mime, "javascript")
+}
+
+// parseLFSPointer recognizes a Git LFS pointer file and returns the object size
+func parseLFSPointer(sample []byte) (int64, bool) {
+	if len(sample) > 1024 || !bytes.HasPrefix(sample, []byte(lfsPointerPrefix)) {
+		return 0, false
+	}
+	for _, line := range strings.Split(string(sample), "\n") {
+		if value, found := strings.CutPrefix(line, "size "); found {
+			if size, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64); err == nil {
+				return size, true
+			}
 		}
 	}
-	return false
+	return 0, true
+}
+
+// invalidUTF8Ratio returns the share of sample bytes that are not valid UTF-8. A
+// multi-byte character cut off at the end of the sample is not counted.
+func invalidUTF8Ratio(sample []byte) float64 {
+	if utf8.Valid(sample) {
+		return 0
+	}
+	invalid := 0
+	for i := 0; i < len(sample); {
+		r, size := utf8.DecodeRune(sample[i:])
+		if r == utf8.RuneError && size == 1 && len(sample)-i >= utf8.UTFMax {
+			invalid++
+		}
+		i += size
+	}
+	return float64(invalid) / float64(len(sample))
+}
+
+// controlRatio returns the share of characters that are control characters other than
+// whitespace and the escape used by colored logs
+func controlRatio(text string) float64 {
+	total, control := 0, 0
+	for _, r := range text {
+		total++
+		switch {
+		case r == '\t' || r == '\n' || r == '\r' || r == '\f' || r == '\v' || r == 0x1b:
+		case r < 0x20 || r == 0x7f:
+			control++
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(control) / float64(total)
+}
+
+// containsNullBytes checks if the buffer contains null bytes (common indicator of binary files)
+func (bd *BinaryDetector) containsNullBytes(buffer []byte) bool {
+	return bytes.IndexByte(buffer, 0) >= 0
 }
 
 // GetMaxFileSize returns the maximum file size for binary detection
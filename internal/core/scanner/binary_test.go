@@ -1,6 +1,7 @@
 package scanner
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
 	"testing"
@@ -128,10 +129,18 @@ func TestBinaryDetector_IsBinary_LargeFile(t *testing.T) {
 		t.Fatalf("failed to create large file: %v", err)
 	}
 
-	// Should return false due to size limit
+	// Large files are classified from their first bytes
 	result := detector.IsBinary(largeFile)
 	if result != false {
-		t.Errorf("expected false for large file, got %v", result)
+		t.Errorf("expected false for large text file, got %v", result)
+	}
+
+	largeBinary := filepath.Join(tempDir, "large.dat")
+	if err := os.WriteFile(largeBinary, append([]byte{0x7f, 'E', 'L', 'F', 0x00}, largeContent...), 0644); err != nil {
+		t.Fatalf("failed to create large binary file: %v", err)
+	}
+	if !detector.IsBinary(largeBinary) {
+		t.Error("expected true for large binary file")
 	}
 }
 
@@ -221,3 +230,80 @@ func TestBinaryDetector_IsBinary_PermissionDenied(t *testing.T) {
 		t.Errorf("expected false for unreadable file, got %v", result)
 	}
 }
+
+func TestBinaryDetector_Detect(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+		return path
+	}
+
+	lfs := "version https://git-lfs.github.com/spec/v1\noid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393\nsize 12345\n"
+	control := bytes.Repeat([]byte("ab\x01\x02\x03\x04"), 100)
+
+	tests := []struct {
+		name       string
+		path       string
+		binary     bool
+		lfsPointer bool
+	}{
+		{"svg is text despite xml magic", write("logo.svg", []byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"></svg>`)), false, false},
+		{"deny-listed extension without reading", write("photo.png", []byte("not really a png")), true, false},
+		{"png magic bytes", write("image", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")), true, false},
+		{"git lfs pointer", write("model.txt", []byte(lfs)), true, true},
+		{"control characters", write("dump.txt", control), true, false},
+		{"colored log output", write("build.log", []byte("\x1b[32mok\x1b[0m  pkg\n\x1b[31mFAIL\x1b[0m other\n")), false, false},
+		{"latin-1 text", write("legacy.c", []byte("/* caf\xe9 cr\xe8me */\n")), false, false},
+	}
+
+	detector := NewBinaryDetector()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detector.Detect(tt.path)
+			if got.Binary != tt.binary || got.LFSPointer != tt.lfsPointer {
+				t.Errorf("Detect() = %+v, want binary=%v lfs=%v", got, tt.binary, tt.lfsPointer)
+			}
+		})
+	}
+
+	if got := detector.Detect(filepath.Join(dir, "model.txt")); got.LFSSize != 12345 {
+		t.Errorf("LFSSize = %d, want 12345", got.LFSSize)
+	}
+}
+
+func TestBinaryDetector_ConfiguredExtensions(t *testing.T) {
+	dir := t.TempDir()
+	data := filepath.Join(dir, "fixture.dat")
+	icon := filepath.Join(dir, "icon.png")
+	os.WriteFile(data, []byte("plain text fixture\n"), 0644)
+	os.WriteFile(icon, []byte("P1\n2 2\n0 1\n1 0\n"), 0644)
+
+	detector := NewBinaryDetectorWithConfig(BinaryDetectorConfig{
+		TextExtensions:   []string{"png"},
+		BinaryExtensions: []string{".DAT"},
+	})
+	if !detector.IsBinary(data) {
+		t.Error("configured binary extension should be binary")
+	}
+	if detector.IsBinary(icon) {
+		t.Error("configured text extension should override the built-in binary list")
+	}
+}
+
+func TestBinaryDetector_CacheInvalidatedOnChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data")
+	os.WriteFile(path, []byte("text for now\n"), 0644)
+
+	detector := NewBinaryDetector()
+	if detector.IsBinary(path) {
+		t.Fatal("expected text before the change")
+	}
+
+	os.WriteFile(path, []byte{0x00, 0x01, 0x02, 0x03, 0x00, 0x00, 0x10, 0x00}, 0644)
+	if !detector.IsBinary(path) {
+		t.Error("cached result should be dropped when the file changes")
+	}
+}
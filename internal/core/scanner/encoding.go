@@ -0,0 +1,157 @@
+package scanner
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// Encoding names a text encoding detected in file contents
+type Encoding string
+
+// Detected encodings
+const (
+	EncodingUTF8        Encoding = "utf-8"
+	EncodingUTF8BOM     Encoding = "utf-8-bom"
+	EncodingUTF16LE     Encoding = "utf-16le"
+	EncodingUTF16BE     Encoding = "utf-16be"
+	EncodingWindows1252 Encoding = "windows-1252" // Latin-1 superset used for legacy sources
+)
+
+// Encoding detection thresholds
+const (
+	utf16ZeroRatio     = 0.4   // Share of code units whose high byte is zero in ASCII-heavy UTF-16
+	utf16StrayZeros    = 0.05  // Share of zeros tolerated in the other byte position
+	invalidUTF8Allowed = 0.001 // Invalid byte share still treated as damaged UTF-8 rather than Latin-1
+)
+
+// DetectEncoding sniffs the encoding of a content sample from byte order marks,
+// the zero-byte pattern of UTF-16 and the share of invalid UTF-8 sequences
+func DetectEncoding(sample []byte) Encoding {
+	switch {
+	case bytes.HasPrefix(sample, []byte{0xEF, 0xBB, 0xBF}):
+		return EncodingUTF8BOM
+	case bytes.HasPrefix(sample, []byte{0xFF, 0xFE}):
+		return EncodingUTF16LE
+	case bytes.HasPrefix(sample, []byte{0xFE, 0xFF}):
+		return EncodingUTF16BE
+	}
+
+	if enc, ok := sniffUTF16(sample); ok {
+		return enc
+	}
+
+	// A sample may end inside a multi-byte character
+	trimmed := sample
+	for i := 0; i < utf8.UTFMax-1 && len(trimmed) > 0 && !utf8.Valid(trimmed); i++ {
+		trimmed = trimmed[:len(trimmed)-1]
+	}
+	if utf8.Valid(trimmed) {
+		return EncodingUTF8
+	}
+
+	invalid, multiByte := 0, 0
+	for i := 0; i < len(sample); {
+		r, size := utf8.DecodeRune(sample[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			invalid++
+		case size > 1:
+			multiByte++
+		}
+		i += size
+	}
+	if multiByte > 0 && float64(invalid)/float64(len(sample)) <= invalidUTF8Allowed {
+		return EncodingUTF8
+	}
+	return EncodingWindows1252
+}
+
+// sniffUTF16 recognizes BOM-less UTF-16 from zeros in every other byte, as text that
+// is mostly ASCII encodes each character with one zero byte
+func sniffUTF16(sample []byte) (Encoding, bool) {
+	units := len(sample) / 2
+	if units < 2 {
+		return "", false
+	}
+
+	evenZeros, oddZeros := 0, 0
+	for i := 0; i+1 < len(sample); i += 2 {
+		if sample[i] == 0 {
+			evenZeros++
+		}
+		if sample[i+1] == 0 {
+			oddZeros++
+		}
+	}
+
+	even, odd := float64(evenZeros)/float64(units), float64(oddZeros)/float64(units)
+	switch {
+	case odd >= utf16ZeroRatio && even <= utf16StrayZeros:
+		return EncodingUTF16LE, true
+	case even >= utf16ZeroRatio && odd <= utf16StrayZeros:
+		return EncodingUTF16BE, true
+	}
+	return "", false
+}
+
+// IsUTF16 reports whether a sample is UTF-16 text, whose zero bytes are not a sign of binary content
+func IsUTF16(sample []byte) bool {
+	enc := DetectEncoding(sample)
+	return enc == EncodingUTF16LE || enc == EncodingUTF16BE
+}
+
+// IsBinaryContent reports whether a sample contains zero bytes that are not UTF-16 text
+func IsBinaryContent(sample []byte) bool {
+	return bytes.IndexByte(sample, 0) >= 0 && !IsUTF16(sample)
+}
+
+// decoder returns the transcoder from enc to UTF-8, or nil when content is already UTF-8
+func (enc Encoding) decoder() *encoding.Decoder {
+	switch enc {
+	case EncodingUTF8BOM:
+		return unicode.UTF8BOM.NewDecoder()
+	case EncodingUTF16LE:
+		return unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewDecoder()
+	case EncodingUTF16BE:
+		return unicode.UTF16(unicode.BigEndian, unicode.UseBOM).NewDecoder()
+	case EncodingWindows1252:
+		return charmap.Windows1252.NewDecoder()
+	}
+	return nil
+}
+
+// DecodeText converts content to valid UTF-8 text, returning the detected encoding.
+// Byte order marks are dropped and invalid UTF-8 sequences become U+FFFD.
+func DecodeText(content []byte) (string, Encoding) {
+	enc := DetectEncoding(content)
+	if decoder := enc.decoder(); decoder != nil {
+		if decoded, err := decoder.Bytes(content); err == nil {
+			return strings.ToValidUTF8(string(decoded), "�"), enc
+		}
+	}
+	return strings.ToValidUTF8(string(content), "�"), EncodingUTF8
+}
+
+// NewDecodingReader wraps r so it yields UTF-8, using the encoding detected in sample,
+// which must be the first bytes r will return
+func NewDecodingReader(r io.Reader, sample []byte) io.Reader {
+	if decoder := DetectEncoding(sample).decoder(); decoder != nil {
+		return transform.NewReader(r, decoder)
+	}
+	return r
+}
+
+// NormalizeNewlines converts CRLF and lone CR line endings to LF
+func NormalizeNewlines(text string) string {
+	if !strings.Contains(text, "\r") {
+		return text
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n")
+}
@@ -0,0 +1,70 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// utf16 encodes ASCII text as UTF-16 in the given byte order
+func utf16(text string, bigEndian bool) []byte {
+	var out []byte
+	for _, c := range []byte(text) {
+		if bigEndian {
+			out = append(out, 0, c)
+		} else {
+			out = append(out, c, 0)
+		}
+	}
+	return out
+}
+
+func TestDetectEncodingAndDecodeText(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+		want    Encoding
+		text    string
+	}{
+		{"plain utf-8", []byte("héllo\n"), EncodingUTF8, "héllo\n"},
+		{"utf-8 bom", append([]byte{0xEF, 0xBB, 0xBF}, "package main\n"...), EncodingUTF8BOM, "package main\n"},
+		{"utf-16le bom", append([]byte{0xFF, 0xFE}, utf16("Set-Item x\r\n", false)...), EncodingUTF16LE, "Set-Item x\r\n"},
+		{"utf-16be bom", append([]byte{0xFE, 0xFF}, utf16("hello", true)...), EncodingUTF16BE, "hello"},
+		{"utf-16le without bom", utf16("[section]\nkey=value\n", false), EncodingUTF16LE, "[section]\nkey=value\n"},
+		{"latin-1", []byte("caf\xe9 cr\xe8me\n"), EncodingWindows1252, "café crème\n"},
+		{"utf-8 cut mid-character", []byte("na\xc3\xafve caf\xc3"), EncodingUTF8, "naïve caf�"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectEncoding(tt.content); got != tt.want {
+				t.Errorf("DetectEncoding() = %s, want %s", got, tt.want)
+			}
+			if text, _ := DecodeText(tt.content); text != tt.text {
+				t.Errorf("DecodeText() = %q, want %q", text, tt.text)
+			}
+		})
+	}
+}
+
+func TestBinaryDetector_UTF16IsText(t *testing.T) {
+	dir := t.TempDir()
+	text := filepath.Join(dir, "notes.txt")
+	binary := filepath.Join(dir, "blob.dat")
+	os.WriteFile(text, append([]byte{0xFF, 0xFE}, utf16("exported from a Windows tool\r\n", false)...), 0644)
+	os.WriteFile(binary, []byte{0x00, 0x01, 0x02, 0x00, 0x7f, 0x00, 0x00, 0x10, 0x00, 0x00}, 0644)
+
+	detector := NewBinaryDetector()
+	if detector.IsBinary(text) {
+		t.Error("UTF-16 text should not be detected as binary")
+	}
+	if !detector.IsBinary(binary) {
+		t.Error("zero-filled data should still be detected as binary")
+	}
+}
+
+func TestNormalizeNewlines(t *testing.T) {
+	if got := NormalizeNewlines("a\r\nb\rc\n"); got != "a\nb\nc\n" {
+		t.Errorf("got %q", got)
+	}
+}
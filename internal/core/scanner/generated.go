@@ -0,0 +1,111 @@
+package scanner
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// generatedHeaderLines is how many leading lines are searched for a generated-code header
+const generatedHeaderLines = 20
+
+// DefaultGeneratedGlobs match file names produced by common code generators
+var DefaultGeneratedGlobs = []string{
+	"*.pb.go", "*.pb.gw.go", "*_grpc.pb.go", "*_string.go", "zz_generated*.go", "*_gen.go",
+	"*.pb.cc", "*.pb.h", "*_pb2.py", "*_pb2_grpc.py", "*_pb.js", "*_pb.d.ts",
+	"*.g.dart", "*.freezed.dart", "*.designer.cs", "*.g.cs",
+}
+
+// defaultGeneratedHeaders match the markers generators write at the top of their output
+var defaultGeneratedHeaders = []string{
+	// Go convention, see go help generate
+	`^// Code generated .* DO NOT EDIT\.$`,
+	// protoc outputs in every language
+	`Generated by the protocol buffer compiler`,
+	// Meta convention, also used by Relay and Buck
+	`@generated\b`,
+	// Common wording of other generators
+	`(?i)\bauto-?generated\b.*\bdo not (edit|modify)`,
+	`(?i)^\W*this file (is|was) (automatically )?generated`,
+}
+
+// GeneratedConfig adds name globs and header regexes to the built-in ones
+type GeneratedConfig struct {
+	Globs   []string `json:"globs,omitempty"`   // Matched against the file name, or the slash path when it contains "/"
+	Headers []string `json:"headers,omitempty"` // Matched against each of the first lines
+}
+
+// GeneratedDetector recognizes generated source files by name and header comment
+type GeneratedDetector struct {
+	globs   []string
+	headers []*regexp.Regexp
+}
+
+// NewGeneratedDetector creates a detector with the built-in rules plus those in config
+func NewGeneratedDetector(config GeneratedConfig) (*GeneratedDetector, error) {
+	detector := &GeneratedDetector{
+		globs: append(append([]string(nil), DefaultGeneratedGlobs...), config.Globs...),
+	}
+	for _, glob := range config.Globs {
+		if !doublestar.ValidatePattern(glob) {
+			return nil, fmt.Errorf("invalid generated file glob %q", glob)
+		}
+	}
+	for _, header := range append(append([]string(nil), defaultGeneratedHeaders...), config.Headers...) {
+		re, err := regexp.Compile(header)
+		if err != nil {
+			return nil, fmt.Errorf("invalid generated header pattern %q: %w", header, err)
+		}
+		detector.headers = append(detector.headers, re)
+	}
+	return detector, nil
+}
+
+// DefaultGeneratedDetector returns a detector with the built-in rules only
+func DefaultGeneratedDetector() *GeneratedDetector {
+	detector, _ := NewGeneratedDetector(GeneratedConfig{})
+	return detector
+}
+
+// IsGenerated reports whether a file name matches a generated glob or one of its first
+// lines matches a generated header. Unreadable files are not generated.
+func (gd *GeneratedDetector) IsGenerated(path string) bool {
+	return gd.IsGeneratedIn(nil, path)
+}
+
+// IsGeneratedIn is IsGenerated for a file of an archive or git revision
+func (gd *GeneratedDetector) IsGeneratedIn(source *Source, path string) bool {
+	slashPath := filepath.ToSlash(path)
+	name := filepath.Base(path)
+	for _, glob := range gd.globs {
+		target := name
+		if strings.Contains(glob, "/") {
+			glob, target = "**/"+strings.TrimPrefix(glob, "/"), slashPath
+		}
+		if matched, _ := doublestar.Match(glob, target); matched {
+			return true
+		}
+	}
+
+	file, err := source.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	lines := bufio.NewScanner(file)
+	lines.Buffer(make([]byte, 0, 4096), 64*1024)
+	for i := 0; i < generatedHeaderLines && lines.Scan(); i++ {
+		line := lines.Bytes()
+		for _, header := range gd.headers {
+			if header.Match(line) {
+				return true
+			}
+		}
+	}
+	return false
+}
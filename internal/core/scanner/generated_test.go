@@ -0,0 +1,58 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGeneratedDetector_IsGenerated(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"handwritten.go":     "package app\n\n// Code generated is discussed in the docs.\nfunc Run() {}\n",
+		"mocks.go":           "// Code generated by MockGen. DO NOT EDIT.\n// Source: store.go\n\npackage app\n",
+		"late_header.go":     "//go:build linux\n\n// Code generated by cgo -godefs; DO NOT EDIT.\n\npackage app\n",
+		"kind_string.go":     "package app\n",
+		"service_pb2.py":     "# -*- coding: utf-8 -*-\n",
+		"schema.graphql.ts":  "/**\n * @generated SignedSource<<abc>>\n */\n",
+		"api/client.gen.ts":  "export const x = 1\n",
+		"protocol.h":         "// Generated by the protocol buffer compiler.  DO NOT EDIT!\n",
+		"resources.Designer": "// This file is written by hand\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+
+	detector, err := NewGeneratedDetector(GeneratedConfig{Globs: []string{"api/*.gen.ts"}})
+	if err != nil {
+		t.Fatalf("NewGeneratedDetector failed: %v", err)
+	}
+
+	tests := map[string]bool{
+		"handwritten.go":     false,
+		"mocks.go":           true,
+		"late_header.go":     true,
+		"kind_string.go":     true,
+		"service_pb2.py":     true,
+		"schema.graphql.ts":  true,
+		"api/client.gen.ts":  true,
+		"protocol.h":         true,
+		"resources.Designer": false,
+	}
+	for name, want := range tests {
+		if got := detector.IsGenerated(filepath.Join(dir, filepath.FromSlash(name))); got != want {
+			t.Errorf("IsGenerated(%s) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestNewGeneratedDetector_InvalidConfig(t *testing.T) {
+	if _, err := NewGeneratedDetector(GeneratedConfig{Headers: []string{"("}}); err == nil {
+		t.Error("expected an error for an invalid header pattern")
+	}
+	if _, err := NewGeneratedDetector(GeneratedConfig{Globs: []string{"[a"}}); err == nil {
+		t.Error("expected an error for an invalid glob")
+	}
+}
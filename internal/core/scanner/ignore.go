@@ -14,13 +14,20 @@ import (
 type Ignorer struct {
 	patterns []string
 	baseDir  string
+	source   *Source
 }
 
 // NewIgnorer creates a new Ignorer for the given directory
 func NewIgnorer(baseDir string) (*Ignorer, error) {
+	return NewIgnorerWithSource(baseDir, nil)
+}
+
+// NewIgnorerWithSource creates an Ignorer reading ignore files from an archive or git revision
+func NewIgnorerWithSource(baseDir string, source *Source) (*Ignorer, error) {
 	ignorer := &Ignorer{
 		baseDir:  filepath.Clean(baseDir),
 		patterns: make([]string, 0),
+		source:   source,
 	}
 
 	// Load .gitignore patterns first
@@ -40,7 +47,7 @@ func NewIgnorer(baseDir string) (*Ignorer, error) {
 func (ig *Ignorer) loadIgnoreFile(filename string) error {
 	ignoreFilePath := filepath.Join(ig.baseDir, filename)
 
-	file, err := os.Open(ignoreFilePath)
+	file, err := ig.source.Open(ignoreFilePath)
 	if err != nil {
 		return err
 	}
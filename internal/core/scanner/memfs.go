@@ -0,0 +1,202 @@
+package scanner
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// memFS is a read-only in-memory filesystem holding the files of an archive or git revision
+type memFS struct {
+	root *memEntry
+}
+
+// memEntry is a file or directory of a memFS; it serves as its own FileInfo and DirEntry
+type memEntry struct {
+	name     string
+	data     []byte
+	open     func() (memReader, error) // Reads the content on demand when data is not held
+	size     int64
+	mode     fs.FileMode
+	modTime  time.Time
+	children map[string]*memEntry // Set for directories only
+}
+
+// memReader is the content of an open memFS file
+type memReader interface {
+	io.Reader
+	io.ReaderAt
+	io.Seeker
+}
+
+// newMemFS creates an empty filesystem
+func newMemFS() *memFS {
+	return &memFS{root: &memEntry{name: ".", mode: fs.ModeDir | 0755, children: make(map[string]*memEntry)}}
+}
+
+// addDir adds a directory and any missing parents
+func (m *memFS) addDir(name string, modTime time.Time) {
+	m.dir(name).modTime = modTime
+}
+
+// addFile adds a regular file, creating missing parent directories
+func (m *memFS) addFile(name string, data []byte, perm fs.FileMode, modTime time.Time) {
+	m.dir(path.Dir(name)).children[path.Base(name)] = &memEntry{
+		name:    path.Base(name),
+		data:    data,
+		size:    int64(len(data)),
+		mode:    perm.Perm(),
+		modTime: modTime,
+	}
+}
+
+// addLazyFile adds a regular file of a known size whose content is read by open each
+// time the file is opened
+func (m *memFS) addLazyFile(name string, size int64, perm fs.FileMode, modTime time.Time, open func() (memReader, error)) {
+	m.dir(path.Dir(name)).children[path.Base(name)] = &memEntry{
+		name:    path.Base(name),
+		open:    open,
+		size:    size,
+		mode:    perm.Perm(),
+		modTime: modTime,
+	}
+}
+
+// dir returns the directory with the given name, creating it and its parents as needed
+func (m *memFS) dir(name string) *memEntry {
+	if name == "." {
+		return m.root
+	}
+	parent := m.dir(path.Dir(name))
+	base := path.Base(name)
+	entry := parent.children[base]
+	if entry == nil || !entry.IsDir() {
+		entry = &memEntry{name: base, mode: fs.ModeDir | 0755, children: make(map[string]*memEntry)}
+		parent.children[base] = entry
+	}
+	return entry
+}
+
+// lookup returns the entry with the given valid name, or nil
+func (m *memFS) lookup(name string) *memEntry {
+	entry := m.root
+	if name == "." {
+		return entry
+	}
+	for _, part := range strings.Split(name, "/") {
+		if entry.children == nil {
+			return nil
+		}
+		if entry = entry.children[part]; entry == nil {
+			return nil
+		}
+	}
+	return entry
+}
+
+// Open implements fs.FS
+func (m *memFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	entry := m.lookup(name)
+	if entry == nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if entry.IsDir() {
+		return &memDir{entry: entry, path: name}, nil
+	}
+	if entry.open != nil {
+		content, err := entry.open()
+		if err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		return &memFile{memReader: content, entry: entry}, nil
+	}
+	return &memFile{memReader: bytes.NewReader(entry.data), entry: entry}, nil
+}
+
+// Name implements fs.FileInfo and fs.DirEntry
+func (e *memEntry) Name() string { return e.name }
+
+// Size implements fs.FileInfo
+func (e *memEntry) Size() int64 { return e.size }
+
+// Mode implements fs.FileInfo
+func (e *memEntry) Mode() fs.FileMode { return e.mode }
+
+// ModTime implements fs.FileInfo
+func (e *memEntry) ModTime() time.Time { return e.modTime }
+
+// IsDir implements fs.FileInfo and fs.DirEntry
+func (e *memEntry) IsDir() bool { return e.mode.IsDir() }
+
+// Sys implements fs.FileInfo
+func (e *memEntry) Sys() any { return nil }
+
+// Type implements fs.DirEntry
+func (e *memEntry) Type() fs.FileMode { return e.mode.Type() }
+
+// Info implements fs.DirEntry
+func (e *memEntry) Info() (fs.FileInfo, error) { return e, nil }
+
+// memFile is an open regular file of a memFS
+type memFile struct {
+	memReader
+	entry *memEntry
+}
+
+// Stat implements fs.File
+func (f *memFile) Stat() (fs.FileInfo, error) { return f.entry, nil }
+
+// Close implements fs.File
+func (f *memFile) Close() error { return nil }
+
+// memDir is an open directory of a memFS
+type memDir struct {
+	entry   *memEntry
+	path    string
+	entries []fs.DirEntry // Sorted children, listed on the first ReadDir
+	offset  int
+}
+
+// Stat implements fs.File
+func (d *memDir) Stat() (fs.FileInfo, error) { return d.entry, nil }
+
+// Close implements fs.File
+func (d *memDir) Close() error { return nil }
+
+// Read implements fs.File; directories cannot be read
+func (d *memDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.path, Err: errors.New("is a directory")}
+}
+
+// ReadDir implements fs.ReadDirFile
+func (d *memDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if d.entries == nil {
+		d.entries = make([]fs.DirEntry, 0, len(d.entry.children))
+		for _, child := range d.entry.children {
+			d.entries = append(d.entries, child)
+		}
+		sort.Slice(d.entries, func(i, j int) bool {
+			return d.entries[i].Name() < d.entries[j].Name()
+		})
+	}
+
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(rest))
+	d.offset += n
+	return rest[:n], nil
+}
@@ -0,0 +1,35 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestMemFS(t *testing.T) {
+	modTime := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	fsys := newMemFS()
+	fsys.addFile("README.md", []byte("# demo\n"), 0644, modTime)
+	fsys.addFile("cmd/app/main.go", []byte("package main\n"), 0755, modTime)
+	fsys.addDir("docs", modTime)
+	opens := 0
+	fsys.addLazyFile("docs/guide.md", 8, 0644, modTime, func() (memReader, error) {
+		opens++
+		return strings.NewReader("# guide\n"), nil
+	})
+
+	if err := fstest.TestFS(fsys, "README.md", "cmd/app/main.go", "docs", "docs/guide.md"); err != nil {
+		t.Fatal(err)
+	}
+
+	if entry := fsys.lookup("cmd/app/main.go"); entry == nil || entry.Size() != 13 || entry.Mode() != 0755 {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+	if opens == 0 || fsys.lookup("docs/guide.md").Size() != 8 {
+		t.Errorf("lazy files should be read on open and report their size, opened %d times", opens)
+	}
+	if fsys.lookup("cmd/missing.go") != nil || fsys.lookup("README.md/x") != nil {
+		t.Error("missing names should not resolve")
+	}
+}
@@ -16,10 +16,12 @@ const (
 
 // Scanner implements the core file scanning functionality with concurrency
 type Scanner struct {
-	ignorer  *Ignorer
-	detector *BinaryDetector
-	workers  int
-	options  ScanOptions
+	ignorer   *Ignorer
+	detector  *BinaryDetector
+	generated *GeneratedDetector
+	source    *Source
+	workers   int
+	options   ScanOptions
 }
 
 // Option defines functional options for Scanner configuration
@@ -70,6 +72,22 @@ func WithBinaryDetector(detector *BinaryDetector) Option {
 	}
 }
 
+// WithGeneratedDetector sets the rules recognizing generated files
+func WithGeneratedDetector(detector *GeneratedDetector) Option {
+	return func(s *Scanner) error {
+		s.generated = detector
+		return nil
+	}
+}
+
+// WithSource scans an archive or git revision instead of the disk
+func WithSource(source *Source) Option {
+	return func(s *Scanner) error {
+		s.source = source
+		return nil
+	}
+}
+
 // WithOptions sets scan options
 func WithOptions(options ScanOptions) Option {
 	return func(s *Scanner) error {
@@ -85,7 +103,14 @@ func WithOptions(options ScanOptions) Option {
 func (s *Scanner) ScanDirectory(ctx context.Context, rootPath string) (<-chan ScanResult, error) {
 	// For now, delegate to the working SimpleConcurrentFileScanner implementation
 	// while maintaining the new functional options interface
-	simpleScanner := NewSimpleConcurrentFileScannerWithOptions(s.options)
+	simpleScanner := NewSimpleConcurrentFileScannerWithOptions(s.options).(*SimpleConcurrentFileScanner)
+	if s.detector != nil {
+		simpleScanner.detector = s.detector
+	}
+	if s.generated != nil {
+		simpleScanner.generated = s.generated
+	}
+	simpleScanner.source = s.source
 	return simpleScanner.ScanDirectory(ctx, rootPath)
 }
 
@@ -114,4 +139,3 @@ func (s *Scanner) ScanDirectorySync(ctx context.Context, rootPath string) ([]*mo
 
 	return results, nil
 }
-
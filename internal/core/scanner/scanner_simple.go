@@ -3,7 +3,7 @@ package scanner
 import (
 	"context"
 	"fmt"
-	"os"
+	"io/fs"
 	"path/filepath"
 	"runtime"
 	"sync"
@@ -13,9 +13,12 @@ import (
 
 // SimpleConcurrentFileScanner is a simpler implementation using goroutines and channels
 type SimpleConcurrentFileScanner struct {
-	options  ScanOptions
-	ignorer  *Ignorer
-	detector *BinaryDetector
+	options    ScanOptions
+	ignorer    *Ignorer
+	detector   *BinaryDetector
+	attributes *GitAttributes
+	generated  *GeneratedDetector
+	source     *Source // Archive or git revision read instead of the disk; nil reads the disk
 }
 
 // NewSimpleConcurrentFileScanner creates a new simple concurrent file scanner
@@ -26,26 +29,23 @@ func NewSimpleConcurrentFileScanner() ScannerInterface {
 // NewSimpleConcurrentFileScannerWithOptions creates a new scanner with custom options
 func NewSimpleConcurrentFileScannerWithOptions(options ScanOptions) ScannerInterface {
 	return &SimpleConcurrentFileScanner{
-		options:  options,
-		ignorer:  nil, // Will be initialized on first scan
-		detector: NewBinaryDetector(),
+		options:   options,
+		ignorer:   nil, // Will be initialized on first scan
+		detector:  SharedBinaryDetector(),
+		generated: DefaultGeneratedDetector(),
 	}
 }
 
 // ScanDirectory implements Scanner.ScanDirectory using a simpler concurrent approach
 func (scfs *SimpleConcurrentFileScanner) ScanDirectory(ctx context.Context, rootPath string) (<-chan ScanResult, error) {
 	// Validate and clean the root path
-	cleanPath := filepath.Clean(rootPath)
-	if !filepath.IsAbs(cleanPath) {
-		var err error
-		cleanPath, err = filepath.Abs(cleanPath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to resolve absolute path for %s: %w", rootPath, err)
-		}
+	cleanPath, err := scfs.source.Resolve(rootPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve absolute path for %s: %w", rootPath, err)
 	}
 
 	// Verify the directory exists
-	info, err := os.Stat(cleanPath)
+	info, err := scfs.source.Stat(cleanPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to stat directory %s: %w", cleanPath, err)
 	}
@@ -55,7 +55,7 @@ func (scfs *SimpleConcurrentFileScanner) ScanDirectory(ctx context.Context, root
 
 	// Initialize ignorer if not set
 	if scfs.ignorer == nil {
-		ignorer, err := NewIgnorer(cleanPath)
+		ignorer, err := NewIgnorerWithSource(cleanPath, scfs.source)
 		if err != nil {
 			// Don't fail if ignorer can't be created, just continue without it
 			scfs.ignorer = nil
@@ -64,6 +64,10 @@ func (scfs *SimpleConcurrentFileScanner) ScanDirectory(ctx context.Context, root
 		}
 	}
 
+	if scfs.options.GitAttributes && scfs.attributes == nil {
+		scfs.attributes = NewGitAttributesWithSource(cleanPath, scfs.source)
+	}
+
 	// Create result channel
 	resultChan := make(chan ScanResult, scfs.options.BufferSize)
 
@@ -154,12 +158,12 @@ func (scfs *SimpleConcurrentFileScanner) discoverPaths(ctx context.Context, path
 	}
 
 	// If this is a directory, discover its children
-	info, err := os.Stat(path)
+	info, err := scfs.source.Stat(path)
 	if err != nil || !info.IsDir() {
 		return
 	}
 
-	entries, err := os.ReadDir(path)
+	entries, err := scfs.source.ReadDir(path)
 	if err != nil {
 		return
 	}
@@ -226,13 +230,13 @@ func (scfs *SimpleConcurrentFileScanner) processPath(ctx context.Context, path s
 	}
 
 	// Get file info
-	info, err := os.Lstat(path) // Use Lstat to not follow symlinks
+	info, err := scfs.source.Lstat(path) // Use Lstat to not follow symlinks
 	if err != nil {
 		return ScanResult{Error: fmt.Errorf("failed to stat %s: %w", path, err)}
 	}
 
 	// Handle symbolic links
-	if info.Mode()&os.ModeSymlink != 0 && !scfs.options.FollowSymlinks {
+	if info.Mode()&fs.ModeSymlink != 0 && !scfs.options.FollowSymlinks {
 		// Skip symbolic links if not following them - return empty result (no error)
 		return ScanResult{}
 	}
@@ -249,7 +253,21 @@ func (scfs *SimpleConcurrentFileScanner) processPath(ctx context.Context, path s
 
 	// Detect binary files for regular files
 	if !node.IsDirectory && scfs.options.DetectBinary && scfs.detector != nil {
-		node.IsBinary = scfs.detector.IsBinary(path)
+		node.IsBinary = scfs.detector.IsBinaryIn(scfs.source, path)
+	}
+
+	// Apply .gitattributes labels; binary there overrides content detection
+	if !node.IsDirectory && scfs.attributes != nil {
+		attrs := scfs.attributes.Lookup(path)
+		node.IsGenerated = attrs.Generated
+		node.IsVendored = attrs.Vendored
+		node.IsExportIgnored = attrs.ExportIgnore
+		node.IsBinary = node.IsBinary || attrs.Binary
+	}
+
+	// Recognize generated code by file name and header comment
+	if !node.IsDirectory && !node.IsBinary && !node.IsGenerated && scfs.options.DetectGenerated && scfs.generated != nil {
+		node.IsGenerated = scfs.generated.IsGeneratedIn(scfs.source, path)
 	}
 
 	return ScanResult{FileNode: node}
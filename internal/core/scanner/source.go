@@ -0,0 +1,421 @@
+package scanner
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Source is a virtual filesystem scanned in place of a directory, such as an archive or
+// a git revision. Its files appear under Root, an absolute path that is not a directory
+// on disk, so they flow through scanning and prompt building like any other path. Paths
+// outside Root, and all paths of a nil Source, are read from the local disk.
+type Source struct {
+	FS      fs.FS
+	Root    string
+	Spec    SourceSpec
+	workDir string    // Directory on disk the source mirrors, for resolving working tree paths
+	closer  io.Closer // Open archive file or git process the files are read from
+}
+
+// Close releases the archive file or git process the source reads from. A nil Source,
+// or one built in memory, has nothing to release.
+func (s *Source) Close() error {
+	if s == nil || s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
+
+// SourceSpec identifies a source so it can be reopened, e.g. when regenerating from a manifest
+type SourceSpec struct {
+	Archive string `json:"archive,omitempty"` // .zip, .tar, .tar.gz or .tgz file
+	Repo    string `json:"repo,omitempty"`    // Top-level directory of a git working tree
+	Rev     string `json:"rev,omitempty"`     // Tree-ish read from Repo, e.g. "v1.2.0"
+}
+
+// Open reads the archive or git revision the spec names
+func (spec SourceSpec) Open() (*Source, error) {
+	switch {
+	case spec.Rev != "":
+		return OpenGitRevision(spec.Repo, spec.Rev)
+	case spec.Archive != "":
+		return OpenArchive(spec.Archive)
+	}
+	return nil, fmt.Errorf("source names neither an archive nor a git revision")
+}
+
+// String describes the spec, e.g. "release.tar.gz" or "v1.2.0"
+func (spec SourceSpec) String() string {
+	if spec.Rev != "" {
+		return spec.Rev
+	}
+	return filepath.Base(spec.Archive)
+}
+
+// archiveExtensions are the archive formats OpenArchive reads
+var archiveExtensions = []string{".zip", ".tar", ".tar.gz", ".tgz"}
+
+// IsArchive reports whether a file name has an extension OpenArchive reads
+func IsArchive(path string) bool {
+	lower := strings.ToLower(path)
+	for _, ext := range archiveExtensions {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenArchive opens a .zip, .tar, .tar.gz or .tgz file. Its files appear under the
+// archive's absolute path, e.g. /downloads/release.tar.gz/src/main.go. Zip and tar files
+// are read on demand and stay open until Close; compressed tar files cannot be read
+// at random, so their contents are decompressed into memory once.
+func OpenArchive(archivePath string) (*Source, error) {
+	abs, err := filepath.Abs(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", archivePath, err)
+	}
+
+	var fsys fs.FS
+	var closer io.Closer
+	switch lower := strings.ToLower(abs); {
+	case strings.HasSuffix(lower, ".zip"):
+		var archive *zip.ReadCloser
+		if archive, err = zip.OpenReader(abs); err == nil {
+			fsys, closer = archive, archive
+		}
+	case strings.HasSuffix(lower, ".tar.gz") || strings.HasSuffix(lower, ".tgz"):
+		fsys, err = readTarGz(abs)
+	case strings.HasSuffix(lower, ".tar"):
+		var file *os.File
+		if file, err = os.Open(abs); err == nil {
+			if fsys, err = readTar(file, file); err != nil {
+				file.Close()
+			}
+			closer = file
+		}
+	default:
+		return nil, fmt.Errorf("unsupported archive %s: expected one of %s", archivePath, strings.Join(archiveExtensions, ", "))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive %s: %w", archivePath, err)
+	}
+
+	return &Source{FS: fsys, Root: abs, Spec: SourceSpec{Archive: abs}, closer: closer}, nil
+}
+
+// readTarGz decompresses a .tar.gz file into memory, streaming it from disk
+func readTarGz(path string) (fs.FS, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(bufio.NewReader(file))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	return readTar(gz, nil)
+}
+
+// readTar loads the directories and regular files of a tar stream. Links and special
+// files are skipped, as symbolic links are when scanning directories. With the archive
+// file at hand, regular files are read from it on Open instead of being held in memory.
+func readTar(r io.Reader, file *os.File) (fs.FS, error) {
+	fsys := newMemFS()
+	archive := tar.NewReader(r)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return fsys, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name := path.Clean(strings.TrimPrefix(header.Name, "/"))
+		if name == "." || !fs.ValidPath(name) {
+			continue
+		}
+
+		switch info := header.FileInfo(); {
+		case info.IsDir():
+			fsys.addDir(name, header.ModTime)
+		case info.Mode().IsRegular() && file != nil && header.Typeflag == tar.TypeReg:
+			// The reader stops at the start of the entry's content
+			offset, err := file.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", header.Name, err)
+			}
+			size := header.Size
+			fsys.addLazyFile(name, size, info.Mode(), header.ModTime, func() (memReader, error) {
+				return io.NewSectionReader(file, offset, size), nil
+			})
+		case info.Mode().IsRegular():
+			data, err := io.ReadAll(archive)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", header.Name, err)
+			}
+			fsys.addFile(name, data, info.Mode(), header.ModTime)
+		}
+	}
+}
+
+// OpenGitRevision reads the files of a tree-ish (commit, tag, branch or tree) from the git
+// repository containing dir, using the local git binary. Its files appear under
+// "<repository>@<rev>", and working tree paths resolve to the same files in the revision.
+// Only the file list is read up front; contents come from one git process on demand,
+// which runs until Close.
+func OpenGitRevision(dir, rev string) (*Source, error) {
+	if rev == "" || strings.HasPrefix(rev, "-") {
+		return nil, fmt.Errorf("invalid git revision %q", rev)
+	}
+	if dir == "" {
+		dir = "."
+	}
+
+	top, err := git(dir, nil, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	repo := filepath.Clean(strings.TrimSpace(string(top)))
+
+	listing, err := git(repo, nil, "ls-tree", "-r", "-l", "-z", "--full-tree", rev)
+	if err != nil {
+		return nil, err
+	}
+
+	// Files take the commit time, as checking the revision out would give them
+	var modTime time.Time
+	if out, err := git(repo, nil, "log", "-1", "--format=%ct", rev); err == nil {
+		if seconds, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64); err == nil {
+			modTime = time.Unix(seconds, 0).UTC()
+		}
+	}
+
+	// Entries are "<mode> <type> <object> <size>\t<path>"; submodules and symbolic links are skipped
+	blobs := &gitBlobs{repo: repo}
+	fsys := newMemFS()
+	for _, entry := range strings.Split(strings.TrimSuffix(string(listing), "\x00"), "\x00") {
+		meta, name, ok := strings.Cut(entry, "\t")
+		fields := strings.Fields(meta)
+		if !ok || len(fields) != 4 || fields[1] != "blob" || fields[0] == "120000" || !fs.ValidPath(name) {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			continue
+		}
+		mode := fs.FileMode(0644)
+		if fields[0] == "100755" {
+			mode = 0755
+		}
+		object := fields[2]
+		fsys.addLazyFile(name, size, mode, modTime, func() (memReader, error) {
+			data, err := blobs.read(object)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s at %s: %w", name, rev, err)
+			}
+			return bytes.NewReader(data), nil
+		})
+	}
+
+	root := repo + "@" + strings.NewReplacer("/", "-", "\\", "-", ":", "-").Replace(rev)
+	return &Source{FS: fsys, Root: root, Spec: SourceSpec{Repo: repo, Rev: rev}, workDir: repo, closer: blobs}, nil
+}
+
+// gitBlobs reads blobs through a git cat-file --batch process, started on the first read
+type gitBlobs struct {
+	repo string
+	mu   sync.Mutex
+	cmd  *exec.Cmd
+	in   io.WriteCloser
+	out  *bufio.Reader
+}
+
+// read returns the content of a blob
+func (g *gitBlobs) read(object string) ([]byte, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.cmd == nil {
+		cmd := exec.Command("git", "-C", g.repo, "cat-file", "--batch")
+		in, err := cmd.StdinPipe()
+		if err != nil {
+			return nil, fmt.Errorf("git cat-file: %w", err)
+		}
+		out, err := cmd.StdoutPipe()
+		if err != nil {
+			return nil, fmt.Errorf("git cat-file: %w", err)
+		}
+		if err := cmd.Start(); err != nil {
+			return nil, fmt.Errorf("git cat-file: %w", err)
+		}
+		g.cmd, g.in, g.out = cmd, in, bufio.NewReaderSize(out, 64*1024)
+	}
+
+	if _, err := io.WriteString(g.in, object+"\n"); err != nil {
+		g.stop()
+		return nil, fmt.Errorf("git cat-file: %w", err)
+	}
+	data, err := readBatchObject(g.out)
+	if err != nil {
+		g.stop() // The output is out of step with the requests; restart on the next read
+	}
+	return data, err
+}
+
+// Close stops the git process
+func (g *gitBlobs) Close() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.stop()
+}
+
+// stop closes the process's input and waits for it to exit; g.mu must be held
+func (g *gitBlobs) stop() error {
+	if g.cmd == nil {
+		return nil
+	}
+	g.in.Close()
+	err := g.cmd.Wait()
+	g.cmd, g.in, g.out = nil, nil, nil
+	return err
+}
+
+// readBatchObject reads one "<object> <type> <size>\n<content>\n" record of git cat-file --batch
+func readBatchObject(r *bufio.Reader) ([]byte, error) {
+	header, err := r.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("truncated git output: %w", err)
+	}
+
+	fields := strings.Fields(header)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("unexpected git output %q", strings.TrimSpace(header))
+	}
+	size, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("unexpected git output %q", strings.TrimSpace(header))
+	}
+
+	data := make([]byte, size+1) // Content and its trailing newline
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("truncated git output: %w", err)
+	}
+	return data[:size], nil
+}
+
+// git runs a git command in dir and returns its standard output
+func git(dir string, stdin io.Reader, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Stdin = stdin
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], message)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
+
+// Resolve maps a path given on the command line to its path in the source. Paths in
+// the working tree the source mirrors map to the same place below Root; other relative
+// paths are relative to Root. A nil Source returns the absolute path.
+func (s *Source) Resolve(p string) (string, error) {
+	if s == nil {
+		return filepath.Abs(p)
+	}
+	if _, ok := s.name(p); ok && filepath.IsAbs(p) {
+		return filepath.Clean(p), nil
+	}
+
+	if s.workDir != "" {
+		if abs, err := filepath.Abs(p); err == nil {
+			if rel, err := filepath.Rel(s.workDir, abs); err == nil && !isOutside(rel) {
+				return filepath.Join(s.Root, rel), nil
+			}
+		}
+	}
+	if filepath.IsAbs(p) || isOutside(filepath.Clean(p)) {
+		return "", fmt.Errorf("%s is outside %s", p, s.Spec)
+	}
+	return filepath.Join(s.Root, p), nil
+}
+
+// name returns the filesystem name of a path below Root
+func (s *Source) name(p string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(s.Root, p)
+	if err != nil || isOutside(rel) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// isOutside reports whether a relative path leaves its base directory
+func isOutside(rel string) bool {
+	return rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Stat returns the file info of a path, following symbolic links on disk
+func (s *Source) Stat(p string) (fs.FileInfo, error) {
+	if name, ok := s.name(p); ok {
+		return fs.Stat(s.FS, name)
+	}
+	return os.Stat(p)
+}
+
+// Lstat returns the file info of a path without following symbolic links
+func (s *Source) Lstat(p string) (fs.FileInfo, error) {
+	if name, ok := s.name(p); ok {
+		return fs.Stat(s.FS, name)
+	}
+	return os.Lstat(p)
+}
+
+// Open opens a file for reading
+func (s *Source) Open(p string) (fs.File, error) {
+	if name, ok := s.name(p); ok {
+		return s.FS.Open(name)
+	}
+	return os.Open(p)
+}
+
+// ReadFile reads a whole file
+func (s *Source) ReadFile(p string) ([]byte, error) {
+	if name, ok := s.name(p); ok {
+		return fs.ReadFile(s.FS, name)
+	}
+	return os.ReadFile(p)
+}
+
+// ReadDir lists a directory sorted by name
+func (s *Source) ReadDir(p string) ([]fs.DirEntry, error) {
+	if name, ok := s.name(p); ok {
+		return fs.ReadDir(s.FS, name)
+	}
+	return os.ReadDir(p)
+}
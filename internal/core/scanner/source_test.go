@@ -0,0 +1,280 @@
+package scanner
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// sourceFiles is the tree written into test archives
+var sourceFiles = map[string]string{
+	".gitignore":        "*.log\n",
+	".gitattributes":    "api/*.pb.go linguist-generated\n",
+	"src/main.go":       "package main\n",
+	"api/service.pb.go": "package api\n",
+	"debug.log":         "noise\n",
+	"logo.png":          "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR",
+}
+
+// writeTarGz writes files into a .tar.gz archive
+func writeTarGz(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+
+	gz := gzip.NewWriter(out)
+	writeTarEntries(t, gz, files)
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeTar writes files into an uncompressed .tar archive
+func writeTar(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	writeTarEntries(t, out, files)
+}
+
+// writeTarEntries writes files as a tar stream
+func writeTarEntries(t *testing.T, w io.Writer, files map[string]string) {
+	t.Helper()
+	archive := tar.NewWriter(w)
+	for name, content := range files {
+		header := &tar.Header{Name: "./" + name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := archive.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := archive.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeZip writes files into a .zip archive
+func writeZip(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+
+	archive := zip.NewWriter(out)
+	for name, content := range files {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenArchive_Scan(t *testing.T) {
+	dir := t.TempDir()
+	writers := map[string]func(*testing.T, string, map[string]string){
+		"release.tar.gz": writeTarGz,
+		"release.tar":    writeTar,
+		"release.zip":    writeZip,
+	}
+
+	for name, write := range writers {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			write(t, path, sourceFiles)
+
+			source, err := OpenArchive(path)
+			if err != nil {
+				t.Fatalf("OpenArchive failed: %v", err)
+			}
+			defer source.Close()
+			if source.Root != path {
+				t.Errorf("Root = %s, want %s", source.Root, path)
+			}
+
+			scanner, err := New(WithSource(source))
+			if err != nil {
+				t.Fatal(err)
+			}
+			nodes, err := scanner.ScanDirectorySync(context.Background(), source.Root)
+			if err != nil {
+				t.Fatalf("scan failed: %v", err)
+			}
+
+			found := make(map[string]bool)
+			var files []string
+			for _, node := range nodes {
+				rel, _ := filepath.Rel(source.Root, node.Path)
+				rel = filepath.ToSlash(rel)
+				if !node.IsDirectory {
+					files = append(files, rel)
+				}
+				found[rel] = true
+				switch rel {
+				case "logo.png":
+					if !node.IsBinary {
+						t.Error("logo.png should be detected as binary")
+					}
+				case "api/service.pb.go":
+					if !node.IsGenerated {
+						t.Error("service.pb.go should be marked generated")
+					}
+				case "src/main.go":
+					if node.IsBinary || node.IsGenerated || node.Size != int64(len(sourceFiles[rel])) {
+						t.Errorf("unexpected main.go node %+v", node)
+					}
+				}
+			}
+
+			sort.Strings(files)
+			if got := strings.Join(files, ","); got != ".gitattributes,.gitignore,api/service.pb.go,logo.png,src/main.go" {
+				t.Errorf("scanned files = %s", got)
+			}
+			if !found["src"] {
+				t.Error("directories should be scanned")
+			}
+			if data, err := source.ReadFile(filepath.Join(path, "api", "service.pb.go")); err != nil || string(data) != sourceFiles["api/service.pb.go"] {
+				t.Errorf("ReadFile = %q, %v", data, err)
+			}
+		})
+	}
+}
+
+func TestOpenArchive_Unsupported(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "release.rar")
+	os.WriteFile(path, []byte("Rar!"), 0644)
+
+	if _, err := OpenArchive(path); err == nil {
+		t.Error("expected an error for an unsupported archive")
+	}
+	if IsArchive(path) || !IsArchive("release.TGZ") {
+		t.Error("IsArchive should match archive extensions case-insensitively")
+	}
+}
+
+func TestSource_Resolve(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "release.zip")
+	source := &Source{FS: newMemFS(), Root: root}
+
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{".", root, false},
+		{"src/main.go", filepath.Join(root, "src", "main.go"), false},
+		{filepath.Join(root, "src"), filepath.Join(root, "src"), false},
+		{"../outside.go", "", true},
+		{"/etc/passwd", "", true},
+	}
+	for _, tt := range tests {
+		got, err := source.Resolve(tt.path)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("Resolve(%q) = %q, %v; want %q", tt.path, got, err, tt.want)
+		}
+	}
+
+	var disk *Source
+	if got, err := disk.Resolve("."); err != nil || !filepath.IsAbs(got) {
+		t.Errorf("nil source should resolve to absolute paths, got %q, %v", got, err)
+	}
+}
+
+func TestOpenGitRevision(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repo := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(repo, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run("init", "-q")
+	write("cmd/app/main.go", "package main // v1\n")
+	write("README.md", "# v1\n")
+	run("add", ".")
+	run("commit", "-q", "-m", "v1")
+	run("tag", "v1.0.0")
+	write("cmd/app/main.go", "package main // v2\n")
+	write("NEW.md", "added later\n")
+	run("commit", "-q", "-am", "v2")
+
+	source, err := OpenGitRevision(filepath.Join(repo, "cmd"), "v1.0.0")
+	if err != nil {
+		t.Fatalf("OpenGitRevision failed: %v", err)
+	}
+	defer source.Close()
+
+	// Working tree paths resolve to the same file in the revision
+	path, err := source.Resolve(filepath.Join(repo, "cmd", "app", "main.go"))
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	data, err := source.ReadFile(path)
+	if err != nil || string(data) != "package main // v1\n" {
+		t.Errorf("ReadFile = %q, %v", data, err)
+	}
+	if info, err := source.Stat(path); err != nil || info.ModTime().IsZero() || info.Size() != int64(len(data)) {
+		t.Errorf("files should carry their size and the commit time, got %v", err)
+	}
+
+	// Contents are read on demand, and again after the git process is stopped
+	if err := source.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+	if data, err := source.ReadFile(filepath.Join(source.Root, "README.md")); err != nil || string(data) != "# v1\n" {
+		t.Errorf("ReadFile after Close = %q, %v", data, err)
+	}
+
+	if _, err := source.Stat(filepath.Join(source.Root, "NEW.md")); err == nil {
+		t.Error("files added after the revision should not exist")
+	}
+	if _, err := OpenGitRevision(repo, "no-such-rev"); err == nil {
+		t.Error("expected an error for an unknown revision")
+	}
+	if _, err := OpenGitRevision(repo, "--output=x"); err == nil {
+		t.Error("expected an error for a revision that looks like an option")
+	}
+
+	reopened, err := source.Spec.Open()
+	if err != nil || reopened.Root != source.Root {
+		t.Errorf("Spec.Open() = %v, %v", reopened, err)
+	}
+}
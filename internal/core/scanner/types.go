@@ -38,6 +38,12 @@ type ScanOptions struct {
 	// DetectBinary enables binary file detection
 	DetectBinary bool
 
+	// GitAttributes marks files from .gitattributes as generated, vendored or binary
+	GitAttributes bool
+
+	// DetectGenerated marks files with generated-code names or headers as generated
+	DetectGenerated bool
+
 	// BufferSize sets the channel buffer size for streaming results
 	BufferSize int
 
@@ -51,12 +57,14 @@ type ScanOptions struct {
 // DefaultScanOptions returns sensible default scanning options
 func DefaultScanOptions() ScanOptions {
 	return ScanOptions{
-		MaxDepth:       0, // Unlimited
-		FollowSymlinks: false,
-		DetectBinary:   true,
-		BufferSize:     100,
-		WorkerCount:    0, // Use runtime.NumCPU()
-		Timeout:        5 * time.Minute,
+		MaxDepth:        0, // Unlimited
+		FollowSymlinks:  false,
+		DetectBinary:    true,
+		GitAttributes:   true,
+		DetectGenerated: true,
+		BufferSize:      100,
+		WorkerCount:     0, // Use runtime.NumCPU()
+		Timeout:         5 * time.Minute,
 	}
 }
 
@@ -0,0 +1,215 @@
+package search
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/diogopedro/shotgun/internal/core/scanner"
+)
+
+const (
+	// DefaultMaxPreview is the number of matching lines kept per file
+	DefaultMaxPreview = 3
+	// maxPreviewWidth truncates long preview lines
+	maxPreviewWidth = 160
+	// maxLineSize is the longest line the searcher will read
+	maxLineSize = 1024 * 1024
+)
+
+// Query describes what to search for
+type Query struct {
+	Pattern    string
+	Literal    bool // Match the pattern as plain text instead of a regular expression
+	IgnoreCase bool
+}
+
+// Compile turns the query into a regular expression
+func (q Query) Compile() (*regexp.Regexp, error) {
+	if q.Pattern == "" {
+		return nil, fmt.Errorf("search pattern cannot be empty")
+	}
+
+	pattern := q.Pattern
+	if q.Literal {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if q.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid search pattern: %w", err)
+	}
+	return re, nil
+}
+
+// Line is a matching line with its 1-based number
+type Line struct {
+	Number int
+	Text   string
+}
+
+// Result holds the matches found in one file
+type Result struct {
+	Path    string
+	Hits    int    // Number of matching lines
+	Preview []Line // First matching lines, truncated for display
+}
+
+// Searcher runs content searches over text files concurrently
+type Searcher struct {
+	workers        int
+	maxPreview     int
+	binaryDetector *scanner.BinaryDetector
+}
+
+// Option configures a Searcher
+type Option func(*Searcher)
+
+// WithWorkers sets the number of files searched in parallel
+func WithWorkers(workers int) Option {
+	return func(s *Searcher) {
+		if workers > 0 {
+			s.workers = workers
+		}
+	}
+}
+
+// WithMaxPreview sets how many matching lines are kept per file
+func WithMaxPreview(lines int) Option {
+	return func(s *Searcher) {
+		if lines >= 0 {
+			s.maxPreview = lines
+		}
+	}
+}
+
+// WithBinaryDetector shares a binary detector with the searcher
+func WithBinaryDetector(detector *scanner.BinaryDetector) Option {
+	return func(s *Searcher) {
+		if detector != nil {
+			s.binaryDetector = detector
+		}
+	}
+}
+
+// New creates a searcher
+func New(opts ...Option) *Searcher {
+	s := &Searcher{
+		workers:        runtime.NumCPU(),
+		maxPreview:     DefaultMaxPreview,
+		binaryDetector: scanner.SharedBinaryDetector(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Search looks for the query in files, skipping binaries and unreadable files.
+// Results contain only files with at least one hit, sorted by path.
+func (s *Searcher) Search(ctx context.Context, files []string, query Query) ([]Result, error) {
+	re, err := query.Compile()
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(chan string)
+	results := make(chan Result)
+
+	var wg sync.WaitGroup
+	for i := 0; i < s.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				if result, ok := s.searchFile(path, re); ok {
+					select {
+					case results <- result:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(paths)
+		for _, path := range files {
+			select {
+			case paths <- path:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var found []Result
+	for result := range results {
+		found = append(found, result)
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	sort.Slice(found, func(i, j int) bool { return found[i].Path < found[j].Path })
+	return found, nil
+}
+
+// searchFile scans a single file line by line
+func (s *Searcher) searchFile(path string, re *regexp.Regexp) (Result, bool) {
+	if s.binaryDetector.IsBinary(path) {
+		return Result{}, false
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return Result{}, false
+	}
+	defer file.Close()
+
+	result := Result{Path: path}
+	lines := bufio.NewScanner(file)
+	lines.Buffer(make([]byte, 64*1024), maxLineSize)
+	for number := 1; lines.Scan(); number++ {
+		line := lines.Bytes()
+		if !re.Match(line) {
+			continue
+		}
+
+		result.Hits++
+		if len(result.Preview) < s.maxPreview {
+			result.Preview = append(result.Preview, Line{Number: number, Text: previewText(string(line))})
+		}
+	}
+
+	return result, result.Hits > 0
+}
+
+// previewText trims and shortens a line for display
+func previewText(line string) string {
+	line = strings.TrimSpace(line)
+	if len(line) > maxPreviewWidth {
+		cut := maxPreviewWidth
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		line = line[:cut] + "…"
+	}
+	return line
+}
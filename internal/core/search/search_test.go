@@ -0,0 +1,69 @@
+package search
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, dir, name string, content []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSearch(t *testing.T) {
+	dir := t.TempDir()
+	a := writeFile(t, dir, "a.go", []byte("package a\n\nfunc Open() error {\n\treturn ErrTimeout\n}\n\nvar ErrTimeout = errors.New(\"timeout\")\n"))
+	b := writeFile(t, dir, "b.txt", []byte("no match here\n"))
+	bin := writeFile(t, dir, "c.bin", []byte("ErrTimeout\x00\x01\x02"))
+
+	results, err := New(WithWorkers(2)).Search(context.Background(), []string{b, bin, a}, Query{Pattern: `Err\w+`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Path != a {
+		t.Fatalf("expected a single hit in a.go (binaries skipped), got %+v", results)
+	}
+	if results[0].Hits != 2 || results[0].Preview[0].Number != 4 || results[0].Preview[0].Text != "return ErrTimeout" {
+		t.Errorf("unexpected result: %+v", results[0])
+	}
+}
+
+func TestSearch_LiteralAndCase(t *testing.T) {
+	dir := t.TempDir()
+	f := writeFile(t, dir, "f.txt", []byte("value (x+1)\nVALUE (X+1)\n"))
+
+	results, err := New().Search(context.Background(), []string{f}, Query{Pattern: "(x+1)", Literal: true})
+	if err != nil || len(results) != 1 || results[0].Hits != 1 {
+		t.Fatalf("literal search: %+v, %v", results, err)
+	}
+
+	results, _ = New().Search(context.Background(), []string{f}, Query{Pattern: "(x+1)", Literal: true, IgnoreCase: true})
+	if len(results) != 1 || results[0].Hits != 2 {
+		t.Errorf("case-insensitive search: %+v", results)
+	}
+
+	if _, err := New().Search(context.Background(), []string{f}, Query{Pattern: "(x+1"}); err == nil {
+		t.Error("expected invalid regex error")
+	}
+}
+
+func TestSearch_PreviewLimit(t *testing.T) {
+	dir := t.TempDir()
+	f := writeFile(t, dir, "many.txt", []byte(strings.Repeat("hit "+strings.Repeat("x", 200)+"\n", 10)))
+
+	results, err := New(WithMaxPreview(2)).Search(context.Background(), []string{f}, Query{Pattern: "hit"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := results[0]
+	if r.Hits != 10 || len(r.Preview) != 2 || !strings.HasSuffix(r.Preview[0].Text, "…") {
+		t.Errorf("unexpected preview: hits=%d preview=%d", r.Hits, len(r.Preview))
+	}
+}
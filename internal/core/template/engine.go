@@ -194,9 +194,11 @@ func (e *templateEngine) prepareVariables(tmpl *models.Template, userVars map[st
 	// Add auto-populated variables
 	processedVars["CURRENT_DATE"] = time.Now().Format("2006-01-02")
 
-	// Get project name from current directory
-	if wd, err := os.Getwd(); err == nil {
-		processedVars["PROJECT_NAME"] = filepath.Base(wd)
+	// Get project name from current directory unless the caller knows the project roots
+	if _, ok := processedVars["PROJECT_NAME"]; !ok {
+		if wd, err := os.Getwd(); err == nil {
+			processedVars["PROJECT_NAME"] = filepath.Base(wd)
+		}
 	}
 
 	// Validate required variables in strict mode
@@ -232,9 +234,11 @@ func (e *templateEngine) prepareVariablesWithFiles(tmpl *models.Template, userVa
 	// Add auto-populated variables
 	processedVars["CURRENT_DATE"] = time.Now().Format("2006-01-02")
 
-	// Get project name from current directory
-	if wd, err := os.Getwd(); err == nil {
-		processedVars["PROJECT_NAME"] = filepath.Base(wd)
+	// Get project name from current directory unless the caller knows the project roots
+	if _, ok := processedVars["PROJECT_NAME"]; !ok {
+		if wd, err := os.Getwd(); err == nil {
+			processedVars["PROJECT_NAME"] = filepath.Base(wd)
+		}
 	}
 
 	// Generate FILE_STRUCTURE if selected files provided and not already set
@@ -28,6 +28,8 @@ func parseTemplateFromData(data []byte) (*models.Template, error) {
 		Tags        []string                `toml:"tags"`
 		Variables   map[string]tomlVariable `toml:"variables"`
 		Content     string                  `toml:"content"`
+		Compaction  []string                `toml:"compaction"`
+		Annotations []string                `toml:"annotations"`
 	}
 
 	// Parse TOML data
@@ -45,6 +47,8 @@ func parseTemplateFromData(data []byte) (*models.Template, error) {
 		Tags:        rawTemplate.Tags,
 		Variables:   make(map[string]models.Variable),
 		Content:     rawTemplate.Content,
+		Compaction:  rawTemplate.Compaction,
+		Annotations: rawTemplate.Annotations,
 	}
 
 	// Convert variables
@@ -28,6 +28,16 @@ func validateTemplate(template *models.Template) error {
 		return err
 	}
 
+	// Validate compaction modes
+	if _, err := models.NormalizeCompaction(template.Compaction); err != nil {
+		return fmt.Errorf("invalid template compaction: %w", err)
+	}
+
+	// Validate annotations
+	if _, err := models.NormalizeAnnotations(template.Annotations); err != nil {
+		return fmt.Errorf("invalid template annotations: %w", err)
+	}
+
 	return nil
 }
 
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Annotation names accepted in templates and on the command line
+const (
+	AnnotateLineNumbers = "line-numbers"
+	AnnotateHeader      = "header"
+)
+
+// Gutter styles for line numbers
+const (
+	GutterPipe  = "pipe"  // "  12 | code"
+	GutterColon = "colon" // "  12: code"
+	GutterPlain = "plain" // "  12  code"
+)
+
+// AnnotationSpec is a parsed annotation such as "line-numbers=colon"
+type AnnotationSpec struct {
+	Name   string
+	Gutter string // Set for line numbers only; empty means the default style
+}
+
+// NormalizeAnnotations lowercases and validates annotation specs, dropping "none" and blanks
+func NormalizeAnnotations(specs []string) ([]AnnotationSpec, error) {
+	var normalized []AnnotationSpec
+	for _, spec := range specs {
+		name, gutter, _ := strings.Cut(strings.TrimSpace(strings.ToLower(spec)), "=")
+		switch name {
+		case AnnotateLineNumbers:
+			switch gutter {
+			case "", GutterPipe, GutterColon, GutterPlain:
+			default:
+				return nil, fmt.Errorf("unknown gutter style %q", gutter)
+			}
+		case AnnotateHeader:
+		case "", "none":
+			continue
+		default:
+			return nil, fmt.Errorf("unknown annotation %q", spec)
+		}
+		normalized = append(normalized, AnnotationSpec{Name: name, Gutter: gutter})
+	}
+	return normalized, nil
+}
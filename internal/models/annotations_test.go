@@ -0,0 +1,19 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeAnnotations(t *testing.T) {
+	got, err := NormalizeAnnotations([]string{"LINE-NUMBERS=colon", "header", "none"})
+	want := []AnnotationSpec{{Name: AnnotateLineNumbers, Gutter: GutterColon}, {Name: AnnotateHeader}}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("NormalizeAnnotations = %v, %v", got, err)
+	}
+	for _, bad := range []string{"line-numbers=dots", "footer"} {
+		if _, err := NormalizeAnnotations([]string{bad}); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
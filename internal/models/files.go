@@ -6,15 +6,97 @@ import (
 
 // FileNode represents a file or directory in the project structure
 type FileNode struct {
-	Path        string      `json:"path"`
-	Name        string      `json:"name"`
-	IsDirectory bool        `json:"is_directory"`
-	IsSelected  bool        `json:"is_selected"`
-	IsIgnored   bool        `json:"is_ignored"`
-	IsBinary    bool        `json:"is_binary"`
-	IsExpanded  bool        `json:"is_expanded"`
-	Size        int64       `json:"size"`
-	ModTime     time.Time   `json:"mod_time"`
-	Children    []*FileNode `json:"children,omitempty"`
-	Parent      *FileNode   `json:"-"`
+	Path            string      `json:"path"`
+	Name            string      `json:"name"`
+	IsDirectory     bool        `json:"is_directory"`
+	IsSelected      bool        `json:"is_selected"`
+	TreeOnly        bool        `json:"tree_only,omitempty"` // Selected for the structure only, without content
+	IsIgnored       bool        `json:"is_ignored"`
+	IsBinary        bool        `json:"is_binary"`
+	IsGenerated     bool        `json:"is_generated,omitempty"`      // Generated code, by .gitattributes, file name or header
+	IsVendored      bool        `json:"is_vendored,omitempty"`       // Marked linguist-vendored in .gitattributes
+	IsExportIgnored bool        `json:"is_export_ignored,omitempty"` // Marked export-ignore in .gitattributes
+	IsExpanded      bool        `json:"is_expanded"`
+	RenderMode      RenderMode  `json:"render_mode,omitempty"`
+	Size            int64       `json:"size"`
+	ModTime         time.Time   `json:"mod_time"`
+	Children        []*FileNode `json:"children,omitempty"`
+	Parent          *FileNode   `json:"-"`
+}
+
+// Selection is the tri-state inclusion of a node in the prompt
+type Selection int
+
+const (
+	SelectionExcluded Selection = iota // Left out of the prompt
+	SelectionFull                      // Listed with content, rendered per RenderMode
+	SelectionTreeOnly                  // Listed in the structure without content
+)
+
+// Next cycles full → tree-only → excluded → full
+func (s Selection) Next() Selection {
+	switch s {
+	case SelectionFull:
+		return SelectionTreeOnly
+	case SelectionTreeOnly:
+		return SelectionExcluded
+	default:
+		return SelectionFull
+	}
+}
+
+// Selection returns the node's tri-state selection
+func (n *FileNode) Selection() Selection {
+	switch {
+	case !n.IsSelected:
+		return SelectionExcluded
+	case n.TreeOnly:
+		return SelectionTreeOnly
+	default:
+		return SelectionFull
+	}
+}
+
+// SetSelection updates IsSelected and TreeOnly to match s
+func (n *FileNode) SetSelection(s Selection) {
+	n.IsSelected = s != SelectionExcluded
+	n.TreeOnly = s == SelectionTreeOnly
+}
+
+// IsDefaultExcluded reports whether the node is generated, vendored or export-ignored,
+// which leaves it out of the default selection
+func (n *FileNode) IsDefaultExcluded() bool {
+	return n.IsGenerated || n.IsVendored || n.IsExportIgnored
+}
+
+// EffectiveRenderMode returns the mode used in the prompt; tree-only nodes are always path-only
+func (n *FileNode) EffectiveRenderMode() RenderMode {
+	if n.TreeOnly {
+		return RenderPathOnly
+	}
+	if n.RenderMode == "" {
+		return RenderFull
+	}
+	return n.RenderMode
+}
+
+// RenderMode controls how a selected file's content is embedded in the prompt
+type RenderMode string
+
+const (
+	RenderFull     RenderMode = "full"      // Full file content (the default)
+	RenderOutline  RenderMode = "outline"   // Declarations and signatures only (Go files)
+	RenderPathOnly RenderMode = "path-only" // Listed in the tree without content
+)
+
+// Next cycles full → outline → path-only → full
+func (m RenderMode) Next() RenderMode {
+	switch m {
+	case RenderOutline:
+		return RenderPathOnly
+	case RenderPathOnly:
+		return RenderFull
+	default:
+		return RenderOutline
+	}
 }
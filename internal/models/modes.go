@@ -0,0 +1,35 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Compaction mode names accepted in templates and on the command line
+const (
+	CompactStripComments = "strip-comments"
+	CompactCollapseBlank = "collapse-blank"
+	CompactDropLicense   = "drop-license"
+	CompactTrimTrailing  = "trim-trailing"
+)
+
+// CompactionModes lists every compaction mode in canonical order
+var CompactionModes = []string{CompactDropLicense, CompactStripComments, CompactTrimTrailing, CompactCollapseBlank}
+
+// NormalizeCompaction lowercases and validates compaction mode names, expanding "all"
+// and dropping "none" and blanks
+func NormalizeCompaction(modes []string) ([]string, error) {
+	var normalized []string
+	for _, mode := range modes {
+		switch name := strings.TrimSpace(strings.ToLower(mode)); name {
+		case CompactStripComments, CompactCollapseBlank, CompactDropLicense, CompactTrimTrailing:
+			normalized = append(normalized, name)
+		case "all":
+			normalized = append(normalized, CompactionModes...)
+		case "", "none":
+		default:
+			return nil, fmt.Errorf("unknown compaction mode %q", mode)
+		}
+	}
+	return normalized, nil
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeCompaction(t *testing.T) {
+	got, err := NormalizeCompaction([]string{" Strip-Comments ", "none", ""})
+	if err != nil || !reflect.DeepEqual(got, []string{CompactStripComments}) {
+		t.Errorf("NormalizeCompaction = %v, %v", got, err)
+	}
+	if got, _ := NormalizeCompaction([]string{"all"}); !reflect.DeepEqual(got, CompactionModes) {
+		t.Errorf("all = %v, want %v", got, CompactionModes)
+	}
+	if _, err := NormalizeCompaction([]string{"minify"}); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
@@ -10,6 +10,8 @@ type Template struct {
 	Tags        []string            `toml:"tags" json:"tags"`
 	Variables   map[string]Variable `toml:"variables" json:"variables"`
 	Content     string              `toml:"content" json:"content"`
+	Compaction  []string            `toml:"compaction,omitempty" json:"compaction,omitempty"`   // Default content compaction modes
+	Annotations []string            `toml:"annotations,omitempty" json:"annotations,omitempty"` // Default line numbers and file headers
 }
 
 // Variable represents a template variable with validation constraints
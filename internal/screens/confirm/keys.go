@@ -4,14 +4,23 @@ import "github.com/charmbracelet/bubbles/key"
 
 // KeyMap defines key bindings for the confirmation screen
 type KeyMap struct {
-	Up       key.Binding
-	Down     key.Binding
-	Edit     key.Binding
-	Generate key.Binding
-	VimUp    key.Binding
-	VimDown  key.Binding
-	Help     key.Binding
-	Quit     key.Binding
+	Up        key.Binding
+	Down      key.Binding
+	Edit      key.Binding
+	Generate  key.Binding
+	Manifest  key.Binding
+	Split     key.Binding
+	Compact   key.Binding
+	Anonymize key.Binding
+	Annotate  key.Binding
+	Transform key.Binding
+	Truncate  key.Binding
+	Tree      key.Binding
+	Newlines  key.Binding
+	VimUp     key.Binding
+	VimDown   key.Binding
+	Help      key.Binding
+	Quit      key.Binding
 }
 
 // DefaultKeyMap returns the default key mappings for confirmation screen
@@ -33,6 +42,42 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("alt+c"),
 			key.WithHelp("Alt+C", "generate prompt"),
 		),
+		Manifest: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", "toggle manifest"),
+		),
+		Split: key.NewBinding(
+			key.WithKeys("p"),
+			key.WithHelp("p", "cycle split into parts"),
+		),
+		Compact: key.NewBinding(
+			key.WithKeys("c"),
+			key.WithHelp("c", "toggle compaction"),
+		),
+		Anonymize: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "toggle anonymization"),
+		),
+		Annotate: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "toggle line numbers and headers"),
+		),
+		Transform: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", "toggle content transformers"),
+		),
+		Truncate: key.NewBinding(
+			key.WithKeys("l"),
+			key.WithHelp("l", "cycle large-file truncation"),
+		),
+		Tree: key.NewBinding(
+			key.WithKeys("u"),
+			key.WithHelp("u", "toggle unicode tree"),
+		),
+		Newlines: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "toggle crlf normalization"),
+		),
 		VimUp: key.NewBinding(
 			key.WithKeys("k"),
 			key.WithHelp("k", "move up (vim)"),
@@ -61,6 +106,6 @@ func (k KeyMap) ShortHelp() []key.Binding {
 func (k KeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{k.Up, k.Down, k.VimUp, k.VimDown},
-		{k.Edit, k.Generate, k.Help, k.Quit},
+		{k.Edit, k.Generate, k.Manifest, k.Split, k.Compact, k.Anonymize, k.Annotate, k.Transform, k.Truncate, k.Tree, k.Newlines, k.Help, k.Quit},
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"github.com/charmbracelet/lipgloss"
 
 	"github.com/diogopedro/shotgun/internal/components/progress"
+	"github.com/diogopedro/shotgun/internal/core/builder"
 	"github.com/diogopedro/shotgun/internal/models"
 )
 
@@ -32,10 +33,22 @@ type ConfirmModel struct {
 	progress      progress.Model
 	progressMgr   *ProgressManager
 	sizeBreakdown SizeBreakdown
+	fileSavings   []builder.FileSavings
 
 	// Output configuration
 	outputFilename string
 	outputPath     string
+	emitManifest   bool
+	splitIndex     int                // Index into splitTokenLimits; 0 disables splitting
+	compaction     builder.Compaction // Content transforms for this run
+	renderModes    map[string]models.RenderMode
+	slices         map[string][]builder.Slice
+	paths          builder.PathOptions // Display paths and anonymization for this run
+	annotations    builder.Annotations // Line numbers and file headers for this run
+	transformers   bool                // Replace notebooks, data files, lockfiles and minified files
+	truncation     builder.Truncation  // How oversized files are cut down for this run
+	treeFormat     builder.TreeFormat  // File tree drawing for this run
+	normalizeLines bool                // Convert CRLF line endings to LF for this run
 
 	// UI state
 	viewport     viewport.Model
@@ -57,6 +70,9 @@ type SizeBreakdown struct {
 	FileContentSize int64
 	TreeStructSize  int64
 	OverheadSize    int64
+	SavedSize       int64 // Bytes removed by compaction
+	TruncatedFiles  int   // Oversized files cut down by truncation
+	TruncatedSize   int64 // Bytes omitted from truncated files
 }
 
 // NewConfirmModel creates a new confirmation screen model
@@ -77,6 +93,7 @@ func NewConfirmModel() ConfirmModel {
 		viewport:    vp,
 		ready:       false,
 		keyMap:      DefaultKeyMap(),
+		treeFormat:  builder.DefaultTreeFormat,
 	}
 }
 
@@ -119,6 +136,25 @@ func (m *ConfirmModel) SetData(template *models.Template, selectedFiles []string
 	m.taskContent = taskContent
 	m.rulesContent = rulesContent
 	m.ready = true
+
+	// Start from the template's compaction defaults
+	m.compaction = builder.Compaction{}
+	if template != nil {
+		if compaction, err := builder.ParseCompaction(template.Compaction); err == nil {
+			m.compaction = compaction
+		}
+	}
+
+	// Transformers are on unless switched off for this run
+	m.transformers = true
+
+	// Likewise for line numbers and file headers
+	m.annotations = builder.Annotations{}
+	if template != nil {
+		if annotations, err := builder.ParseAnnotations(template.Annotations); err == nil {
+			m.annotations = annotations
+		}
+	}
 }
 
 // IsReady returns whether the model has been populated with data
@@ -182,6 +218,182 @@ func (m *ConfirmModel) SetOutputFilename(filename string) {
 	m.outputFilename = filename
 }
 
+// ToggleManifest toggles writing a reproducibility manifest alongside the prompt
+func (m *ConfirmModel) ToggleManifest() {
+	m.emitManifest = !m.emitManifest
+}
+
+// ManifestEnabled returns whether a manifest will be written with the prompt
+func (m *ConfirmModel) ManifestEnabled() bool {
+	return m.emitManifest
+}
+
+// splitTokenLimits are the per-part token limits cycled by the split key (0 = off)
+var splitTokenLimits = []int64{0, 32_000, 100_000, 200_000}
+
+// CycleSplitLimit advances to the next split-into-parts limit
+func (m *ConfirmModel) CycleSplitLimit() {
+	m.splitIndex = (m.splitIndex + 1) % len(splitTokenLimits)
+}
+
+// SplitTokenLimit returns the per-part token limit, or 0 when splitting is off
+func (m *ConfirmModel) SplitTokenLimit() int64 {
+	return splitTokenLimits[m.splitIndex]
+}
+
+// ChunkConfig returns the chunking configuration for generation, or nil when splitting is off
+func (m *ConfirmModel) ChunkConfig() *builder.ChunkConfig {
+	limit := m.SplitTokenLimit()
+	if limit == 0 {
+		return nil
+	}
+	return &builder.ChunkConfig{MaxTokens: limit}
+}
+
+// ToggleCompaction switches compaction off, or on with the template's modes (all modes if it has none)
+func (m *ConfirmModel) ToggleCompaction() {
+	if m.compaction.Enabled() {
+		m.compaction = builder.Compaction{}
+		return
+	}
+
+	m.compaction = builder.FullCompaction()
+	if m.template != nil && len(m.template.Compaction) > 0 {
+		if compaction, err := builder.ParseCompaction(m.template.Compaction); err == nil {
+			m.compaction = compaction
+		}
+	}
+}
+
+// ToggleAnnotations switches annotations off, or on with the template's choices
+// (line numbers and headers if it has none)
+func (m *ConfirmModel) ToggleAnnotations() {
+	if m.annotations.Enabled() {
+		m.annotations = builder.Annotations{}
+		return
+	}
+
+	m.annotations = builder.Annotations{LineNumbers: true, Header: true}
+	if m.template != nil && len(m.template.Annotations) > 0 {
+		if annotations, err := builder.ParseAnnotations(m.template.Annotations); err == nil {
+			m.annotations = annotations
+		}
+	}
+}
+
+// ToggleTransformers switches the per-file-type content transformers
+func (m *ConfirmModel) ToggleTransformers() {
+	m.transformers = !m.transformers
+}
+
+// Transformers returns the content transformers selected for this run
+func (m *ConfirmModel) Transformers() builder.Transformers {
+	if !m.transformers {
+		return nil
+	}
+	return builder.DefaultTransformers()
+}
+
+// truncationStrategies lists the strategies cycled for oversized files; the first keeps the placeholder
+var truncationStrategies = []string{"", builder.TruncateHeadTail, builder.TruncateHead, builder.TruncateKeywords}
+
+// CycleTruncation advances to the next strategy for files above the size limit
+func (m *ConfirmModel) CycleTruncation() {
+	next := 0
+	for i, strategy := range truncationStrategies {
+		if strategy == m.truncation.Strategy {
+			next = (i + 1) % len(truncationStrategies)
+		}
+	}
+	m.truncation, _ = builder.ParseTruncation(truncationStrategies[next])
+}
+
+// Truncation returns how oversized files are cut down, with keywords taken from the task
+func (m *ConfirmModel) Truncation() builder.Truncation {
+	return m.truncation.ForTask(m.taskContent)
+}
+
+// ToggleTreeStyle switches the file tree between ASCII and box-drawing characters
+func (m *ConfirmModel) ToggleTreeStyle() {
+	m.treeFormat.UseUnicode = !m.treeFormat.UseUnicode
+}
+
+// TreeFormat returns the file tree formatting selected for this run
+func (m *ConfirmModel) TreeFormat() *builder.TreeFormat {
+	format := m.treeFormat
+	return &format
+}
+
+// ToggleNormalizeNewlines switches conversion of CRLF line endings to LF
+func (m *ConfirmModel) ToggleNormalizeNewlines() {
+	m.normalizeLines = !m.normalizeLines
+}
+
+// NormalizeNewlines reports whether CRLF line endings are converted to LF for this run
+func (m *ConfirmModel) NormalizeNewlines() bool {
+	return m.normalizeLines
+}
+
+// Annotations returns the line-number and header settings selected for this run
+func (m *ConfirmModel) Annotations() builder.Annotations {
+	return m.annotations
+}
+
+// SetRenderModes sets the per-file render modes chosen in the file tree
+func (m *ConfirmModel) SetRenderModes(modes map[string]models.RenderMode) {
+	m.renderModes = modes
+}
+
+// SetSlices sets the per-file line ranges and symbols to render
+func (m *ConfirmModel) SetSlices(slices map[string][]builder.Slice) {
+	m.slices = slices
+}
+
+// SetPathRoot sets the project root that prompt paths are rendered relative to
+func (m *ConfirmModel) SetPathRoot(root string) {
+	m.paths.Root = root
+	m.paths.Roots = nil
+}
+
+// SetPathRoots sets the project roots; with several, prompt paths start with each root's label
+func (m *ConfirmModel) SetPathRoots(roots []string) {
+	if len(roots) == 1 {
+		m.SetPathRoot(roots[0])
+		return
+	}
+	m.paths.Root = ""
+	m.paths.Roots = roots
+}
+
+// ToggleAnonymize switches scrubbing of the local username, hostname and home directory
+func (m *ConfirmModel) ToggleAnonymize() {
+	m.paths.Anonymize = !m.paths.Anonymize
+}
+
+// PathOptions returns how paths are rendered and whether contents are anonymized
+func (m *ConfirmModel) PathOptions() builder.PathOptions {
+	return m.paths
+}
+
+// estimationSettings returns the per-run rendering settings that affect the size estimate
+func (m *ConfirmModel) estimationSettings() EstimationSettings {
+	return EstimationSettings{
+		Compaction:   m.compaction,
+		RenderModes:  m.renderModes,
+		Slices:       m.slices,
+		Paths:        m.paths,
+		Annotations:  m.annotations,
+		Transformers: m.Transformers(),
+		Truncation:   m.Truncation(),
+		Newlines:     m.normalizeLines,
+	}
+}
+
+// Compaction returns the content transforms selected for this run
+func (m *ConfirmModel) Compaction() builder.Compaction {
+	return m.compaction
+}
+
 // GetOutputFilename returns the current output filename
 func (m *ConfirmModel) GetOutputFilename() string {
 	return m.outputFilename
@@ -220,3 +220,34 @@ func TestIsCalculating(t *testing.T) {
 		t.Error("Expected IsCalculating to be false after SetEstimatedSize")
 	}
 }
+
+func TestTreeAndNewlineSettings(t *testing.T) {
+	model := NewConfirmModel()
+
+	if model.TreeFormat().UseUnicode {
+		t.Error("Expected the tree to use ASCII characters by default")
+	}
+	if model.NormalizeNewlines() {
+		t.Error("Expected newline normalization to be off by default")
+	}
+
+	model.ToggleTreeStyle()
+	model.ToggleNormalizeNewlines()
+
+	format := model.TreeFormat()
+	if !format.UseUnicode {
+		t.Error("Expected the tree to use box-drawing characters after toggling")
+	}
+	if format.IndentSize != 4 {
+		t.Errorf("Expected the default indent to be kept, got %d", format.IndentSize)
+	}
+	if !model.NormalizeNewlines() || !model.estimationSettings().Newlines {
+		t.Error("Expected newline normalization to be on and used for the estimate")
+	}
+
+	// The returned format is a copy
+	format.UseUnicode = false
+	if !model.TreeFormat().UseUnicode {
+		t.Error("Expected changes to the returned format not to affect the model")
+	}
+}
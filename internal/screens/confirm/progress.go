@@ -9,6 +9,7 @@ import (
 	"github.com/diogopedro/shotgun/internal/components/common"
 	"github.com/diogopedro/shotgun/internal/components/progress"
 	"github.com/diogopedro/shotgun/internal/components/spinner"
+	"github.com/diogopedro/shotgun/internal/core/builder"
 )
 
 // ProgressState represents the current state of size calculation
@@ -25,9 +26,10 @@ type ProgressMsg ProgressState
 
 // SizeCalculationCompleteMsg is sent when calculation is done
 type SizeCalculationCompleteMsg struct {
-	TotalSize int64
-	Breakdown SizeBreakdown
-	Error     error
+	TotalSize   int64
+	Breakdown   SizeBreakdown
+	FileSavings []builder.FileSavings // Per-file compaction savings, largest first
+	Error       error
 }
 
 // CancellationMsg is sent when user cancels calculation
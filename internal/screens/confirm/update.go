@@ -39,6 +39,60 @@ func (m ConfirmModel) Update(msg tea.Msg) (ConfirmModel, tea.Cmd) {
 			}
 			return m, NavigateToExitCmd()
 
+		case "m":
+			// Toggle manifest output
+			m.ToggleManifest()
+
+		case "p":
+			// Cycle the split-into-parts limit
+			m.CycleSplitLimit()
+
+		case "c":
+			// Toggle compaction and re-estimate the output size
+			if !m.calculating {
+				m.ToggleCompaction()
+				return m, func() tea.Msg { return SizeCalculationStartMsg{} }
+			}
+
+		case "a":
+			// Toggle anonymization and re-estimate the output size
+			if !m.calculating {
+				m.ToggleAnonymize()
+				return m, func() tea.Msg { return SizeCalculationStartMsg{} }
+			}
+
+		case "n":
+			// Toggle line numbers and file headers and re-estimate the output size
+			if !m.calculating {
+				m.ToggleAnnotations()
+				return m, func() tea.Msg { return SizeCalculationStartMsg{} }
+			}
+
+		case "t":
+			// Toggle content transformers and re-estimate the output size
+			if !m.calculating {
+				m.ToggleTransformers()
+				return m, func() tea.Msg { return SizeCalculationStartMsg{} }
+			}
+
+		case "l":
+			// Cycle the large-file truncation strategy and re-estimate the output size
+			if !m.calculating {
+				m.CycleTruncation()
+				return m, func() tea.Msg { return SizeCalculationStartMsg{} }
+			}
+
+		case "u":
+			// Switch between ASCII and box-drawing tree characters
+			m.ToggleTreeStyle()
+
+		case "r":
+			// Toggle CRLF normalization and re-estimate the output size
+			if !m.calculating {
+				m.ToggleNormalizeNewlines()
+				return m, func() tea.Msg { return SizeCalculationStartMsg{} }
+			}
+
 		case "up", "k":
 			// Scroll viewport up
 			m.viewport.LineUp(1)
@@ -80,6 +134,7 @@ func (m ConfirmModel) Update(msg tea.Msg) (ConfirmModel, tea.Cmd) {
 			m.calculating = false
 		} else {
 			m.SetEstimatedSize(msg.TotalSize, msg.Breakdown)
+			m.fileSavings = msg.FileSavings
 			// Complete progress manager
 			if m.progressMgr != nil {
 				completeCmd := m.progressMgr.CompleteProgress()
@@ -95,7 +150,7 @@ func (m ConfirmModel) Update(msg tea.Msg) (ConfirmModel, tea.Cmd) {
 		// Start progress tracking with estimated file count
 		m.progressMgr.StartProgress(len(m.selectedFiles) + 3) // files + template + task + rules
 		ctx := m.progressMgr.GetContext()
-		cmds = append(cmds, CalculateSizeWithProgressCmd(ctx, m.selectedFiles, m.template, m.taskContent, m.rulesContent))
+		cmds = append(cmds, CalculateSizeWithProgressCmd(ctx, m.selectedFiles, m.template, m.taskContent, m.rulesContent, m.estimationSettings()))
 
 	case CancellationMsg:
 		// Handle cancelled calculation
@@ -193,7 +248,7 @@ func CancelSizeCalculationCmd() tea.Cmd {
 }
 
 // CalculateSizeWithProgressCmd performs size calculation with progress updates
-func CalculateSizeWithProgressCmd(ctx context.Context, selectedFiles []string, template *models.Template, taskContent, rulesContent string) tea.Cmd {
+func CalculateSizeWithProgressCmd(ctx context.Context, selectedFiles []string, template *models.Template, taskContent, rulesContent string, settings EstimationSettings) tea.Cmd {
 	return tea.Sequence(
 		// Start progress indicator
 		func() tea.Msg {
@@ -207,11 +262,23 @@ func CalculateSizeWithProgressCmd(ctx context.Context, selectedFiles []string, t
 		},
 		// Perform calculation with progress updates
 		func() tea.Msg {
-			return calculateSizeWithProgress(ctx, selectedFiles, template, taskContent, rulesContent)
+			return calculateSizeWithProgress(ctx, selectedFiles, template, taskContent, rulesContent, settings)
 		},
 	)
 }
 
+// EstimationSettings carries the per-run rendering choices that change the output size
+type EstimationSettings struct {
+	Compaction   builder.Compaction
+	RenderModes  map[string]models.RenderMode
+	Slices       map[string][]builder.Slice
+	Paths        builder.PathOptions
+	Annotations  builder.Annotations
+	Transformers builder.Transformers
+	Truncation   builder.Truncation
+	Newlines     bool // Convert CRLF line endings to LF
+}
+
 // templateEngineAdapter adapts the template engine to the builder interface
 type templateEngineAdapter struct {
 	engine template.TemplateEngine
@@ -228,7 +295,7 @@ func (a *templateEngineAdapter) ProcessTemplate(tmpl *models.Template, variables
 }
 
 // calculateSizeWithProgress performs the actual size calculation with progress updates
-func calculateSizeWithProgress(ctx context.Context, selectedFiles []string, templateModel *models.Template, taskContent, rulesContent string) tea.Msg {
+func calculateSizeWithProgress(ctx context.Context, selectedFiles []string, templateModel *models.Template, taskContent, rulesContent string, settings EstimationSettings) tea.Msg {
 	// Create template engine adapter and estimator
 	templateEngine := template.NewTemplateEngine()
 	adapter := &templateEngineAdapter{engine: templateEngine}
@@ -246,6 +313,15 @@ func calculateSizeWithProgress(ctx context.Context, selectedFiles []string, temp
 		Variables:     variables,
 		SelectedFiles: selectedFiles,
 		IncludeTree:   true,
+		Compaction:    settings.Compaction,
+		RenderModes:   settings.RenderModes,
+		Slices:        settings.Slices,
+		Paths:         settings.Paths,
+		Annotations:   settings.Annotations,
+		Transformers:  settings.Transformers,
+		Truncation:    settings.Truncation,
+
+		NormalizeNewlines: settings.Newlines,
 	}
 
 	// Perform estimation with progress callback
@@ -262,12 +338,16 @@ func calculateSizeWithProgress(ctx context.Context, selectedFiles []string, temp
 		FileContentSize: estimate.FileContentSize,
 		TreeStructSize:  estimate.TreeStructSize,
 		OverheadSize:    estimate.OverheadSize,
+		SavedSize:       estimate.SavedSize,
+		TruncatedFiles:  estimate.TruncatedFiles,
+		TruncatedSize:   estimate.TruncatedSize,
 	}
 
 	return SizeCalculationCompleteMsg{
-		TotalSize: estimate.TotalSize,
-		Breakdown: breakdown,
-		Error:     nil,
+		TotalSize:   estimate.TotalSize,
+		Breakdown:   breakdown,
+		FileSavings: estimate.FileSavings,
+		Error:       nil,
 	}
 }
 
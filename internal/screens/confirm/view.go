@@ -6,6 +6,8 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/diogopedro/shotgun/internal/core/builder"
 )
 
 var (
@@ -198,6 +200,17 @@ func (m ConfirmModel) renderSizeEstimation() string {
 	content.WriteString(fmt.Sprintf("Formatting Overhead: %s\n", formatBytes(m.sizeBreakdown.OverheadSize)))
 	content.WriteString("\n")
 
+	// Truncated large files
+	if m.sizeBreakdown.TruncatedFiles > 0 {
+		content.WriteString(fmt.Sprintf("Truncated: %d large files (%s), %s omitted\n",
+			m.sizeBreakdown.TruncatedFiles, m.truncation.Strategy, formatBytes(m.sizeBreakdown.TruncatedSize)))
+	}
+
+	// Compaction savings
+	if m.compaction.Enabled() {
+		content.WriteString(m.renderCompactionSavings())
+	}
+
 	// Total size with color coding
 	totalSizeStr := fmt.Sprintf("Total Estimated Size: %s", formatBytes(m.estimatedSize))
 	switch m.warningLevel {
@@ -216,11 +229,85 @@ func (m ConfirmModel) renderSizeEstimation() string {
 	// Output filename
 	if m.outputFilename != "" {
 		content.WriteString(fmt.Sprintf("Output File: %s", m.outputFilename))
+		if m.emitManifest {
+			content.WriteString(fmt.Sprintf("\nManifest: %s", m.outputFilename+builder.ManifestSuffix))
+		}
+	}
+
+	// Anonymization
+	if m.paths.Anonymize {
+		content.WriteString("\nAnonymized: username, hostname and home directory scrubbed from contents")
+	}
+
+	// Line numbers and file headers
+	if specs := m.annotations.Specs(); len(specs) > 0 {
+		content.WriteString("\nAnnotations: " + strings.Join(specs, ", "))
+	}
+
+	// Content transformers
+	if !m.transformers {
+		content.WriteString("\nTransformers: off (notebooks, data files, lockfiles and minified files kept in full)")
+	}
+
+	// Large-file truncation
+	if truncation := m.Truncation(); truncation.Enabled() {
+		content.WriteString("\nLarge files: " + truncation.Strategy)
+		if truncation.Strategy == builder.TruncateKeywords {
+			if len(truncation.Keywords) == 0 {
+				content.WriteString(" (no task keywords; first lines kept)")
+			} else {
+				content.WriteString(" (" + strings.Join(truncation.Keywords, ", ") + ")")
+			}
+		}
+	}
+
+	// File tree characters
+	if m.treeFormat.UseUnicode {
+		content.WriteString("\nTree: box-drawing characters")
+	}
+
+	// Line endings
+	if m.normalizeLines {
+		content.WriteString("\nLine endings: CRLF converted to LF")
+	}
+
+	// Split into parts
+	if limit := m.SplitTokenLimit(); limit > 0 {
+		limitBytes := limit * builder.BytesPerToken
+		parts := (m.estimatedSize + limitBytes - 1) / limitBytes
+		if parts < 1 {
+			parts = 1
+		}
+		content.WriteString(fmt.Sprintf("\nSplit: parts of ≤ %dk tokens (~%d parts)", limit/1000, parts))
 	}
 
 	return sizeStyle.Render(content.String())
 }
 
+// renderCompactionSavings renders the per-file savings from compaction
+func (m ConfirmModel) renderCompactionSavings() string {
+	var content strings.Builder
+
+	content.WriteString(fmt.Sprintf("Compaction (%s): saved %s (~%d tokens) in %d files\n",
+		strings.Join(m.compaction.Modes(), ", "), formatBytes(m.sizeBreakdown.SavedSize),
+		builder.EstimateTokens(m.sizeBreakdown.SavedSize), len(m.fileSavings)))
+
+	maxFiles := 5
+	if len(m.fileSavings) < maxFiles {
+		maxFiles = len(m.fileSavings)
+	}
+	for _, saving := range m.fileSavings[:maxFiles] {
+		content.WriteString(fmt.Sprintf("  • %s: -%s (~%d tokens)\n",
+			filepath.Base(saving.Path), formatBytes(saving.SavedBytes()), saving.SavedTokens()))
+	}
+	if len(m.fileSavings) > maxFiles {
+		content.WriteString(fmt.Sprintf("  ... and %d more files\n", len(m.fileSavings)-maxFiles))
+	}
+	content.WriteString("\n")
+
+	return content.String()
+}
+
 // renderWarningSection renders size warnings if applicable
 func (m ConfirmModel) renderWarningSection() string {
 	var content strings.Builder
@@ -264,6 +351,15 @@ func (m ConfirmModel) renderNavigationHelp() string {
 	help := []string{
 		"Alt+C: Confirm and generate prompt",
 		"Ctrl+Left: Return to rules input",
+		"M: Toggle manifest",
+		"P: Split into parts",
+		"C: Toggle compaction",
+		"A: Toggle anonymization",
+		"N: Toggle line numbers",
+		"T: Toggle transformers",
+		"L: Cycle large-file truncation",
+		"U: Toggle Unicode tree",
+		"R: Toggle CRLF normalization",
 		"Ctrl+Q/ESC: Exit",
 	}
 
@@ -0,0 +1,94 @@
+package filetree
+
+import (
+	"fmt"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/diogopedro/shotgun/internal/core/deps"
+	"github.com/diogopedro/shotgun/internal/models"
+)
+
+// DepsExpandedMsg carries the files found by a dependency expansion
+type DepsExpandedMsg struct {
+	Files   []string
+	Reverse bool
+	Error   error
+}
+
+// expandDepsCmd resolves one hop of in-module Go imports (or importers when reverse is set)
+// for the current selection
+func (m *FileTreeModel) expandDepsCmd(reverse bool) tea.Cmd {
+	selected := m.GetSelectedFiles()
+	if len(selected) == 0 {
+		m.notice = "No files selected"
+		return nil
+	}
+
+	return func() tea.Msg {
+		resolver, err := deps.NewResolver(filepath.Dir(selected[0]))
+		if err != nil {
+			return DepsExpandedMsg{Reverse: reverse, Error: err}
+		}
+
+		var files []string
+		if reverse {
+			files, err = resolver.Dependents(selected, 1)
+		} else {
+			files, err = resolver.Expand(selected, 1)
+		}
+		return DepsExpandedMsg{Files: files, Reverse: reverse, Error: err}
+	}
+}
+
+// selectFiles selects the tree nodes for files, expanding their ancestors so they are
+// visible, and returns the paths that were newly selected
+func (m *FileTreeModel) selectFiles(files []string) []string {
+	wanted := make(map[string]bool, len(files))
+	for _, f := range files {
+		wanted[f] = true
+	}
+
+	var added []string
+	var visit func(nodes []*models.FileNode)
+	visit = func(nodes []*models.FileNode) {
+		for _, node := range nodes {
+			if node.IsDirectory {
+				visit(node.Children)
+				continue
+			}
+			if !wanted[node.Path] || node.IsBinary || node.IsSelected {
+				continue
+			}
+
+			node.IsSelected = true
+			m.selected[node.Path] = true
+			added = append(added, node.Path)
+			for parent := node.Parent; parent != nil; parent = parent.Parent {
+				parent.IsExpanded = true
+			}
+			if node.Parent != nil {
+				m.updateParentSelection(node.Parent)
+			}
+		}
+	}
+	visit(m.items)
+
+	return added
+}
+
+// handleDepsExpanded applies a dependency expansion result and reports it in the status bar
+func (m *FileTreeModel) handleDepsExpanded(msg DepsExpandedMsg) {
+	kind := "imports"
+	if msg.Reverse {
+		kind = "dependents"
+	}
+
+	if msg.Error != nil {
+		m.notice = fmt.Sprintf("Dependency expansion failed: %v", msg.Error)
+		return
+	}
+
+	added := m.selectFiles(msg.Files)
+	m.notice = fmt.Sprintf("+%d files from %s", len(added), kind)
+}
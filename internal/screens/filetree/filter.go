@@ -0,0 +1,174 @@
+package filetree
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/diogopedro/shotgun/internal/models"
+	"github.com/sahilm/fuzzy"
+)
+
+// filterState holds the "/" filter: the query being typed or applied and the nodes it matches
+type filterState struct {
+	editing bool            // Typing the query; keys go to the filter input
+	query   string          // Fuzzy text, or a glob when it contains * ? or [
+	grep    bool            // Matches come from a content search rather than the query
+	matches map[string]bool // Paths of matching files
+	visible map[string]bool // Matches plus their ancestors
+}
+
+// active reports whether a filter restricts the tree
+func (f filterState) active() bool {
+	return f.query != ""
+}
+
+// isGlob reports whether the query should be matched as a glob
+func (f filterState) isGlob() bool {
+	return strings.ContainsAny(f.query, "*?[")
+}
+
+// handleFilterKey processes keys while the filter query is being typed
+func (m *FileTreeModel) handleFilterKey(key string, runes []rune) {
+	switch key {
+	case "esc":
+		m.clearFilter()
+	case "enter":
+		m.filter.editing = false
+	case "backspace":
+		if q := []rune(m.filter.query); len(q) > 0 {
+			m.applyFilter(string(q[:len(q)-1]))
+		}
+	default:
+		if len(runes) > 0 {
+			m.applyFilter(m.filter.query + string(runes))
+		}
+	}
+}
+
+// applyFilter sets the query, recomputes matches and expands their ancestors
+func (m *FileTreeModel) applyFilter(query string) {
+	m.filter.query = query
+	m.filter.grep = false
+	m.filter.matches = make(map[string]bool)
+	m.filter.visible = make(map[string]bool)
+	m.cursor = 0
+	if query == "" {
+		return
+	}
+
+	files := m.filterCandidates()
+	paths := make([]string, len(files))
+	for i, node := range files {
+		paths[i] = relativePath(node)
+	}
+
+	if m.filter.isGlob() {
+		pattern := filepath.ToSlash(query)
+		for i, path := range paths {
+			if globMatch(pattern, path) {
+				m.markMatch(files[i])
+			}
+		}
+		return
+	}
+
+	for _, match := range fuzzy.Find(query, paths) {
+		m.markMatch(files[match.Index])
+	}
+}
+
+// globMatch matches a glob against a slash-separated relative path. Patterns without a
+// slash also match the base name, so "*.go" finds Go files at any depth.
+func globMatch(pattern, path string) bool {
+	if ok, _ := doublestar.Match(pattern, path); ok {
+		return true
+	}
+	if !strings.Contains(pattern, "/") {
+		ok, _ := doublestar.Match(pattern, filepath.Base(filepath.FromSlash(path)))
+		return ok
+	}
+	return false
+}
+
+// markMatch records a matching node and makes its ancestors visible and expanded
+func (m *FileTreeModel) markMatch(node *models.FileNode) {
+	m.filter.matches[node.Path] = true
+	m.filter.visible[node.Path] = true
+	for parent := node.Parent; parent != nil; parent = parent.Parent {
+		parent.IsExpanded = true
+		m.filter.visible[parent.Path] = true
+	}
+}
+
+// clearFilter leaves filter mode, stops a running search, drops its results and shows the
+// whole tree again
+func (m *FileTreeModel) clearFilter() {
+	m.cancelGrep()
+	m.filter = filterState{}
+	m.grep.results = nil
+	m.cursor = 0
+}
+
+// filterCandidates returns all file nodes in tree order
+func (m *FileTreeModel) filterCandidates() []*models.FileNode {
+	var files []*models.FileNode
+	var visit func(nodes []*models.FileNode)
+	visit = func(nodes []*models.FileNode) {
+		for _, node := range nodes {
+			if node.IsDirectory {
+				visit(node.Children)
+			} else {
+				files = append(files, node)
+			}
+		}
+	}
+	visit(m.items)
+	return files
+}
+
+// relativePath returns a node's path relative to its root node, using forward slashes.
+// Each root of a multi-root tree is its own base; loose top-level files match by name.
+func relativePath(node *models.FileNode) string {
+	root := node
+	for root.Parent != nil {
+		root = root.Parent
+	}
+	base := root.Path
+	if root == node || !root.IsDirectory {
+		base = filepath.Dir(root.Path)
+	}
+	if rel, err := filepath.Rel(base, node.Path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(node.Path)
+}
+
+// setMatchesSelected selects or deselects every file matching the filter
+func (m *FileTreeModel) setMatchesSelected(selected bool) {
+	var paths []string
+	for path := range m.filter.matches {
+		paths = append(paths, path)
+	}
+
+	if selected {
+		added := m.selectFiles(paths)
+		m.notice = fmt.Sprintf("Selected %d matches", len(added))
+	} else {
+		removed := m.deselectFiles(paths)
+		m.notice = fmt.Sprintf("Deselected %d matches", removed)
+	}
+}
+
+// CapturesKey reports whether the filter needs a key the app would otherwise handle globally.
+// While typing it takes everything but quit keys; an applied filter takes Esc to clear itself.
+func (m FileTreeModel) CapturesKey(key string) bool {
+	switch {
+	case m.filter.editing, m.grep.editing:
+		return key != "ctrl+c" && key != "ctrl+q"
+	case m.filter.active(), m.grep.running:
+		return key == "esc"
+	}
+	return false
+}
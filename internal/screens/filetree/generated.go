@@ -0,0 +1,47 @@
+package filetree
+
+import (
+	"fmt"
+
+	"github.com/diogopedro/shotgun/internal/models"
+)
+
+// toggleGeneratedPathOnly switches generated files between excluded, their default,
+// and tree-only, so the tree shows them without their content. Files whose selection
+// was changed by hand are left alone.
+func (m *FileTreeModel) toggleGeneratedPathOnly() {
+	m.generatedPathOnly = !m.generatedPathOnly
+
+	from, to := models.SelectionTreeOnly, models.SelectionExcluded
+	if m.generatedPathOnly {
+		from, to = to, from
+	}
+
+	count := 0
+	var visit func(nodes []*models.FileNode)
+	visit = func(nodes []*models.FileNode) {
+		for _, node := range nodes {
+			if node.IsDirectory {
+				visit(node.Children)
+				continue
+			}
+			if !node.IsGenerated || node.IsBinary || node.Selection() != from {
+				continue
+			}
+
+			count++
+			node.SetSelection(to)
+			m.selected[node.Path] = node.IsSelected
+			if node.Parent != nil {
+				m.updateParentSelection(node.Parent)
+			}
+		}
+	}
+	visit(m.items)
+
+	if m.generatedPathOnly {
+		m.notice = fmt.Sprintf("Listing %d generated files as path-only", count)
+	} else {
+		m.notice = fmt.Sprintf("Excluded %d generated files", count)
+	}
+}
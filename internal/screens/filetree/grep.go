@@ -0,0 +1,141 @@
+package filetree
+
+import (
+	"context"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/diogopedro/shotgun/internal/core/search"
+	"github.com/diogopedro/shotgun/internal/models"
+)
+
+// GrepCompleteMsg carries the results of a content search
+type GrepCompleteMsg struct {
+	Search  int // Generation of the search that produced the results
+	Query   search.Query
+	Results []search.Result
+	Error   error
+}
+
+// grepState holds the content search query and its per-file results
+type grepState struct {
+	editing bool
+	running bool
+	cancel  context.CancelFunc // Stops the running search
+	search  int                // Generation of the latest search; older results are stale
+	query   search.Query
+	results map[string]search.Result
+}
+
+// cancelGrep stops a running search; its results are dropped when they arrive
+func (m *FileTreeModel) cancelGrep() {
+	if m.grep.cancel != nil {
+		m.grep.cancel()
+		m.grep.cancel = nil
+	}
+	m.grep.running = false
+}
+
+// handleGrepKey processes keys while the search query is being typed
+func (m *FileTreeModel) handleGrepKey(key string, runes []rune) tea.Cmd {
+	switch key {
+	case "esc":
+		m.grep.editing = false
+		m.grep.query.Pattern = ""
+		m.cancelGrep()
+	case "enter":
+		m.grep.editing = false
+		return m.startGrep()
+	case "tab":
+		m.grep.query.Literal = !m.grep.query.Literal
+	case "backspace":
+		if q := []rune(m.grep.query.Pattern); len(q) > 0 {
+			m.grep.query.Pattern = string(q[:len(q)-1])
+		}
+	default:
+		if len(runes) > 0 {
+			m.grep.query.Pattern += string(runes)
+		}
+	}
+	return nil
+}
+
+// startGrep validates the query and searches the tree's text files in the background,
+// cancelling any search still running
+func (m *FileTreeModel) startGrep() tea.Cmd {
+	m.cancelGrep()
+	query := m.grep.query
+	if _, err := query.Compile(); err != nil {
+		m.notice = err.Error()
+		return nil
+	}
+
+	var files []string
+	for _, node := range m.filterCandidates() {
+		if !node.IsBinary && !node.IsIgnored {
+			files = append(files, node.Path)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.grep.running = true
+	m.grep.cancel = cancel
+	m.grep.search++
+	generation := m.grep.search
+	return func() tea.Msg {
+		defer cancel()
+		results, err := search.New().Search(ctx, files, query)
+		return GrepCompleteMsg{Search: generation, Query: query, Results: results, Error: err}
+	}
+}
+
+// handleGrepComplete shows the matching files as a filter with hit counts. Results of
+// a cancelled or superseded search are dropped.
+func (m *FileTreeModel) handleGrepComplete(msg GrepCompleteMsg) {
+	if !m.grep.running || msg.Search != m.grep.search {
+		return
+	}
+	m.grep.running = false
+	m.grep.cancel = nil
+	if msg.Error != nil {
+		m.notice = fmt.Sprintf("Search failed: %v", msg.Error)
+		return
+	}
+
+	m.grep.results = make(map[string]search.Result, len(msg.Results))
+	for _, r := range msg.Results {
+		m.grep.results[r.Path] = r
+	}
+
+	m.filter = filterState{query: msg.Query.Pattern, grep: true, matches: make(map[string]bool), visible: make(map[string]bool)}
+	m.cursor = 0
+	for _, node := range m.filterCandidates() {
+		if _, ok := m.grep.results[node.Path]; ok {
+			m.markMatch(node)
+		}
+	}
+
+	hits := 0
+	for _, r := range msg.Results {
+		hits += r.Hits
+	}
+	m.notice = fmt.Sprintf("%d hits in %d files", hits, len(msg.Results))
+}
+
+// grepPreview returns the matching lines of the file under the cursor
+func (m FileTreeModel) grepPreview() []search.Line {
+	if m.grep.results == nil {
+		return nil
+	}
+
+	flatItems := m.flattenTree(m.items, 0)
+	if m.cursor >= len(flatItems) {
+		return nil
+	}
+	return m.grep.results[flatItems[m.cursor].node.Path].Preview
+}
+
+// grepHits returns the number of matching lines in a node, or 0
+func (m FileTreeModel) grepHits(node *models.FileNode) int {
+	return m.grep.results[node.Path].Hits
+}
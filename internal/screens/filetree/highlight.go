@@ -0,0 +1,226 @@
+package filetree
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	keywordStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+	stringStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("114"))
+	commentStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("244")).Italic(true)
+	numberStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("179"))
+)
+
+// syntax describes the lexical rules the preview highlighter needs for a language
+type syntax struct {
+	keywords     map[string]bool
+	lineComment  string
+	blockStart   string
+	blockEnd     string
+	quotes       string // Characters that open single-line strings
+	rawQuote     byte   // Quote that opens a string which may span lines (0 for none)
+	headingsOnly bool   // Markdown: only highlight headings
+}
+
+func keywordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(words) {
+		set[w] = true
+	}
+	return set
+}
+
+var (
+	goSyntax = syntax{
+		keywords: keywordSet("break case chan const continue default defer else fallthrough for func go goto if import " +
+			"interface map package range return select struct switch type var nil true false"),
+		lineComment: "//", blockStart: "/*", blockEnd: "*/", quotes: `"'`, rawQuote: '`',
+	}
+	jsSyntax = syntax{
+		keywords: keywordSet("async await break case catch class const continue default delete do else export extends " +
+			"finally for from function if import in instanceof interface let new null return static super switch this " +
+			"throw try type typeof undefined var void while yield true false"),
+		lineComment: "//", blockStart: "/*", blockEnd: "*/", quotes: `"'`, rawQuote: '`',
+	}
+	cSyntax = syntax{
+		keywords: keywordSet("auto break case char class const continue default do double else enum extern final float " +
+			"fn for if impl import int let long match mod mut namespace new null private protected pub public return " +
+			"self short signed sizeof static struct super switch this throw trait try typedef union unsigned use void " +
+			"volatile while true false"),
+		lineComment: "//", blockStart: "/*", blockEnd: "*/", quotes: `"'`,
+	}
+	pythonSyntax = syntax{
+		keywords: keywordSet("and as assert async await break class continue def del elif else except finally for from " +
+			"global if import in is lambda nonlocal not or pass raise return try while with yield None True False"),
+		lineComment: "#", quotes: `"'`,
+	}
+	shellSyntax = syntax{
+		keywords:    keywordSet("case do done elif else esac export fi for function if in local return then until while"),
+		lineComment: "#", quotes: `"'`,
+	}
+	configSyntax = syntax{
+		keywords:    keywordSet("true false null yes no on off"),
+		lineComment: "#", quotes: `"'`,
+	}
+	jsonSyntax = syntax{
+		keywords: keywordSet("true false null"),
+		quotes:   `"`,
+	}
+	markdownSyntax = syntax{headingsOnly: true}
+)
+
+// syntaxForPath picks the highlighting rules for a file by extension
+func syntaxForPath(path string) *syntax {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".go":
+		return &goSyntax
+	case ".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx":
+		return &jsSyntax
+	case ".c", ".h", ".cc", ".cpp", ".hpp", ".java", ".kt", ".rs", ".cs", ".swift", ".scala":
+		return &cSyntax
+	case ".py":
+		return &pythonSyntax
+	case ".sh", ".bash", ".zsh":
+		return &shellSyntax
+	case ".yaml", ".yml", ".toml", ".ini", ".cfg", ".conf":
+		return &configSyntax
+	case ".json":
+		return &jsonSyntax
+	case ".md", ".markdown":
+		return &markdownSyntax
+	}
+	return nil
+}
+
+// highlightLines applies syntax highlighting to lines, carrying block comment and
+// multi-line string state across lines. Unknown extensions are returned unchanged.
+func highlightLines(path string, lines []string) []string {
+	syn := syntaxForPath(path)
+	if syn == nil {
+		return lines
+	}
+
+	out := make([]string, len(lines))
+	var open string // Closing delimiter of a construct left open by the previous line
+	for i, line := range lines {
+		out[i], open = syn.highlightLine(line, open)
+	}
+	return out
+}
+
+// highlightLine highlights one line. open is the closing delimiter of a block comment
+// or raw string carried in from the previous line; the returned value is the same for the next.
+func (s *syntax) highlightLine(line, open string) (string, string) {
+	if s.headingsOnly {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			return keywordStyle.Render(line), ""
+		}
+		return line, ""
+	}
+
+	var b strings.Builder
+	i := 0
+
+	// Finish a construct carried over from the previous line
+	if open != "" {
+		style := commentStyle
+		if open != s.blockEnd {
+			style = stringStyle
+		}
+		end := strings.Index(line, open)
+		if end < 0 {
+			return style.Render(line), open
+		}
+		i = end + len(open)
+		b.WriteString(style.Render(line[:i]))
+	}
+
+	for i < len(line) {
+		rest := line[i:]
+		c := line[i]
+
+		switch {
+		case s.lineComment != "" && strings.HasPrefix(rest, s.lineComment):
+			b.WriteString(commentStyle.Render(rest))
+			return b.String(), ""
+
+		case s.blockStart != "" && strings.HasPrefix(rest, s.blockStart):
+			end := strings.Index(rest[len(s.blockStart):], s.blockEnd)
+			if end < 0 {
+				b.WriteString(commentStyle.Render(rest))
+				return b.String(), s.blockEnd
+			}
+			n := len(s.blockStart) + end + len(s.blockEnd)
+			b.WriteString(commentStyle.Render(rest[:n]))
+			i += n
+
+		case s.rawQuote != 0 && c == s.rawQuote:
+			end := strings.IndexByte(rest[1:], c)
+			if end < 0 {
+				b.WriteString(stringStyle.Render(rest))
+				return b.String(), string(c)
+			}
+			b.WriteString(stringStyle.Render(rest[:end+2]))
+			i += end + 2
+
+		case strings.IndexByte(s.quotes, c) >= 0:
+			n := quotedLength(rest)
+			b.WriteString(stringStyle.Render(rest[:n]))
+			i += n
+
+		case isWordStart(c):
+			n := 1
+			for n < len(rest) && isWordPart(rest[n]) {
+				n++
+			}
+			word := rest[:n]
+			if s.keywords[word] {
+				b.WriteString(keywordStyle.Render(word))
+			} else {
+				b.WriteString(word)
+			}
+			i += n
+
+		case c >= '0' && c <= '9':
+			n := 1
+			for n < len(rest) && (isWordPart(rest[n]) || rest[n] == '.') {
+				n++
+			}
+			b.WriteString(numberStyle.Render(rest[:n]))
+			i += n
+
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+
+	return b.String(), ""
+}
+
+// quotedLength returns the length of a quoted string at the start of s, honouring
+// backslash escapes; an unterminated string runs to the end of the line
+func quotedLength(s string) int {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case quote:
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+func isWordStart(c byte) bool {
+	return c == '_' || c >= 0x80 || unicode.IsLetter(rune(c))
+}
+
+func isWordPart(c byte) bool {
+	return isWordStart(c) || (c >= '0' && c <= '9')
+}
@@ -9,6 +9,16 @@ type KeyMap struct {
 	Left     key.Binding
 	Right    key.Binding
 	Toggle   key.Binding
+	Mode     key.Binding
+	Deps     key.Binding
+	RevDeps  key.Binding
+	Tests    key.Binding
+	Sources  key.Binding
+	Filter   key.Binding
+	Grep     key.Binding
+	Preview  key.Binding
+	SizeSort key.Binding
+	Codegen  key.Binding
 	VimUp    key.Binding
 	VimDown  key.Binding
 	VimLeft  key.Binding
@@ -40,6 +50,46 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys(" "),
 			key.WithHelp("space", "toggle selection"),
 		),
+		Mode: key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("o", "cycle full/outline/path-only"),
+		),
+		Deps: key.NewBinding(
+			key.WithKeys("d"),
+			key.WithHelp("d", "add imported packages (1 hop)"),
+		),
+		RevDeps: key.NewBinding(
+			key.WithKeys("D"),
+			key.WithHelp("D", "add importing files (1 hop)"),
+		),
+		Tests: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", "toggle tests for selected files"),
+		),
+		Sources: key.NewBinding(
+			key.WithKeys("T"),
+			key.WithHelp("T", "toggle sources for selected tests"),
+		),
+		Filter: key.NewBinding(
+			key.WithKeys("/"),
+			key.WithHelp("/", "filter by fuzzy path or glob"),
+		),
+		Grep: key.NewBinding(
+			key.WithKeys("g"),
+			key.WithHelp("g", "search file contents"),
+		),
+		Preview: key.NewBinding(
+			key.WithKeys("p"),
+			key.WithHelp("p", "toggle preview (J/K scroll)"),
+		),
+		SizeSort: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "toggle largest-first sort"),
+		),
+		Codegen: key.NewBinding(
+			key.WithKeys("G"),
+			key.WithHelp("G", "toggle generated files as path-only"),
+		),
 		VimUp: key.NewBinding(
 			key.WithKeys("k"),
 			key.WithHelp("k", "move up (vim)"),
@@ -77,6 +127,6 @@ func (k KeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{k.Up, k.Down, k.VimUp, k.VimDown},
 		{k.Left, k.Right, k.VimLeft, k.VimRight},
-		{k.Toggle, k.Help, k.Quit},
+		{k.Toggle, k.Mode, k.Deps, k.RevDeps, k.Tests, k.Sources, k.Filter, k.Grep, k.Preview, k.SizeSort, k.Codegen, k.Help, k.Quit},
 	}
 }
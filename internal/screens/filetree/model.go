@@ -9,6 +9,7 @@ import (
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/diogopedro/shotgun/internal/components/spinner"
+	"github.com/diogopedro/shotgun/internal/core/builder"
 	"github.com/diogopedro/shotgun/internal/core/scanner"
 	"github.com/diogopedro/shotgun/internal/models"
 )
@@ -42,6 +43,18 @@ type FileTreeModel struct {
 	scanError  error
 	filesFound int
 	currentDir string
+	notice     string // One-line result of the last action, shown in the status bar
+	// Files added by the test pairing toggles; nil when the toggle is off
+	pairedTests   []string
+	pairedSources []string
+	filter        filterState
+	grep          grepState
+	showPreview   bool
+	sortBySize    bool // Largest first instead of directories first, alphabetically
+	preview       previewState
+	checks        *builder.FileStructureBuilder // Binary and sensitive checks shared with prompt generation
+	// Generated files are selected as path-only instead of excluded
+	generatedPathOnly bool
 }
 
 // NewFileTreeModel creates a new FileTreeModel with defaults
@@ -59,6 +72,7 @@ func NewFileTreeModel() FileTreeModel {
 		keyMap:   DefaultKeyMap(),
 		scanning: false,
 		spinner:  spinner.New(spinner.SpinnerDots),
+		checks:   builder.NewFileStructureBuilder(),
 	}
 }
 
@@ -67,21 +81,32 @@ func (m *FileTreeModel) LoadFileTree(nodes []*models.FileNode) {
 	m.items = nodes
 	m.cursor = 0
 	m.selected = make(map[string]bool)
+	m.pairedTests = nil
+	m.pairedSources = nil
+	m.filter = filterState{}
+	m.cancelGrep()
+	m.grep = grepState{search: m.grep.search} // Keep counting so results of earlier trees stay stale
+	m.preview = previewState{}
+	m.generatedPathOnly = false
 
 	// Initialize all files as selected by default (IsSelected: true)
 	m.initializeSelection(nodes, true)
+	if m.sortBySize {
+		m.resortTree(nodes)
+	}
 }
 
 // initializeSelection recursively sets initial selection state
 func (m *FileTreeModel) initializeSelection(nodes []*models.FileNode, isSelected bool) {
 	for _, node := range nodes {
-		if !node.IsBinary { // Don't select binary files
+		if !node.IsBinary && !node.IsDefaultExcluded() { // Don't select binary, generated or vendored files
 			node.IsSelected = isSelected
 			m.selected[node.Path] = isSelected
 		} else {
 			node.IsSelected = false
 			m.selected[node.Path] = false
 		}
+		node.TreeOnly = false
 
 		// Recursively handle children
 		if node.IsDirectory && len(node.Children) > 0 {
@@ -117,21 +142,40 @@ func (m FileTreeModel) IsScanning() bool {
 
 // LoadFromScanner loads file tree data from the scanner service with loading state
 func (m *FileTreeModel) LoadFromScanner(ctx context.Context, rootPath string) tea.Cmd {
+	return m.LoadRoots(ctx, []string{rootPath})
+}
+
+// LoadRoots scans each project root with its own ignore rules and .gitattributes.
+// With several roots, each root's top-level node is named by its label.
+func (m *FileTreeModel) LoadRoots(ctx context.Context, roots []string) tea.Cmd {
 	return tea.Cmd(func() tea.Msg {
-		// Create scanner with default options
-		scannerInstance, err := scanner.New()
-		if err != nil {
-			return ScanErrorMsg{Error: err}
-		}
+		labels := builder.RootLabels(roots)
+
+		var treeNodes []*models.FileNode
+		for i, root := range roots {
+			// Create scanner with default options
+			scannerInstance, err := scanner.New()
+			if err != nil {
+				return ScanErrorMsg{Error: err}
+			}
 
-		// Scan directory synchronously for simplicity
-		nodes, err := scannerInstance.ScanDirectorySync(ctx, rootPath)
-		if err != nil {
-			return ScanErrorMsg{Error: err}
-		}
+			// Scan directory synchronously for simplicity
+			nodes, err := scannerInstance.ScanDirectorySync(ctx, root)
+			if err != nil {
+				return ScanErrorMsg{Error: err}
+			}
 
-		// Convert flat list to tree structure
-		treeNodes := m.buildTreeStructure(nodes)
+			// Convert flat list to tree structure
+			rootNodes := m.buildTreeStructure(nodes)
+			if len(roots) > 1 {
+				for _, node := range rootNodes {
+					if node.Path == filepath.Clean(root) {
+						node.Name = labels[i]
+					}
+				}
+			}
+			treeNodes = append(treeNodes, rootNodes...)
+		}
 
 		return ScanCompleteMsg{Nodes: treeNodes}
 	})
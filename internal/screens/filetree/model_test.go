@@ -2,6 +2,9 @@ package filetree
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -269,6 +272,37 @@ func TestLoadFromScanner(t *testing.T) {
 	}
 }
 
+func TestLoadRoots(t *testing.T) {
+	base := t.TempDir()
+	var roots []string
+	for _, dir := range []string{"team-a/api", "team-b/api"} {
+		root := filepath.Join(base, dir)
+		if err := os.MkdirAll(root, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+	}
+
+	model := NewFileTreeModel()
+	msg, ok := model.LoadRoots(context.Background(), roots)().(ScanCompleteMsg)
+	if !ok {
+		t.Fatalf("Expected ScanCompleteMsg")
+	}
+
+	var names []string
+	for _, node := range msg.Nodes {
+		if node.Parent == nil {
+			names = append(names, node.Name)
+		}
+	}
+	if strings.Join(names, ",") != "team-a-api,team-b-api" {
+		t.Errorf("Expected labeled root nodes, got %v", names)
+	}
+}
+
 func TestLoadFromScannerStreaming(t *testing.T) {
 	model := NewFileTreeModel()
 	ctx := context.Background()
@@ -326,3 +360,52 @@ func TestSortTreeNodes(t *testing.T) {
 		}
 	}
 }
+
+func TestLoadFileTree_DeselectsGeneratedAndVendored(t *testing.T) {
+	model := NewFileTreeModel()
+	nodes := []*models.FileNode{
+		{Path: "/test/main.go", Name: "main.go"},
+		{Path: "/test/api.pb.go", Name: "api.pb.go", IsGenerated: true},
+		{Path: "/test/lib.c", Name: "lib.c", IsVendored: true},
+		{Path: "/test/guide.md", Name: "guide.md", IsExportIgnored: true},
+	}
+
+	model.LoadFileTree(nodes)
+
+	for _, node := range nodes {
+		if want := !node.IsDefaultExcluded(); node.IsSelected != want {
+			t.Errorf("%s: IsSelected = %v, want %v", node.Name, node.IsSelected, want)
+		}
+	}
+	if view := model.renderTreeItem(treeItem{node: nodes[1]}, false); !strings.Contains(view, "(generated)") {
+		t.Errorf("expected generated marker in %q", view)
+	}
+	if view := model.renderTreeItem(treeItem{node: nodes[3]}, false); !strings.Contains(view, "(export-ignore)") {
+		t.Errorf("expected export-ignore marker in %q", view)
+	}
+}
+
+func TestToggleGeneratedPathOnly(t *testing.T) {
+	model := NewFileTreeModel()
+	generated := &models.FileNode{Path: "/test/kind_string.go", Name: "kind_string.go", IsGenerated: true}
+	chosen := &models.FileNode{Path: "/test/api.pb.go", Name: "api.pb.go", IsGenerated: true}
+	nodes := []*models.FileNode{{Path: "/test/main.go", Name: "main.go"}, generated, chosen}
+	model.LoadFileTree(nodes)
+	chosen.SetSelection(models.SelectionFull) // Picked by hand before toggling
+
+	model.toggleGeneratedPathOnly()
+	if generated.Selection() != models.SelectionTreeOnly || generated.RenderMode != "" {
+		t.Errorf("generated file should be tree-only, got selection=%v mode=%s", generated.Selection(), generated.RenderMode)
+	}
+	if chosen.Selection() != models.SelectionFull {
+		t.Errorf("hand-selected generated file should keep its content, got %v", chosen.Selection())
+	}
+
+	model.toggleGeneratedPathOnly()
+	if generated.IsSelected || generated.TreeOnly {
+		t.Errorf("generated file should be excluded again, got selection=%v", generated.Selection())
+	}
+	if !nodes[0].IsSelected || chosen.Selection() != models.SelectionFull {
+		t.Error("hand-written and hand-selected files should keep their selection")
+	}
+}
@@ -0,0 +1,71 @@
+package filetree
+
+import (
+	"fmt"
+
+	"github.com/diogopedro/shotgun/internal/core/pairing"
+	"github.com/diogopedro/shotgun/internal/models"
+)
+
+// toggleTestPairing switches "include tests for selected files" (or, when sources is set,
+// "include sources for selected tests"). Turning a toggle off deselects the files it added.
+func (m *FileTreeModel) toggleTestPairing(sources bool) {
+	label, added := "tests", &m.pairedTests
+	if sources {
+		label, added = "sources", &m.pairedSources
+	}
+
+	if *added != nil {
+		m.deselectFiles(*added)
+		m.notice = fmt.Sprintf("Paired %s off (-%d files)", label, len(*added))
+		*added = nil
+		return
+	}
+
+	pairer := pairing.New()
+	selected := m.GetSelectedFiles()
+	var files []string
+	if sources {
+		files = pairer.Sources(selected)
+	} else {
+		files = pairer.Tests(selected)
+	}
+
+	*added = m.selectFiles(files)
+	if *added == nil {
+		*added = []string{} // Keep the toggle on even when nothing matched
+	}
+	m.notice = fmt.Sprintf("Paired %s on (+%d files)", label, len(*added))
+}
+
+// deselectFiles deselects the tree nodes for files and returns how many were selected before
+func (m *FileTreeModel) deselectFiles(files []string) int {
+	wanted := make(map[string]bool, len(files))
+	for _, f := range files {
+		wanted[f] = true
+	}
+
+	removed := 0
+	var visit func(nodes []*models.FileNode)
+	visit = func(nodes []*models.FileNode) {
+		for _, node := range nodes {
+			if node.IsDirectory {
+				visit(node.Children)
+				continue
+			}
+			if !wanted[node.Path] || !node.IsSelected {
+				continue
+			}
+
+			removed++
+			node.SetSelection(models.SelectionExcluded)
+			m.selected[node.Path] = false
+			if node.Parent != nil {
+				m.updateParentSelection(node.Parent)
+			}
+		}
+	}
+	visit(m.items)
+
+	return removed
+}
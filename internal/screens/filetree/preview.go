@@ -0,0 +1,177 @@
+package filetree
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/diogopedro/shotgun/internal/core/builder"
+	"github.com/diogopedro/shotgun/internal/core/scanner"
+	"github.com/diogopedro/shotgun/internal/models"
+)
+
+const (
+	// previewMaxBytes limits how much of a file the preview reads
+	previewMaxBytes = 64 * 1024
+	// previewMinWidth is the narrowest terminal that gets a side-by-side preview
+	previewMinWidth = 80
+)
+
+var (
+	previewStyle = lipgloss.NewStyle().
+			BorderStyle(lipgloss.NormalBorder()).
+			BorderLeft(true).
+			BorderForeground(lipgloss.Color("240")).
+			PaddingLeft(1)
+
+	previewMetaStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("248"))
+
+	previewFlagStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("203")).
+				Bold(true)
+)
+
+// previewState caches the preview of the file under the cursor
+type previewState struct {
+	path   string
+	header []string // Name, metadata and flags
+	lines  []string // Highlighted content lines
+	offset int      // First visible content line
+}
+
+// togglePreview shows or hides the preview pane
+func (m *FileTreeModel) togglePreview() {
+	m.showPreview = !m.showPreview
+	m.refreshPreview()
+}
+
+// refreshPreview reloads the preview when the cursor has moved to another node
+func (m *FileTreeModel) refreshPreview() {
+	if !m.showPreview {
+		return
+	}
+
+	flatItems := m.getFlattenedItems()
+	if len(flatItems) == 0 || m.cursor >= len(flatItems) {
+		m.preview = previewState{}
+		return
+	}
+
+	node := flatItems[m.cursor].node
+	if node.Path == m.preview.path {
+		return
+	}
+	m.preview = loadPreview(node, m.checks)
+}
+
+// scrollPreview moves the preview content by delta lines
+func (m *FileTreeModel) scrollPreview(delta int) {
+	m.preview.offset = max(0, min(m.preview.offset+delta, len(m.preview.lines)-1))
+}
+
+// loadPreview reads metadata and, for text files that would be embedded, highlighted content
+func loadPreview(node *models.FileNode, checks *builder.FileStructureBuilder) previewState {
+	p := previewState{path: node.Path, header: []string{directoryStyle.Render(node.Name)}}
+
+	info, err := os.Stat(node.Path)
+	if err != nil {
+		p.header = append(p.header, previewFlagStyle.Render(err.Error()))
+		return p
+	}
+
+	if node.IsDirectory {
+		p.header = append(p.header, previewMetaStyle.Render(fmt.Sprintf("Directory • %d entries • modified %s",
+			len(node.Children), info.ModTime().Format(time.DateTime))))
+		return p
+	}
+
+	p.header = append(p.header, previewMetaStyle.Render(fmt.Sprintf("%s • ~%d tokens • modified %s",
+		builder.FormatFileSize(info.Size()), builder.EstimateTokens(info.Size()), info.ModTime().Format(time.DateTime))))
+
+	binary := node.IsBinary || checks.IsBinaryFile(node.Path)
+	sensitive := checks.IsSensitiveFile(node.Path)
+	var flags []string
+	if binary {
+		flags = append(flags, "binary")
+	}
+	if sensitive {
+		flags = append(flags, "sensitive")
+	}
+	if node.IsIgnored {
+		flags = append(flags, "ignored")
+	}
+	if len(flags) > 0 {
+		p.header = append(p.header, previewFlagStyle.Render("⚠ "+strings.Join(flags, ", ")))
+	}
+	if binary || sensitive {
+		return p // Content is not embedded in prompts, so it is not shown either
+	}
+
+	content, truncated, err := readPreview(node.Path)
+	if err != nil {
+		p.header = append(p.header, previewFlagStyle.Render(err.Error()))
+		return p
+	}
+
+	lines := strings.Split(strings.ReplaceAll(content, "\t", "    "), "\n")
+	p.lines = highlightLines(node.Path, lines)
+	if truncated {
+		p.lines = append(p.lines, previewMetaStyle.Render(fmt.Sprintf("… preview limited to %s", builder.FormatFileSize(previewMaxBytes))))
+	}
+	return p
+}
+
+// readPreview reads up to previewMaxBytes of a file as UTF-8 text
+func readPreview(path string) (string, bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", false, err
+	}
+	defer file.Close()
+
+	buf, err := io.ReadAll(io.LimitReader(file, previewMaxBytes+1))
+	if err != nil {
+		return "", false, err
+	}
+	truncated := len(buf) > previewMaxBytes
+	if truncated {
+		buf = buf[:previewMaxBytes]
+	}
+	text, _ := scanner.DecodeText(buf)
+	return scanner.NormalizeNewlines(text), truncated, nil
+}
+
+// renderPreviewPane renders the preview at the given size, clipping long lines
+func (m FileTreeModel) renderPreviewPane(width, height int) string {
+	contentWidth := width - 2 // Left border and padding
+	lines := append([]string(nil), m.preview.header...)
+	if len(m.preview.lines) > 0 {
+		lines = append(lines, "")
+		visible := height - len(lines)
+		end := min(len(m.preview.lines), m.preview.offset+max(visible, 0))
+		lines = append(lines, m.preview.lines[m.preview.offset:end]...)
+	}
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+
+	clip := lipgloss.NewStyle().MaxWidth(contentWidth)
+	for i, line := range lines {
+		lines[i] = clip.Render(line)
+	}
+
+	return previewStyle.Width(width - 1).Height(height).Render(strings.Join(lines, "\n"))
+}
+
+// renderSideBySideLayout renders the tree and the preview pane next to each other
+func (m FileTreeModel) renderSideBySideLayout(tree string) string {
+	treeWidth := m.width * 3 / 5
+	previewWidth := m.width - treeWidth
+
+	treePane := lipgloss.NewStyle().Width(treeWidth).MaxWidth(treeWidth).Render(tree)
+	return lipgloss.JoinHorizontal(lipgloss.Top, treePane, m.renderPreviewPane(previewWidth, m.viewport.Height))
+}
@@ -0,0 +1,132 @@
+package filetree
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/diogopedro/shotgun/internal/core/builder"
+	"github.com/diogopedro/shotgun/internal/models"
+)
+
+var sizeStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("244"))
+
+// selectedSize returns the byte size of a file, or of the selected, non-binary files beneath
+// a directory whose content goes into the prompt. Computed on demand so the aggregates
+// follow selection changes live.
+func selectedSize(node *models.FileNode) int64 {
+	if !node.IsDirectory {
+		return node.Size
+	}
+	var total int64
+	for _, child := range node.Children {
+		if child.IsDirectory || contributesContent(child) {
+			total += selectedSize(child)
+		}
+	}
+	return total
+}
+
+// contributesContent reports whether a selected file's content is embedded in the prompt
+func contributesContent(node *models.FileNode) bool {
+	return node.IsSelected && !node.IsBinary && !node.TreeOnly
+}
+
+// selectedTotal returns the byte size of all selected files
+func (m FileTreeModel) selectedTotal() int64 {
+	var total int64
+	for _, node := range m.items {
+		if node.IsDirectory || contributesContent(node) {
+			total += selectedSize(node)
+		}
+	}
+	return total
+}
+
+// sizeAnnotation renders the size of a row: the file's own size, or the selected
+// aggregate for directories. Directories with nothing selected get no annotation.
+func sizeAnnotation(node *models.FileNode) string {
+	size := selectedSize(node)
+	if node.IsDirectory && size == 0 {
+		return ""
+	}
+	return sizeStyle.Render(" " + formatSizeTokens(size))
+}
+
+// formatSizeTokens renders a byte count with its estimated token count
+func formatSizeTokens(size int64) string {
+	return fmt.Sprintf("%s · ~%s tokens", builder.FormatFileSize(size), formatCount(builder.EstimateTokens(size)))
+}
+
+// formatCount abbreviates large counts as 12.3k or 1.2M
+func formatCount(n int64) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1_000)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
+
+// totalSize returns the size of a node including all descendants, selected or not
+func totalSize(node *models.FileNode) int64 {
+	if !node.IsDirectory {
+		return node.Size
+	}
+	var total int64
+	for _, child := range node.Children {
+		total += totalSize(child)
+	}
+	return total
+}
+
+// toggleSizeSort switches between alphabetical and largest-first ordering,
+// keeping the cursor on the same node
+func (m *FileTreeModel) toggleSizeSort() {
+	var current *models.FileNode
+	if flatItems := m.getFlattenedItems(); m.cursor < len(flatItems) {
+		current = flatItems[m.cursor].node
+	}
+
+	m.sortBySize = !m.sortBySize
+	m.resortTree(m.items)
+
+	for i, item := range m.getFlattenedItems() {
+		if item.node == current {
+			m.cursor = i
+			break
+		}
+	}
+}
+
+// resortTree re-sorts nodes and their descendants in the current order
+func (m *FileTreeModel) resortTree(nodes []*models.FileNode) {
+	if m.sortBySize {
+		sortBySize(nodes)
+	} else {
+		m.sortTreeNodes(nodes)
+	}
+	for _, node := range nodes {
+		if node.IsDirectory {
+			m.resortTree(node.Children)
+		}
+	}
+}
+
+// sortBySize sorts nodes largest first, counting every descendant of a directory
+func sortBySize(nodes []*models.FileNode) {
+	sizes := make(map[*models.FileNode]int64, len(nodes))
+	for _, node := range nodes {
+		sizes[node] = totalSize(node)
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if sizes[nodes[i]] != sizes[nodes[j]] {
+			return sizes[nodes[i]] > sizes[nodes[j]]
+		}
+		return strings.ToLower(nodes[i].Name) < strings.ToLower(nodes[j].Name)
+	})
+}
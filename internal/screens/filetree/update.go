@@ -2,6 +2,7 @@ package filetree
 
 import (
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/diogopedro/shotgun/internal/core/builder"
 	"github.com/diogopedro/shotgun/internal/models"
 )
 
@@ -40,6 +41,16 @@ func (m FileTreeModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.filesFound = msg.FilesFound
 		m.currentDir = msg.CurrentDir
 		return m, nil
+
+	case GrepCompleteMsg:
+		m.handleGrepComplete(msg)
+		m.updateViewport()
+		return m, nil
+
+	case DepsExpandedMsg:
+		m.handleDepsExpanded(msg)
+		m.updateViewport()
+		return m, nil
 	}
 
 	// Update spinner if scanning
@@ -68,6 +79,20 @@ func (m FileTreeModel) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	m.notice = ""
+
+	if m.grep.editing {
+		cmd := m.handleGrepKey(msg.String(), msg.Runes)
+		return m, cmd
+	}
+
+	if m.filter.editing {
+		m.handleFilterKey(msg.String(), msg.Runes)
+		m.updateViewport()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
 	switch msg.String() {
 	case "up", "k":
 		m.moveCursorUp()
@@ -79,10 +104,46 @@ func (m FileTreeModel) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.expandDirectory()
 	case " ":
 		m.toggleSelection()
+	case "o":
+		m.cycleRenderMode()
+	case "d":
+		cmd = m.expandDepsCmd(false)
+	case "D":
+		cmd = m.expandDepsCmd(true)
+	case "t":
+		m.toggleTestPairing(false)
+	case "T":
+		m.toggleTestPairing(true)
+	case "/":
+		m.filter.editing = true
+	case "g":
+		m.cancelGrep()
+		m.grep.editing = true
+	case "p":
+		m.togglePreview()
+	case "s":
+		m.toggleSizeSort()
+	case "G":
+		m.toggleGeneratedPathOnly()
+	case "J":
+		m.scrollPreview(1)
+	case "K":
+		m.scrollPreview(-1)
+	case "esc":
+		m.clearFilter()
+	case "a":
+		if m.filter.active() {
+			m.setMatchesSelected(true)
+		}
+	case "A":
+		if m.filter.active() {
+			m.setMatchesSelected(false)
+		}
 	}
 
 	m.updateViewport()
-	return m, nil
+	m.refreshPreview()
+	return m, cmd
 }
 
 // moveCursorUp moves cursor up one position
@@ -110,6 +171,11 @@ func (m *FileTreeModel) flattenTreeInternal(items []*models.FileNode, depth int)
 	var result []treeItem
 
 	for _, item := range items {
+		// An active filter hides everything but matches and their ancestors
+		if m.filter.active() && !m.filter.visible[item.Path] {
+			continue
+		}
+
 		result = append(result, treeItem{
 			node:  item,
 			depth: depth,
@@ -151,7 +217,8 @@ func (m *FileTreeModel) expandDirectory() {
 	}
 }
 
-// toggleSelection toggles selection for current item
+// toggleSelection cycles the current item through full, tree-only and excluded.
+// Directories apply the new state to every selectable node beneath them.
 func (m *FileTreeModel) toggleSelection() {
 	flatItems := m.getFlattenedItems()
 	if len(flatItems) == 0 || m.cursor >= len(flatItems) {
@@ -165,18 +232,13 @@ func (m *FileTreeModel) toggleSelection() {
 		return
 	}
 
-	currentItem.IsSelected = !currentItem.IsSelected
+	selection := currentItem.Selection().Next()
+	currentItem.SetSelection(selection)
 	m.selected[currentItem.Path] = currentItem.IsSelected
 
 	// Handle hierarchical selection for directories
 	if currentItem.IsDirectory {
-		if currentItem.IsSelected {
-			// When selecting a directory, select all non-binary children
-			m.selectChildren(currentItem, true)
-		} else {
-			// When deselecting a directory, deselect all children
-			m.deselectChildren(currentItem)
-		}
+		m.setChildrenSelection(currentItem, selection)
 	}
 
 	// Update parent selection state based on children
@@ -185,15 +247,56 @@ func (m *FileTreeModel) toggleSelection() {
 	}
 }
 
+// cycleRenderMode cycles the current item between full, outline and path-only rendering.
+// Files that cannot be outlined skip the outline state. Directories apply the new mode
+// to every file beneath them.
+func (m *FileTreeModel) cycleRenderMode() {
+	flatItems := m.getFlattenedItems()
+	if len(flatItems) == 0 || m.cursor >= len(flatItems) {
+		return
+	}
+
+	currentItem := flatItems[m.cursor].node
+	if currentItem.IsBinary {
+		return
+	}
+
+	mode := currentItem.RenderMode.Next()
+	if mode == models.RenderOutline && !currentItem.IsDirectory && !builder.SupportsOutline(currentItem.Path) {
+		mode = mode.Next()
+	}
+	setRenderMode(currentItem, mode)
+}
+
+// setRenderMode sets the render mode on a node and all its descendants. Outline only
+// reaches files that support it; other files keep their mode.
+func setRenderMode(node *models.FileNode, mode models.RenderMode) {
+	if node.IsDirectory || mode != models.RenderOutline || builder.SupportsOutline(node.Path) {
+		node.RenderMode = mode
+	}
+	for _, child := range node.Children {
+		setRenderMode(child, mode)
+	}
+}
+
 // selectChildren recursively selects/deselects all non-binary children
 func (m *FileTreeModel) selectChildren(node *models.FileNode, selected bool) {
+	selection := models.SelectionExcluded
+	if selected {
+		selection = models.SelectionFull
+	}
+	m.setChildrenSelection(node, selection)
+}
+
+// setChildrenSelection recursively applies a selection state to all non-binary children
+func (m *FileTreeModel) setChildrenSelection(node *models.FileNode, selection models.Selection) {
 	for _, child := range node.Children {
 		if !child.IsBinary { // Don't select binary files
-			child.IsSelected = selected
-			m.selected[child.Path] = selected
+			child.SetSelection(selection)
+			m.selected[child.Path] = child.IsSelected
 		}
 		if child.IsDirectory {
-			m.selectChildren(child, selected)
+			m.setChildrenSelection(child, selection)
 		}
 	}
 }
@@ -212,6 +315,7 @@ func (m *FileTreeModel) updateParentSelection(parent *models.FileNode) {
 	// Count selected and total selectable children
 	selectedChildren := 0
 	selectableChildren := 0
+	treeOnlyChildren := 0
 
 	for _, child := range parent.Children {
 		if !child.IsBinary { // Only count non-binary files
@@ -219,13 +323,17 @@ func (m *FileTreeModel) updateParentSelection(parent *models.FileNode) {
 			if child.IsSelected {
 				selectedChildren++
 			}
+			if child.TreeOnly {
+				treeOnlyChildren++
+			}
 		}
 	}
 
 	// Update parent selection based on children
 	if selectableChildren > 0 {
-		// Parent is selected if all selectable children are selected
+		// Parent is selected if all selectable children are selected, and tree-only if all are tree-only
 		parent.IsSelected = selectedChildren == selectableChildren
+		parent.TreeOnly = parent.IsSelected && treeOnlyChildren == selectableChildren
 		m.selected[parent.Path] = parent.IsSelected
 	}
 
@@ -268,6 +376,28 @@ func (m *FileTreeModel) collectSelectedFiles(nodes []*models.FileNode, selected
 	}
 }
 
+// GetRenderModes returns the non-default render modes of selected files, keyed by path.
+// Tree-only files are reported as path-only.
+func (m *FileTreeModel) GetRenderModes() map[string]models.RenderMode {
+	modes := make(map[string]models.RenderMode)
+	m.collectRenderModes(m.items, modes)
+	return modes
+}
+
+// collectRenderModes recursively collects render modes of selected files
+func (m *FileTreeModel) collectRenderModes(nodes []*models.FileNode, modes map[string]models.RenderMode) {
+	for _, node := range nodes {
+		if !node.IsDirectory && node.IsSelected && !node.IsBinary {
+			if mode := node.EffectiveRenderMode(); mode != models.RenderFull {
+				modes[node.Path] = mode
+			}
+		}
+		if node.IsDirectory && len(node.Children) > 0 {
+			m.collectRenderModes(node.Children, modes)
+		}
+	}
+}
+
 // SetSize updates the width and height of the model and viewport
 func (m *FileTreeModel) SetSize(width, height int) {
 	m.width = width
@@ -1,7 +1,12 @@
 package filetree
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -194,8 +199,15 @@ func TestHandleKeyPress_ToggleSelection(t *testing.T) {
 	updatedModel, _ := model.handleKeyPress(spaceKey)
 	m := updatedModel.(FileTreeModel)
 
-	if m.items[0].IsSelected {
-		t.Error("Expected file to be deselected after space key")
+	if !m.items[0].IsSelected || !m.items[0].TreeOnly {
+		t.Error("Expected file to be tree-only after first space key")
+	}
+
+	updatedModel, _ = m.handleKeyPress(spaceKey)
+	m = updatedModel.(FileTreeModel)
+
+	if m.items[0].IsSelected || m.items[0].TreeOnly {
+		t.Error("Expected file to be deselected after second space key")
 	}
 
 	// Test toggling binary file (should not change)
@@ -256,7 +268,13 @@ func TestToggleSelection_Hierarchical(t *testing.T) {
 	model.LoadFileTree([]*models.FileNode{parentDir})
 	model.cursor = 0
 
-	// Deselect parent directory
+	// Make the directory tree-only, then deselect it
+	model.toggleSelection()
+
+	if !parentDir.TreeOnly || !childFile.TreeOnly || !childFile.IsSelected {
+		t.Error("Expected tree-only state to apply to the directory and its children")
+	}
+
 	model.toggleSelection()
 
 	if parentDir.IsSelected {
@@ -502,3 +520,398 @@ func createTestTreeNodes() []*models.FileNode {
 		},
 	}
 }
+
+func TestCycleRenderMode(t *testing.T) {
+	model := NewFileTreeModel()
+	file := &models.FileNode{Path: "/p/dir/a.go", Name: "a.go"}
+	dir := &models.FileNode{Path: "/p/dir", Name: "dir", IsDirectory: true, IsExpanded: true, Children: []*models.FileNode{file}}
+	file.Parent = dir
+	model.LoadFileTree([]*models.FileNode{dir})
+
+	// Cursor on the directory applies the mode to its files
+	model.cycleRenderMode()
+	if file.RenderMode != models.RenderOutline {
+		t.Fatalf("Expected outline, got %q", file.RenderMode)
+	}
+	if modes := model.GetRenderModes(); modes["/p/dir/a.go"] != models.RenderOutline {
+		t.Errorf("Expected outline in render modes, got %v", modes)
+	}
+
+	model.cursor = 1
+	updated, _ := model.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'o'}})
+	model = updated.(FileTreeModel)
+	if file.RenderMode != models.RenderPathOnly {
+		t.Errorf("Expected path-only after cycling, got %q", file.RenderMode)
+	}
+
+	model.cycleRenderMode()
+	if file.RenderMode != models.RenderFull || len(model.GetRenderModes()) != 0 {
+		t.Errorf("Expected full rendering to be omitted from render modes")
+	}
+}
+
+func TestCycleRenderMode_SkipsOutlineForNonGoFiles(t *testing.T) {
+	model := NewFileTreeModel()
+	goFile := &models.FileNode{Path: "/p/dir/a.go", Name: "a.go"}
+	doc := &models.FileNode{Path: "/p/dir/README.md", Name: "README.md"}
+	dir := &models.FileNode{Path: "/p/dir", Name: "dir", IsDirectory: true, IsExpanded: true, Children: []*models.FileNode{doc, goFile}}
+	goFile.Parent, doc.Parent = dir, dir
+	model.LoadFileTree([]*models.FileNode{dir})
+
+	// Outline on the directory only reaches the Go file
+	model.cycleRenderMode()
+	if goFile.RenderMode != models.RenderOutline || doc.RenderMode == models.RenderOutline {
+		t.Fatalf("Expected outline on Go files only, got %q and %q", goFile.RenderMode, doc.RenderMode)
+	}
+
+	// A non-Go file goes straight from full to path-only
+	model.cursor = 1
+	model.cycleRenderMode()
+	if doc.RenderMode != models.RenderPathOnly || goFile.RenderMode != models.RenderOutline {
+		t.Errorf("Expected path-only after full, got %q", doc.RenderMode)
+	}
+}
+
+func TestDepsExpandedSelectsFiles(t *testing.T) {
+	model := NewFileTreeModel()
+	main := &models.FileNode{Path: "/p/cmd/main.go", Name: "main.go"}
+	store := &models.FileNode{Path: "/p/store/store.go", Name: "store.go"}
+	cmdDir := &models.FileNode{Path: "/p/cmd", Name: "cmd", IsDirectory: true, Children: []*models.FileNode{main}}
+	storeDir := &models.FileNode{Path: "/p/store", Name: "store", IsDirectory: true, Children: []*models.FileNode{store}}
+	main.Parent = cmdDir
+	store.Parent = storeDir
+	model.LoadFileTree([]*models.FileNode{cmdDir, storeDir})
+	store.IsSelected, storeDir.IsSelected = false, false
+
+	updated, _ := model.Update(DepsExpandedMsg{Files: []string{"/p/store/store.go"}})
+	model = updated.(FileTreeModel)
+
+	if !store.IsSelected || !storeDir.IsSelected {
+		t.Error("Expected dependency and its directory to be selected")
+	}
+	if !storeDir.IsExpanded {
+		t.Error("Expected ancestors of added files to be expanded")
+	}
+	if model.notice != "+1 files from imports" {
+		t.Errorf("Unexpected notice: %q", model.notice)
+	}
+}
+
+func TestToggleTestPairing(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"foo.go", "foo_test.go"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("package foo\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	model := NewFileTreeModel()
+	src := &models.FileNode{Path: filepath.Join(root, "foo.go"), Name: "foo.go"}
+	test := &models.FileNode{Path: filepath.Join(root, "foo_test.go"), Name: "foo_test.go"}
+	dir := &models.FileNode{Path: root, Name: "pkg", IsDirectory: true, Children: []*models.FileNode{src, test}}
+	src.Parent, test.Parent = dir, dir
+	model.LoadFileTree([]*models.FileNode{dir})
+	test.IsSelected, dir.IsSelected = false, false
+
+	updated, _ := model.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	model = updated.(FileTreeModel)
+	if !test.IsSelected || !dir.IsSelected {
+		t.Fatal("Expected the paired test to be selected")
+	}
+
+	model.toggleTestPairing(false)
+	if test.IsSelected || !src.IsSelected {
+		t.Error("Expected toggling off to deselect only the paired test")
+	}
+}
+
+func TestFilterMode(t *testing.T) {
+	model := NewFileTreeModel()
+	keys := &models.FileNode{Path: "/p/internal/app/keys.go", Name: "keys.go"}
+	view := &models.FileNode{Path: "/p/internal/app/view.go", Name: "view.go"}
+	readme := &models.FileNode{Path: "/p/README.md", Name: "README.md"}
+	app := &models.FileNode{Path: "/p/internal/app", Name: "app", IsDirectory: true, Children: []*models.FileNode{keys, view}}
+	internal := &models.FileNode{Path: "/p/internal", Name: "internal", IsDirectory: true, Children: []*models.FileNode{app}}
+	root := &models.FileNode{Path: "/p", Name: "p", IsDirectory: true, IsExpanded: true, Children: []*models.FileNode{internal, readme}}
+	keys.Parent, view.Parent, app.Parent, internal.Parent, readme.Parent = app, app, internal, root, root
+	model.LoadFileTree([]*models.FileNode{root})
+
+	press := func(keys ...string) {
+		for _, k := range keys {
+			msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+			switch k {
+			case "enter":
+				msg = tea.KeyMsg{Type: tea.KeyEnter}
+			case "esc":
+				msg = tea.KeyMsg{Type: tea.KeyEsc}
+			}
+			updated, _ := model.handleKeyPress(msg)
+			model = updated.(FileTreeModel)
+		}
+	}
+
+	// Fuzzy: "kys" matches internal/app/keys.go only; ancestors are expanded and shown
+	press("/", "k", "y", "s", "enter")
+	if !app.IsExpanded || !internal.IsExpanded {
+		t.Error("Expected ancestors of matches to be expanded")
+	}
+	if items := model.getFlattenedItems(); len(items) != 4 || items[3].node != keys {
+		t.Fatalf("Expected the root, internal, app and keys.go to be visible, got %d items", len(items))
+	}
+
+	press("A")
+	if keys.IsSelected || !view.IsSelected {
+		t.Error("Expected only the match to be deselected")
+	}
+	press("a")
+	if !keys.IsSelected {
+		t.Error("Expected the match to be selected again")
+	}
+
+	// Glob: a pattern without a slash matches base names at any depth
+	press("esc", "/", "*", ".", "g", "o", "enter")
+	if len(model.filter.matches) != 2 || model.filter.matches[readme.Path] {
+		t.Errorf("Expected *.go to match both Go files, got %v", model.filter.matches)
+	}
+	model.applyFilter("internal/**/keys.go")
+	if len(model.filter.matches) != 1 || !model.filter.matches[keys.Path] {
+		t.Errorf("Expected ** glob to match keys.go, got %v", model.filter.matches)
+	}
+
+	if !model.CapturesKey("esc") {
+		t.Error("An applied filter should capture esc")
+	}
+	press("esc")
+	if model.filter.active() || len(model.getFlattenedItems()) != 6 {
+		t.Error("Expected esc to clear the filter")
+	}
+}
+
+func TestFilterMode_MultipleRoots(t *testing.T) {
+	model := NewFileTreeModel()
+	var roots, files []*models.FileNode
+	for _, name := range []string{"api", "web"} {
+		file := &models.FileNode{Path: "/work/" + name + "/internal/keys.go", Name: "keys.go"}
+		dir := &models.FileNode{Path: "/work/" + name + "/internal", Name: "internal", IsDirectory: true, Children: []*models.FileNode{file}}
+		root := &models.FileNode{Path: "/work/" + name, Name: name, IsDirectory: true, Children: []*models.FileNode{dir}}
+		file.Parent, dir.Parent = dir, root
+		roots, files = append(roots, root), append(files, file)
+	}
+	model.LoadFileTree(roots)
+
+	model.applyFilter("internal/*.go")
+	if len(model.filter.matches) != 2 || !model.filter.matches[files[0].Path] || !model.filter.matches[files[1].Path] {
+		t.Errorf("Expected paths relative to each root to match, got %v", model.filter.matches)
+	}
+}
+
+func TestGrepMode(t *testing.T) {
+	root := t.TempDir()
+	hit := filepath.Join(root, "hit.go")
+	miss := filepath.Join(root, "miss.go")
+	os.WriteFile(hit, []byte("package a\n// TODO: fix\n"), 0644)
+	os.WriteFile(miss, []byte("package a\n"), 0644)
+
+	model := NewFileTreeModel()
+	hitNode := &models.FileNode{Path: hit, Name: "hit.go"}
+	missNode := &models.FileNode{Path: miss, Name: "miss.go"}
+	model.LoadFileTree([]*models.FileNode{hitNode, missNode})
+
+	for _, r := range "gTODO" {
+		updated, _ := model.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		model = updated.(FileTreeModel)
+	}
+	updated, cmd := model.handleKeyPress(tea.KeyMsg{Type: tea.KeyEnter})
+	model = updated.(FileTreeModel)
+	if cmd == nil {
+		t.Fatal("Expected enter to start the search")
+	}
+
+	updated, _ = model.Update(cmd())
+	model = updated.(FileTreeModel)
+	if items := model.getFlattenedItems(); len(items) != 1 || items[0].node != hitNode {
+		t.Fatalf("Expected only the matching file to be shown, got %d items", len(items))
+	}
+	if model.grepHits(hitNode) != 1 || len(model.grepPreview()) != 1 {
+		t.Error("Expected hit count and preview for the matching file")
+	}
+
+	updated, _ = model.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'A'}})
+	model = updated.(FileTreeModel)
+	if hitNode.IsSelected || !missNode.IsSelected {
+		t.Error("Expected bulk deselect to affect only matches")
+	}
+}
+
+func TestGrepMode_DropsStaleResults(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "main.go")
+	os.WriteFile(file, []byte("package main\n// TODO: fix\n"), 0644)
+
+	model := NewFileTreeModel()
+	model.LoadFileTree([]*models.FileNode{{Path: file, Name: "main.go"}})
+
+	start := func(pattern string) tea.Cmd {
+		t.Helper()
+		model.grep.query.Pattern = pattern
+		cmd := model.startGrep()
+		if cmd == nil {
+			t.Fatalf("Expected %q to start a search", pattern)
+		}
+		return cmd
+	}
+
+	// A new search cancels the first; the first search's results are dropped
+	first := start("TODO")
+	second := start("package")
+	if msg := first().(GrepCompleteMsg); !errors.Is(msg.Error, context.Canceled) {
+		t.Errorf("Expected the superseded search to be cancelled, got %v", msg.Error)
+	} else {
+		model.handleGrepComplete(msg)
+	}
+	if !model.grep.running || model.grep.results != nil {
+		t.Fatal("Expected stale results to be dropped while the new search runs")
+	}
+	model.handleGrepComplete(second().(GrepCompleteMsg))
+	if model.grep.running || model.filter.query != "package" {
+		t.Errorf("Expected results of the current search, got filter %q", model.filter.query)
+	}
+
+	// Restarting the same query drops the cancelled run and keeps the new one
+	first = start("package")
+	second = start("package")
+	model.handleGrepComplete(first().(GrepCompleteMsg))
+	if !model.grep.running || strings.Contains(model.notice, "failed") {
+		t.Fatalf("Expected the cancelled run to be dropped, got notice %q", model.notice)
+	}
+	model.handleGrepComplete(second().(GrepCompleteMsg))
+	if model.grep.running || model.grep.results == nil {
+		t.Error("Expected results of the restarted search")
+	}
+
+	// Esc cancels a running search
+	pending := start("fix")
+	if !model.CapturesKey("esc") {
+		t.Error("Expected esc to be captured while a search runs")
+	}
+	updated, _ := model.handleKeyPress(tea.KeyMsg{Type: tea.KeyEsc})
+	model = updated.(FileTreeModel)
+	model.handleGrepComplete(pending().(GrepCompleteMsg))
+	if model.grep.running || model.grep.results != nil || model.filter.active() {
+		t.Error("Expected results of a cancelled search to be dropped")
+	}
+}
+
+func TestPreviewPane(t *testing.T) {
+	root := t.TempDir()
+	code := filepath.Join(root, "main.go")
+	secret := filepath.Join(root, ".env")
+	os.WriteFile(code, []byte("package main\n\nfunc main() {}\n"), 0644)
+	os.WriteFile(secret, []byte("TOKEN=abc\n"), 0644)
+
+	model := NewFileTreeModel()
+	model.LoadFileTree([]*models.FileNode{
+		{Path: code, Name: "main.go"},
+		{Path: secret, Name: ".env"},
+	})
+	model.SetSize(120, 20)
+
+	updated, _ := model.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}})
+	model = updated.(FileTreeModel)
+	if !model.showPreview || model.preview.path != code {
+		t.Fatal("Expected p to show a preview of the cursor file")
+	}
+	if len(model.preview.lines) != 4 {
+		t.Errorf("Expected 4 content lines, got %d", len(model.preview.lines))
+	}
+	if view := model.View(); !strings.Contains(view, "tokens") || !strings.Contains(view, "func") {
+		t.Error("Expected the side-by-side view to include metadata and content")
+	}
+
+	updated, _ = model.handleKeyPress(tea.KeyMsg{Type: tea.KeyDown})
+	model = updated.(FileTreeModel)
+	if model.preview.path != secret || model.preview.lines != nil {
+		t.Error("Expected sensitive file content to be hidden from the preview")
+	}
+	if !strings.Contains(strings.Join(model.preview.header, "\n"), "sensitive") {
+		t.Error("Expected the sensitive flag in the preview header")
+	}
+
+	model.SetSize(60, 20)
+	if strings.Contains(model.View(), "sensitive") {
+		t.Error("Expected narrow terminals to fall back to a single column")
+	}
+}
+
+func TestSizeAnnotationsAndSort(t *testing.T) {
+	small := &models.FileNode{Path: "/p/a.go", Name: "a.go", Size: 400}
+	big := &models.FileNode{Path: "/p/vendor/lib.go", Name: "lib.go", Size: 4000}
+	vendor := &models.FileNode{Path: "/p/vendor", Name: "vendor", IsDirectory: true, IsExpanded: true,
+		Children: []*models.FileNode{big}}
+	big.Parent = vendor
+
+	model := NewFileTreeModel()
+	model.LoadFileTree([]*models.FileNode{vendor, small})
+
+	if got := model.selectedTotal(); got != 4400 {
+		t.Errorf("Expected 4400 selected bytes, got %d", got)
+	}
+	if !strings.Contains(sizeAnnotation(vendor), "~1.0k tokens") {
+		t.Errorf("Expected directory aggregate, got %q", sizeAnnotation(vendor))
+	}
+
+	// Deselecting the vendored file updates the aggregate and total
+	model.cursor = 1
+	model.toggleSelection()
+	if sizeAnnotation(vendor) != "" || model.selectedTotal() != 400 {
+		t.Error("Expected aggregates to follow selection")
+	}
+
+	model.cursor = 2
+	updated, _ := model.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	model = updated.(FileTreeModel)
+	items := model.getFlattenedItems()
+	if items[0].node != vendor || items[model.cursor].node != small {
+		t.Error("Expected largest first order with the cursor kept on its node")
+	}
+
+	// Directories come first again in the default order, even when smaller
+	big.Size = 10
+	updated, _ = model.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	model = updated.(FileTreeModel)
+	if model.getFlattenedItems()[0].node != vendor {
+		t.Error("Expected default directories-first order after toggling back")
+	}
+	updated, _ = model.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	model = updated.(FileTreeModel)
+	if model.getFlattenedItems()[0].node != small {
+		t.Error("Expected the larger file before the smaller directory")
+	}
+}
+
+func TestTreeOnlySelection(t *testing.T) {
+	migration := &models.FileNode{Path: "/p/migrations/001.sql", Name: "001.sql", Size: 800}
+	migrations := &models.FileNode{Path: "/p/migrations", Name: "migrations", IsDirectory: true, IsExpanded: true,
+		Children: []*models.FileNode{migration}}
+	migration.Parent = migrations
+	main := &models.FileNode{Path: "/p/main.go", Name: "main.go", Size: 100}
+
+	model := NewFileTreeModel()
+	model.LoadFileTree([]*models.FileNode{migrations, main})
+	model.toggleSelection()
+
+	files := model.GetSelectedFiles()
+	if len(files) != 2 {
+		t.Fatalf("Expected tree-only files to stay selected, got %v", files)
+	}
+	modes := model.GetRenderModes()
+	if modes[migration.Path] != models.RenderPathOnly || len(modes) != 1 {
+		t.Errorf("Expected tree-only file to render path-only, got %v", modes)
+	}
+	if model.selectedTotal() != 100 || sizeAnnotation(migrations) != "" {
+		t.Error("Expected tree-only files to add no content size")
+	}
+	if line := model.renderTreeItem(treeItem{node: migration, depth: 1}, false); !strings.Contains(line, "tree only") {
+		t.Errorf("Expected tree-only marker, got %q", line)
+	}
+}
@@ -23,6 +23,17 @@ var (
 			Foreground(lipgloss.Color("33")).
 			Bold(true)
 
+	renderModeStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("214")).
+			Italic(true)
+
+	attributeStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("244")).
+			Italic(true)
+
+	grepStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("40"))
+
 	statusStyle = lipgloss.NewStyle().
 			Background(lipgloss.Color("240")).
 			Foreground(lipgloss.Color("255")).
@@ -59,9 +70,20 @@ func (m FileTreeModel) View() string {
 		content.WriteString(line + "\n")
 	}
 
+	// Matching lines of the file under the cursor take space from the tree
+	preview := m.renderGrepPreview()
+	if n := strings.Count(preview, "\n"); n > 0 && m.viewport.Height > n {
+		m.viewport.Height -= n
+	}
+
 	m.viewport.SetContent(content.String())
 
-	return m.viewport.View() + "\n" + m.statusBar() + "\n" + m.helpBar()
+	main := m.viewport.View()
+	if m.showPreview && m.width > previewMinWidth {
+		main = m.renderSideBySideLayout(main)
+	}
+
+	return main + "\n" + preview + m.statusBar() + "\n" + m.helpBar()
 }
 
 // renderScanningState shows the spinner and progress during file scanning
@@ -147,6 +169,8 @@ func (m FileTreeModel) renderTreeItem(item treeItem, isSelected bool) string {
 	var checkbox string
 	if item.node.IsBinary {
 		checkbox = "⚫ " // Unselectable binary file
+	} else if item.node.TreeOnly {
+		checkbox = "🌲 " // Listed in the structure without content
 	} else if item.node.IsSelected {
 		checkbox = "✅ "
 	} else {
@@ -169,8 +193,37 @@ func (m FileTreeModel) renderTreeItem(item treeItem, isSelected bool) string {
 		name = binaryStyle.Render(name)
 	}
 
+	// Render mode marker for non-default rendering
+	var modeMarker string
+	switch {
+	case item.node.TreeOnly:
+		modeMarker = renderModeStyle.Render(" [tree only]")
+	case item.node.RenderMode == models.RenderOutline:
+		modeMarker = renderModeStyle.Render(" [outline]")
+	case item.node.RenderMode == models.RenderPathOnly:
+		modeMarker = renderModeStyle.Render(" [path only]")
+	}
+
+	// .gitattributes labels of files left out of the default selection
+	var attrMarker string
+	switch {
+	case item.node.IsGenerated:
+		attrMarker = attributeStyle.Render(" (generated)")
+	case item.node.IsVendored:
+		attrMarker = attributeStyle.Render(" (vendored)")
+	case item.node.IsExportIgnored:
+		attrMarker = attributeStyle.Render(" (export-ignore)")
+	}
+
+	// Content search hit count
+	var hitMarker string
+	if hits := m.grepHits(item.node); hits > 0 {
+		hitMarker = grepStyle.Render(fmt.Sprintf(" (%d hits)", hits))
+	}
+
 	// Combine all parts
-	line := treeStructure.String() + expandIndicator + checkbox + icon + name
+	line := treeStructure.String() + expandIndicator + checkbox + icon + name + modeMarker + attrMarker + hitMarker +
+		sizeAnnotation(item.node)
 
 	// Highlight current cursor position
 	if isSelected {
@@ -180,6 +233,15 @@ func (m FileTreeModel) renderTreeItem(item treeItem, isSelected bool) string {
 	return line
 }
 
+// renderGrepPreview renders the matching lines of the file under the cursor, one per line
+func (m FileTreeModel) renderGrepPreview() string {
+	var b strings.Builder
+	for _, line := range m.grepPreview() {
+		b.WriteString(grepStyle.Render(fmt.Sprintf("  %5d: %s", line.Number, line.Text)) + "\n")
+	}
+	return b.String()
+}
+
 // statusBar renders the status bar with file counts
 func (m FileTreeModel) statusBar() string {
 	selected, excluded, ignored := m.calculateCounts()
@@ -187,13 +249,51 @@ func (m FileTreeModel) statusBar() string {
 
 	// Create individual sections with icons
 	selectedText := fmt.Sprintf("✅ %d selected", selected)
+	if treeOnly := m.countTreeOnly(m.items); treeOnly > 0 {
+		selectedText += fmt.Sprintf(" (🌲 %d tree only)", treeOnly)
+	}
 	excludedText := fmt.Sprintf("⚫ %d excluded", excluded)
 	ignoredText := fmt.Sprintf("🚫 %d ignored", ignored)
 	totalText := fmt.Sprintf("📄 %d total", total)
+	sizeText := "📦 " + formatSizeTokens(m.selectedTotal())
 
 	// Combine sections with separators
-	status := fmt.Sprintf("%s  │  %s  │  %s  │  %s",
-		selectedText, excludedText, ignoredText, totalText)
+	status := fmt.Sprintf("%s  │  %s  │  %s  │  %s  │  %s",
+		selectedText, excludedText, ignoredText, totalText, sizeText)
+	if m.sortBySize {
+		status += "  │  ↓ largest first"
+	}
+	if m.grep.editing || m.grep.running {
+		mode := "regex"
+		if m.grep.query.Literal {
+			mode = "literal"
+		}
+		state := "typing"
+		if m.grep.running {
+			state = "searching…"
+		}
+		status += fmt.Sprintf("  │  🔎 grep %s (%s, %s)", m.grep.query.Pattern, mode, state)
+	} else if m.filter.active() || m.filter.editing {
+		mode := "fuzzy"
+		if m.filter.grep {
+			mode = "grep"
+		} else if m.filter.isGlob() {
+			mode = "glob"
+		}
+		status += fmt.Sprintf("  │  🔍 /%s (%s, %d matches)", m.filter.query, mode, len(m.filter.matches))
+	}
+	if m.generatedPathOnly {
+		status += "  │  ⚙ generated as path-only"
+	}
+	if m.pairedTests != nil {
+		status += "  │  🧪 tests paired"
+	}
+	if m.pairedSources != nil {
+		status += "  │  📎 sources paired"
+	}
+	if m.notice != "" {
+		status += "  │  " + m.notice
+	}
 
 	// Add padding to fill width if needed
 	if m.width > 0 {
@@ -211,8 +311,14 @@ func (m FileTreeModel) helpBar() string {
 	var help string
 	if m.scanning {
 		help = "ESC: cancel scanning │ Ctrl+Q: quit"
+	} else if m.grep.editing {
+		help = "Type to search file contents │ tab: regex/literal │ enter: search │ esc: cancel"
+	} else if m.filter.editing {
+		help = "Type to filter (fuzzy; glob with * ? [ ]) │ enter: apply │ backspace: delete │ esc: clear"
+	} else if m.filter.active() {
+		help = "↑/↓ or k/j: navigate │ space: toggle │ a: select matches │ A: deselect matches │ /: edit filter │ esc: clear filter"
 	} else {
-		help = "↑/↓ or k/j: navigate │ ←/→ or h/l: expand/collapse │ space: toggle │ Alt+C: continue │ Ctrl+Q: quit"
+		help = "↑/↓ or k/j: navigate │ ←/→ or h/l: expand/collapse │ space: full/tree/off │ o: full/outline/path │ d/D: deps/dependents │ t/T: tests/sources │ /: filter │ g: grep │ p: preview │ s: sort by size │ Alt+C: continue │ Ctrl+Q: quit"
 	}
 	return helpStyle.Render(help)
 }
@@ -243,3 +349,16 @@ func (m FileTreeModel) countNodes(nodes []*models.FileNode, selected, excluded,
 		}
 	}
 }
+
+// countTreeOnly counts selected files listed without content
+func (m FileTreeModel) countTreeOnly(nodes []*models.FileNode) int {
+	count := 0
+	for _, node := range nodes {
+		if node.IsDirectory {
+			count += m.countTreeOnly(node.Children)
+		} else if node.TreeOnly && node.IsSelected && !node.IsBinary {
+			count++
+		}
+	}
+	return count
+}
@@ -36,13 +36,30 @@ func WritePromptToFileCmd(result *builder.GeneratedPrompt) tea.Cmd {
 			}
 		}
 
-		writer := builder.NewFileWriter()
-		outputFile, err := writer.WritePromptFile(result.Content, "")
+		// Prompts streamed during generation are already on disk
+		outputFile := result.OutputFile
+		var err error
+		if outputFile == "" {
+			writer := builder.NewFileWriter()
+			outputFile, err = writer.WritePromptFile(result.Content, "")
+			result.OutputFile = outputFile
+		}
+
+		// Record the reproducibility manifest next to the prompt when requested;
+		// split prompts share one manifest named after the unsplit prompt.
+		// The prompt is already on disk, so a manifest failure is reported on its own.
+		var manifestFile string
+		var manifestErr error
+		if err == nil && result.Manifest != nil {
+			manifestFile, manifestErr = builder.WriteManifest(result.Manifest, result.ManifestTarget())
+		}
 
 		return FileWriteCompleteMsg{
-			Result:     result,
-			OutputFile: outputFile,
-			Error:      err,
+			Result:        result,
+			OutputFile:    outputFile,
+			ManifestFile:  manifestFile,
+			ManifestError: manifestErr,
+			Error:         err,
 		}
 	}
 }
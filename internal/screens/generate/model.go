@@ -22,6 +22,9 @@ type GenerateModel struct {
 	// Results
 	completed     bool
 	outputFile    string
+	manifestFile  string
+	manifestError error
+	partFiles     []string
 	generatedSize int64
 	error         error
 
@@ -69,6 +72,9 @@ func (m *GenerateModel) StartGeneration() {
 	m.completed = false
 	m.error = nil
 	m.outputFile = ""
+	m.manifestFile = ""
+	m.manifestError = nil
+	m.partFiles = nil
 	m.generatedSize = 0
 }
 
@@ -83,6 +89,7 @@ func (m *GenerateModel) CompleteGeneration(result *builder.GeneratedPrompt, outp
 		m.fileCount = result.FileCount
 		m.totalSize = result.TotalSize
 		m.generatedSize = result.TotalSize
+		m.partFiles = result.PartFiles
 	}
 
 	if outputFile != "" {
@@ -115,6 +122,21 @@ func (m *GenerateModel) GetOutputFile() string {
 	return m.outputFile
 }
 
+// GetPartFiles returns the numbered part files when the prompt was split
+func (m *GenerateModel) GetPartFiles() []string {
+	return m.partFiles
+}
+
+// GetManifestFile returns the path to the manifest written alongside the prompt, if any
+func (m *GenerateModel) GetManifestFile() string {
+	return m.manifestFile
+}
+
+// GetManifestError returns why the manifest could not be written, if it failed
+func (m *GenerateModel) GetManifestError() error {
+	return m.manifestError
+}
+
 // ToggleStats toggles the display of generation statistics
 func (m *GenerateModel) ToggleStats() {
 	m.showStats = !m.showStats
@@ -255,3 +255,22 @@ func TestShowingStats(t *testing.T) {
 		t.Error("ShowingStats should be false after toggle")
 	}
 }
+
+func TestFileWriteComplete_ManifestError(t *testing.T) {
+	model := NewGenerateModel()
+	model.StartGeneration()
+
+	result := &builder.GeneratedPrompt{FileCount: 1, TotalSize: 10}
+	updated, _ := model.Update(FileWriteCompleteMsg{
+		Result:        result,
+		OutputFile:    "/path/to/output.md",
+		ManifestError: errors.New("disk full"),
+	})
+
+	if updated.HasError() || !updated.IsCompleted() {
+		t.Error("A manifest failure should not fail the written prompt")
+	}
+	if updated.GetManifestError() == nil || updated.GetManifestFile() != "" {
+		t.Errorf("Manifest error should be reported on its own, got %v", updated.GetManifestError())
+	}
+}
@@ -66,6 +66,8 @@ func (m GenerateModel) Update(msg tea.Msg) (GenerateModel, tea.Cmd) {
 	case FileWriteCompleteMsg:
 		// File writing completed
 		m.CompleteGeneration(msg.Result, msg.OutputFile, msg.Error)
+		m.manifestFile = msg.ManifestFile
+		m.manifestError = msg.ManifestError
 
 	case GenerationCancelledMsg:
 		// Generation was cancelled
@@ -116,9 +118,11 @@ type StartGenerationMsg struct {
 
 // FileWriteCompleteMsg indicates file writing has completed
 type FileWriteCompleteMsg struct {
-	Result     *builder.GeneratedPrompt
-	OutputFile string
-	Error      error
+	Result        *builder.GeneratedPrompt
+	OutputFile    string
+	ManifestFile  string
+	ManifestError error // The prompt was written, but its manifest was not
+	Error         error
 }
 
 // GenerationCancelledMsg indicates generation was cancelled
@@ -122,6 +122,17 @@ func (m GenerateModel) renderSuccessView() string {
 		}
 		content.WriteString(fmt.Sprintf("Path: %s\n", infoStyle.Render(dir)))
 
+		if len(m.partFiles) > 1 {
+			content.WriteString(fmt.Sprintf("Parts: %s\n", infoStyle.Render(fmt.Sprintf("%d files (%s … %s)",
+				len(m.partFiles), filepath.Base(m.partFiles[0]), filepath.Base(m.partFiles[len(m.partFiles)-1])))))
+		}
+
+		if m.manifestFile != "" {
+			content.WriteString(fmt.Sprintf("Manifest: %s\n", infoStyle.Render(filepath.Base(m.manifestFile))))
+		} else if m.manifestError != nil {
+			content.WriteString(fmt.Sprintf("Manifest: %s\n", warningStyle.Render("not written: "+m.manifestError.Error())))
+		}
+
 		if m.generatedSize > 0 {
 			content.WriteString(fmt.Sprintf("Size: %s", infoStyle.Render(formatBytes(m.generatedSize))))
 			if m.totalSize > 0 && m.totalSize != m.generatedSize {